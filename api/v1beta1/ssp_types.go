@@ -17,15 +17,44 @@ limitations under the License.
 package v1beta1
 
 import (
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	admission "k8s.io/api/admissionregistration/v1"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	lifecycleapi "kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
 )
 
 const (
 	OperatorPausedAnnotation = "kubevirt.io/operator.paused"
+
+	// PausedAnnotation pauses reconciliation of the SSP resource, when set to "true"
+	PausedAnnotation = "ssp.kubevirt.io/paused"
+
+	// WatchedConfigMapLabel marks ConfigMaps watched by the operator, when set to "true".
+	// Other ConfigMaps are not cached, so their changes do not trigger reconciliation.
+	WatchedConfigMapLabel = "ssp.kubevirt.io/watched"
+
+	// MaxConcurrentRequestsLimit is the highest allowed value of TemplateValidator.MaxConcurrentRequests
+	MaxConcurrentRequestsLimit = 10000
+
+	// MaxLogVerbosity is the highest allowed value of TemplateValidator.LogVerbosity
+	MaxLogVerbosity = 10
+
+	// TLSProviderServiceCA uses the OpenShift service CA operator to provide the template validator certificate
+	TLSProviderServiceCA = "ServiceCA"
+
+	// TLSProviderSelfSigned uses a self-signed template validator certificate created by the operator
+	TLSProviderSelfSigned = "SelfSigned"
 )
 
 type TemplateValidator struct {
+	// Namespace is the k8s namespace where the template validator should be installed.
+	// If empty, the namespace of the SSP resource is used.
+	//+kubebuilder:validation:MaxLength=63
+	//+kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Namespace string `json:"namespace,omitempty"`
+
 	// Replicas is the number of replicas of the template validator pod
 	//+kubebuilder:validation:Minimum=0
 	//+kubebuilder:default=2
@@ -33,13 +62,335 @@ type TemplateValidator struct {
 
 	// Placement describes the node scheduling configuration
 	Placement *lifecycleapi.NodePlacement `json:"placement,omitempty"`
+
+	// PodLabels are additional labels added to the template validator pods.
+	// Labels used by the operator cannot be overwritten.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are additional annotations added to the template validator pods
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// ScrapeAnnotations adds the prometheus.io annotations to the template validator pods,
+	// so their metrics are collected on clusters using annotation based scraping.
+	// Annotations defined in PodAnnotations take precedence.
+	ScrapeAnnotations *bool `json:"scrapeAnnotations,omitempty"`
+
+	// WebhookFailurePolicy defines how errors from the template validator webhook are handled
+	//+kubebuilder:validation:Enum=Fail;Ignore
+	//+kubebuilder:default=Fail
+	WebhookFailurePolicy admission.FailurePolicyType `json:"webhookFailurePolicy,omitempty"`
+
+	// NamespaceSelector selects namespaces, where virtual machines are validated by the template validator webhook.
+	// If not set, virtual machines in all namespaces are validated.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// ObjectSelector selects virtual machines validated by the template validator webhook, based on their labels.
+	// If not set, all virtual machines are validated.
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// ImagePullSecrets are references to secrets used to pull the template validator image
+	ImagePullSecrets []core.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// ImagePullPolicy is the pull policy of the template validator image.
+	// If not set, the image is always pulled.
+	//+kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy core.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// CertExpiryWarningDays is the number of days before the serving certificate
+	// expiration, when the SSP starts reporting degraded condition.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=30
+	CertExpiryWarningDays int32 `json:"certExpiryWarningDays,omitempty"`
+
+	// TLSConfig configures TLS of the template validator webhook endpoint.
+	// It is only applied by template validator versions supporting it.
+	TLSConfig *TLSConfig `json:"tlsConfig,omitempty"`
+
+	// TLSProvider selects how the serving certificate of the template validator is provided.
+	// ServiceCA requires the OpenShift service CA operator, which creates the certificate
+	// and injects the CA bundle to the webhook. SelfSigned can be used on clusters without it,
+	// the operator creates a self-signed certificate, renews it before it expires,
+	// and uses it as the CA bundle of the webhook.
+	//+kubebuilder:validation:Enum=ServiceCA;SelfSigned
+	//+kubebuilder:default=ServiceCA
+	TLSProvider string `json:"tlsProvider,omitempty"`
+
+	// ProbeConfig configures the timing of the template validator liveness and readiness probes.
+	// Fields that are not set use default values.
+	ProbeConfig *ProbeConfig `json:"probeConfig,omitempty"`
+
+	// AuditWebhookURL is the URL, where the template validator posts its admission decisions,
+	// for example to forward them to a SIEM. It requires a template validator supporting
+	// the --audit-webhook-url argument. If empty, decisions are not posted.
+	AuditWebhookURL string `json:"auditWebhookURL,omitempty"`
+
+	// MaxConcurrentRequests is the maximum number of admission reviews processed
+	// by a template validator pod at the same time. Requests over the limit wait.
+	// It requires a template validator supporting the --max-concurrent-requests argument.
+	// If not set, the number of requests is not limited.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=10000
+	MaxConcurrentRequests *int32 `json:"maxConcurrentRequests,omitempty"`
+
+	// LogVerbosity is the verbosity level of the template validator logs
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=10
+	//+kubebuilder:default=2
+	LogVerbosity *int32 `json:"logVerbosity,omitempty"`
+
+	// Resources are the compute resources of the template validator container.
+	// If not set, default requests are used.
+	Resources *core.ResourceRequirements `json:"resources,omitempty"`
 }
 
+// ProbeConfig defines the timing of container probes
+type ProbeConfig struct {
+	// InitialDelay is the number of seconds after the container has started before probes are initiated
+	//+kubebuilder:validation:Minimum=0
+	InitialDelay *int32 `json:"initialDelay,omitempty"`
+
+	// Period is how often, in seconds, to perform the probe
+	//+kubebuilder:validation:Minimum=1
+	Period *int32 `json:"period,omitempty"`
+
+	// Timeout is the number of seconds after which the probe times out
+	//+kubebuilder:validation:Minimum=1
+	Timeout *int32 `json:"timeout,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures, after which the probe is considered failed
+	//+kubebuilder:validation:Minimum=1
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// TLSConfig defines TLS settings of a served endpoint
+type TLSConfig struct {
+	// MinVersion is the minimum TLS version accepted by the server.
+	// If not set, the server default is used.
+	//+kubebuilder:validation:Enum=VersionTLS12;VersionTLS13
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// CipherSuites is the list of enabled cipher suites, using IANA names,
+	// for example TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// Cipher suites cannot be configured for TLS 1.3.
+	// If not set, the server default cipher suites are used.
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+
+	// DisableHTTP2 disables HTTP/2 on the endpoint, only HTTP/1.1 is served
+	DisableHTTP2 bool `json:"disableHTTP2,omitempty"`
+}
+
+const (
+	TLSVersion12 = "VersionTLS12"
+	TLSVersion13 = "VersionTLS13"
+)
+
 type CommonTemplates struct {
-	// Namespace is the k8s namespace where CommonTemplates should be installed
+	// Namespace is the k8s namespace where CommonTemplates should be installed.
+	// When it is changed, templates are moved from the previous namespace.
+	//+kubebuilder:validation:MaxLength=63
+	//+kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Namespace string `json:"namespace"`
+
+	// Enabled enables deployment of common templates.
+	// If false, templates previously deployed by the operator are removed.
+	//+kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// GoldenImagesEnabled enables reconciliation of the golden images namespace and its RBAC.
+	// If false, the RBAC previously created by the operator is removed,
+	// the golden images namespace is kept, because it may contain images.
+	//+kubebuilder:default=true
+	GoldenImagesEnabled *bool `json:"goldenImagesEnabled,omitempty"`
+
+	// GoldenImagesNamespace is the k8s namespace where golden images are stored.
+	// If empty, "kubevirt-os-images" is used.
 	//+kubebuilder:validation:MaxLength=63
 	//+kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	GoldenImagesNamespace string `json:"goldenImagesNamespace,omitempty"`
+
+	// DeleteOldGoldenImagesNamespaces enables deletion of golden images namespaces
+	// previously created by the operator, after the GoldenImagesNamespace was changed.
+	// Namespaces that still contain PersistentVolumeClaims are never deleted.
+	DeleteOldGoldenImagesNamespaces bool `json:"deleteOldGoldenImagesNamespaces,omitempty"`
+
+	// ManageGoldenImagesNamespace enables creating and updating the golden images namespace.
+	// If false, the namespace has to be created by the admin and the operator only checks
+	// that it exists. The namespace is also not deleted when the SSP CR is removed.
+	//+kubebuilder:default=true
+	ManageGoldenImagesNamespace *bool `json:"manageGoldenImagesNamespace,omitempty"`
+
+	// GoldenImagesNamespaceLabels are added to the managed golden images namespace.
+	// Other labels on the namespace are kept, labels used by the operator cannot be overwritten.
+	GoldenImagesNamespaceLabels map[string]string `json:"goldenImagesNamespaceLabels,omitempty"`
+
+	// GoldenImagesNamespaceAnnotations are added to the managed golden images namespace.
+	// Other annotations on the namespace are kept.
+	GoldenImagesNamespaceAnnotations map[string]string `json:"goldenImagesNamespaceAnnotations,omitempty"`
+
+	// AdditionalViewRoleSubjects are added to the RoleBindings granting view access to golden images.
+	// Subjects added to the RoleBindings directly in the cluster are kept as well.
+	AdditionalViewRoleSubjects []rbac.Subject `json:"additionalViewRoleSubjects,omitempty"`
+
+	// AggregateGoldenImagesViewRole enables a ClusterRole aggregated into the default "view" and "edit"
+	// ClusterRoles, that allows reading golden images wherever these roles are bound.
+	// If false, the ClusterRole is removed and users have to be bound to the view Role
+	// in the golden images namespace.
+	//+kubebuilder:default=true
+	AggregateGoldenImagesViewRole *bool `json:"aggregateGoldenImagesViewRole,omitempty"`
+
+	// AdditionalGoldenImageNamespaces are namespaces, where golden images are stored in addition
+	// to the GoldenImagesNamespace. The namespaces have to be created by the admin, the operator
+	// only creates the view Role and RoleBinding in them.
+	AdditionalGoldenImageNamespaces []string `json:"additionalGoldenImageNamespaces,omitempty"`
+
+	// DefaultVMLabels are added to VirtualMachines defined in common templates.
+	// Labels already defined by a template are not overwritten.
+	DefaultVMLabels map[string]string `json:"defaultVMLabels,omitempty"`
+
+	// DefaultVMAnnotations are added to VirtualMachines defined in common templates.
+	// Annotations already defined by a template are not overwritten.
+	DefaultVMAnnotations map[string]string `json:"defaultVMAnnotations,omitempty"`
+
+	// DefaultSnapshotClass is the VolumeSnapshotClass set to VirtualMachines defined in common templates.
+	// The snapshot class already defined by a template is not overwritten.
+	DefaultSnapshotClass string `json:"defaultSnapshotClass,omitempty"`
+
+	// DefaultMigrationPolicy is the name of a KubeVirt MigrationPolicy applied to VirtualMachines defined
+	// in common templates. The labels selected by the policy are added to the VirtualMachine instances.
+	// Labels already defined by a template are not overwritten.
+	DefaultMigrationPolicy string `json:"defaultMigrationPolicy,omitempty"`
+
+	// DefaultMemoryBallooning enables or disables the memory balloon device
+	// in VirtualMachines defined in common templates.
+	// The value already defined by a template is not overwritten.
+	DefaultMemoryBallooning *bool `json:"defaultMemoryBallooning,omitempty"`
+
+	// SecureBootByOS enables or disables secure boot in VirtualMachines defined in common templates
+	// for the given operating system, for example "win10". Enabling secure boot also enables EFI and SMM.
+	// Values already defined by a template are not overwritten.
+	SecureBootByOS map[string]bool `json:"secureBootByOS,omitempty"`
+
+	// TPMByOS enables or disables a virtual TPM device in VirtualMachines defined in common templates
+	// for the given operating system, for example "win11". An enabled TPM keeps its state
+	// in a persistent volume, that requires the VM state storage class to be configured in KubeVirt.
+	// Values already defined by a template are not overwritten.
+	TPMByOS map[string]bool `json:"tpmByOS,omitempty"`
+
+	// DefaultAccessCredentials are added to VirtualMachines defined in common templates.
+	// Access credentials already defined by a template are not overwritten.
+	DefaultAccessCredentials *DefaultAccessCredentials `json:"defaultAccessCredentials,omitempty"`
+
+	// DedicatedCPUForWorkloads enables dedicated CPU placement in VirtualMachines defined in common templates
+	// labeled with one of the given workloads, for example "highperformance".
+	// Values already defined by a template are not overwritten.
+	DedicatedCPUForWorkloads []string `json:"dedicatedCPUForWorkloads,omitempty"`
+
+	// EnableHotplugForWorkloads enables hot-plug of disks in VirtualMachines defined in common templates
+	// labeled with one of the given workloads. It requires the HotplugVolumes feature gate in KubeVirt.
+	// Values already defined by a template are not overwritten.
+	EnableHotplugForWorkloads []string `json:"enableHotplugForWorkloads,omitempty"`
+
+	// SchedulerByWorkload sets the scheduler name in VirtualMachines defined in common templates
+	// labeled with the given workload, for example {"highperformance": "vm-scheduler"}.
+	// If a template has multiple workloads, the first one in alphabetical order is used.
+	// Values already defined by a template are not overwritten.
+	// A scheduler is reported as not running, unless it holds a leader election Lease
+	// with its name in the kube-system namespace, that was renewed within its lease duration.
+	SchedulerByWorkload map[string]string `json:"schedulerByWorkload,omitempty"`
+
+	// PruneRemovedTemplates enables deletion of templates of the current version,
+	// that were deployed by the operator, but are no longer part of the bundle.
+	PruneRemovedTemplates bool `json:"pruneRemovedTemplates,omitempty"`
+
+	// DeprecatedTemplatesRetention is the time after which templates
+	// from older bundle versions are deleted. If not set, they are kept.
+	DeprecatedTemplatesRetention *metav1.Duration `json:"deprecatedTemplatesRetention,omitempty"`
+
+	// VerifyBundleIntegrity enables verification of the templates bundle file
+	// against the SHA-256 checksum file shipped with it.
+	VerifyBundleIntegrity bool `json:"verifyBundleIntegrity,omitempty"`
+
+	// PermissiveBundleLoading deploys templates from valid documents of the bundle file,
+	// even if other documents cannot be decoded or contain malformed templates.
+	// The failed documents are reported in the status.
+	PermissiveBundleLoading bool `json:"permissiveBundleLoading,omitempty"`
+
+	// BundleConfigMap references a ConfigMap containing the templates bundle.
+	// If set, templates are loaded from the ConfigMap instead of the bundle shipped with the operator.
+	// The ConfigMap should have the "ssp.kubevirt.io/watched" label set to "true",
+	// otherwise its changes are only loaded on the next reconciliation.
+	BundleConfigMap *BundleConfigMapReference `json:"bundleConfigMap,omitempty"`
+
+	// AdditionalBundles are paths of additional templates bundle files, or directories containing them.
+	// Their templates are deployed together with the templates from the main bundle.
+	// Template names must be unique across all bundles.
+	AdditionalBundles []string `json:"additionalBundles,omitempty"`
+
+	// Filters select which templates from the bundle are deployed.
+	// If not set, all templates are deployed.
+	Filters *TemplateFilters `json:"filters,omitempty"`
+
+	// ExpectedOSFamilies are operating system families, for example "fedora" or "win",
+	// that should be covered by at least one template in the bundle, that is not deprecated.
+	// Missing families are reported in the SSP status, templates are still deployed.
+	ExpectedOSFamilies []string `json:"expectedOSFamilies,omitempty"`
+
+	// MaxTemplateParameters is the maximum number of parameters a template should have.
+	// Templates with more parameters are reported in the SSP status, but are still deployed,
+	// unless StrictMaxTemplateParameters is set. If not set, the number is not limited.
+	//+kubebuilder:validation:Minimum=1
+	MaxTemplateParameters *int32 `json:"maxTemplateParameters,omitempty"`
+
+	// StrictMaxTemplateParameters prevents deployment of templates
+	// with more parameters than MaxTemplateParameters.
+	StrictMaxTemplateParameters bool `json:"strictMaxTemplateParameters,omitempty"`
+
+	// PreserveParameterDefaults keeps the value and generator of template parameters
+	// changed in the cluster, for example by an admin. Parameters added to the bundle
+	// are still added and parameters removed from the bundle are removed.
+	// Other parameter fields are always taken from the bundle.
+	PreserveParameterDefaults bool `json:"preserveParameterDefaults,omitempty"`
+}
+
+// DefaultAccessCredentials defines SSH public keys propagated to VirtualMachines
+type DefaultAccessCredentials struct {
+	// SecretName is the name of the secret containing SSH public keys.
+	// The secret has to exist in the golden images namespace.
+	SecretName string `json:"secretName"`
+
+	// Users are the guest users, to which the keys are propagated using the QEMU guest agent
+	//+kubebuilder:validation:MinItems=1
+	Users []string `json:"users"`
+
+	// OperatingSystems limits the defaults to templates for the given operating systems, for example "fedora33".
+	// If empty, the defaults are added to all templates.
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+}
+
+// BundleConfigMapReference references a key in a ConfigMap containing a templates bundle.
+type BundleConfigMapReference struct {
+	// Namespace of the ConfigMap
 	Namespace string `json:"namespace"`
+
+	// Name of the ConfigMap
+	Name string `json:"name"`
+
+	// Key in the ConfigMap data containing the bundle.
+	// If empty, "common-templates.yaml" is used.
+	Key string `json:"key,omitempty"`
+}
+
+// TemplateFilters select common templates by their labels.
+// A template is deployed, if it matches all non-empty lists.
+type TemplateFilters struct {
+	// OperatingSystems is a list of OS names, for example "fedora33"
+	OperatingSystems []string `json:"operatingSystems,omitempty"`
+
+	// Workloads is a list of workload names, for example "server"
+	Workloads []string `json:"workloads,omitempty"`
+
+	// Flavors is a list of flavor names, for example "small"
+	Flavors []string `json:"flavors,omitempty"`
 }
 
 type NodeLabeller struct {
@@ -68,6 +419,110 @@ type SSPStatus struct {
 
 	// ObservedGeneration is the latest generation observed by the operator.
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// CommonTemplates describes the common templates deployed by the last reconciliation.
+	// +optional
+	CommonTemplates *CommonTemplatesStatus `json:"commonTemplates,omitempty"`
+
+	// CommonTemplatesVersion is the version of the common templates bundle,
+	// that was last deployed without failed templates.
+	// The version of the operator is in the ObservedVersion field.
+	// +optional
+	CommonTemplatesVersion string `json:"commonTemplatesVersion,omitempty"`
+
+	// TemplateValidator describes the deployed template validator.
+	// +optional
+	TemplateValidator *TemplateValidatorStatus `json:"templateValidator,omitempty"`
+
+	// LastUpgrade summarizes the changes made by the last upgrade of the operator.
+	// +optional
+	LastUpgrade *UpgradeSummary `json:"lastUpgrade,omitempty"`
+
+	// Operands describe the state of resources reconciled by each operand
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Operands []OperandStatus `json:"operands,omitempty"`
+}
+
+// OperandStatus describes the state of resources reconciled by an operand
+type OperandStatus struct {
+	// Name is the name of the operand
+	Name string `json:"name"`
+
+	// Conditions are the Available, Progressing and Degraded conditions of the operand resources
+	// +optional
+	Conditions []conditionsv1.Condition `json:"conditions,omitempty"`
+}
+
+// UpgradeSummary describes the changes made to operands after the operator version changed
+type UpgradeSummary struct {
+	// PreviousVersion is the operator version before the upgrade
+	PreviousVersion string `json:"previousVersion,omitempty"`
+
+	// Version is the operator version after the upgrade
+	Version string `json:"version,omitempty"`
+
+	// CompletionTime is the time when all resources were deployed with the new version.
+	// It is not set while the upgrade is in progress.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// AddedTemplates is the number of common templates created during the upgrade
+	AddedTemplates int `json:"addedTemplates"`
+
+	// UpdatedTemplates is the number of common templates updated to the new bundle version
+	UpdatedTemplates int `json:"updatedTemplates"`
+
+	// DeprecatedTemplates is the number of common templates deprecated during the upgrade
+	DeprecatedTemplates int `json:"deprecatedTemplates"`
+
+	// PreviousValidatorImage is the template validator image before the upgrade
+	// +optional
+	PreviousValidatorImage string `json:"previousValidatorImage,omitempty"`
+
+	// ValidatorImage is the template validator image after the upgrade
+	// +optional
+	ValidatorImage string `json:"validatorImage,omitempty"`
+}
+
+// TemplateValidatorStatus defines the observed state of the template validator
+type TemplateValidatorStatus struct {
+	// Namespace is the namespace where the template validator was last deployed.
+	// When the configured namespace changes, the validator resources are removed
+	// from this namespace after they are deployed to the new one.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CommonTemplatesStatus defines the observed state of common templates
+type CommonTemplatesStatus struct {
+	// Version is the version of the deployed common templates bundle
+	Version string `json:"version,omitempty"`
+
+	// Namespace is the namespace where the templates were last deployed.
+	// When the configured namespace changes, templates deployed by the operator
+	// are removed from this namespace after they are deployed to the new one.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// DeployedTemplates is the number of templates from the bundle reconciled successfully
+	DeployedTemplates int `json:"deployedTemplates"`
+
+	// DeprecatedTemplates is the number of templates from older bundle versions found in the cluster
+	DeprecatedTemplates int `json:"deprecatedTemplates"`
+
+	// FailedTemplates is the number of templates that failed to reconcile
+	FailedTemplates int `json:"failedTemplates"`
+
+	// FirstError is the error message of the first template that failed to reconcile
+	// +optional
+	FirstError string `json:"firstError,omitempty"`
+
+	// FailedTemplateNames are the names of templates that failed to reconcile.
+	// At most 10 names are listed, the remaining templates are summarized as "+N more".
+	// +optional
+	FailedTemplateNames []string `json:"failedTemplateNames,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -18,13 +18,17 @@ package v1beta1
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/url"
 
 	"github.com/pkg/errors"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 	"kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -62,6 +66,10 @@ func (r *SSP) ValidateCreate() error {
 		return fmt.Errorf("creation failed, an SSP CR already exists in namespace %v: %v", ssps.Items[0].ObjectMeta.Namespace, ssps.Items[0].ObjectMeta.Name)
 	}
 
+	if err = validateSpec(r); err != nil {
+		return fmt.Errorf("creation failed, %v", err)
+	}
+
 	// Check if the common templates namespace exists
 	namespaceName := r.Spec.CommonTemplates.Namespace
 	var namespace v1.Namespace
@@ -70,6 +78,14 @@ func (r *SSP) ValidateCreate() error {
 		return fmt.Errorf("creation failed, the configured namespace for common templates does not exist: %v", namespaceName)
 	}
 
+	if err = validateTemplateValidatorNamespace(r); err != nil {
+		return fmt.Errorf("creation failed, %v", err)
+	}
+
+	if err = validateTLSConfig(r.Spec.TemplateValidator.TLSConfig); err != nil {
+		return fmt.Errorf("creation failed, %v", err)
+	}
+
 	if err = validatePlacement(r); err != nil {
 		return errors.Wrap(err, "placement api validation error")
 	}
@@ -81,11 +97,28 @@ func (r *SSP) ValidateCreate() error {
 func (r *SSP) ValidateUpdate(old runtime.Object) error {
 	ssplog.Info("validate update", "name", r.Name)
 
+	if err := validateSpec(r); err != nil {
+		return fmt.Errorf("update failed, %v", err)
+	}
+
+	// Templates are moved by the operator, when the namespace is changed,
+	// so only the new namespace has to exist
 	oldSsp := old.(*SSP)
 	if r.Spec.CommonTemplates.Namespace != oldSsp.Spec.CommonTemplates.Namespace {
-		return fmt.Errorf("commonTemplates.namespace cannot be changed. Attempting to change from: %v to %v",
-			oldSsp.Spec.CommonTemplates.Namespace,
-			r.Spec.CommonTemplates.Namespace)
+		namespaceName := r.Spec.CommonTemplates.Namespace
+		var namespace v1.Namespace
+		err := clt.Get(context.TODO(), client.ObjectKey{Name: namespaceName}, &namespace)
+		if err != nil {
+			return fmt.Errorf("update failed, the configured namespace for common templates does not exist: %v", namespaceName)
+		}
+	}
+
+	if err := validateTemplateValidatorNamespace(r); err != nil {
+		return fmt.Errorf("update failed, %v", err)
+	}
+
+	if err := validateTLSConfig(r.Spec.TemplateValidator.TLSConfig); err != nil {
+		return fmt.Errorf("update failed, %v", err)
 	}
 
 	if err := validatePlacement(r); err != nil {
@@ -105,6 +138,123 @@ func setClientForWebhook(c client.Client) {
 	clt = c
 }
 
+// validateSpec checks the spec fields that can be validated without accessing the cluster.
+// All problems are reported together, each prefixed with the path of the field.
+func validateSpec(ssp *SSP) error {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	validatorPath := specPath.Child("templateValidator")
+	validator := &ssp.Spec.TemplateValidator
+	if validator.Namespace != "" {
+		errs = append(errs, validateNamespaceName(validatorPath.Child("namespace"), validator.Namespace)...)
+	}
+	if validator.Replicas != nil && *validator.Replicas < 0 {
+		errs = append(errs, field.Invalid(validatorPath.Child("replicas"), *validator.Replicas, "must not be negative"))
+	}
+
+	if validator.MaxConcurrentRequests != nil &&
+		(*validator.MaxConcurrentRequests < 1 || *validator.MaxConcurrentRequests > MaxConcurrentRequestsLimit) {
+		errs = append(errs, field.Invalid(validatorPath.Child("maxConcurrentRequests"), *validator.MaxConcurrentRequests,
+			fmt.Sprintf("must be between 1 and %d", MaxConcurrentRequestsLimit)))
+	}
+
+	if validator.LogVerbosity != nil &&
+		(*validator.LogVerbosity < 0 || *validator.LogVerbosity > MaxLogVerbosity) {
+		errs = append(errs, field.Invalid(validatorPath.Child("logVerbosity"), *validator.LogVerbosity,
+			fmt.Sprintf("must be between 0 and %d", MaxLogVerbosity)))
+	}
+
+	if validator.AuditWebhookURL != "" {
+		errs = append(errs, validateWebhookURL(validatorPath.Child("auditWebhookURL"), validator.AuditWebhookURL)...)
+	}
+
+	templatesPath := specPath.Child("commonTemplates")
+	templates := &ssp.Spec.CommonTemplates
+	if templates.Namespace == "" {
+		errs = append(errs, field.Required(templatesPath.Child("namespace"), "namespace for common templates must be set"))
+	} else {
+		errs = append(errs, validateNamespaceName(templatesPath.Child("namespace"), templates.Namespace)...)
+	}
+	if templates.GoldenImagesNamespace != "" {
+		errs = append(errs, validateNamespaceName(templatesPath.Child("goldenImagesNamespace"), templates.GoldenImagesNamespace)...)
+	}
+	for i, namespace := range templates.AdditionalGoldenImageNamespaces {
+		errs = append(errs, validateNamespaceName(templatesPath.Child("additionalGoldenImageNamespaces").Index(i), namespace)...)
+	}
+	if templates.ManageGoldenImagesNamespace != nil && !*templates.ManageGoldenImagesNamespace && templates.DeleteOldGoldenImagesNamespaces {
+		errs = append(errs, field.Invalid(templatesPath.Child("deleteOldGoldenImagesNamespaces"), true,
+			"cannot be enabled when manageGoldenImagesNamespace is false"))
+	}
+	if templates.DeprecatedTemplatesRetention != nil && templates.DeprecatedTemplatesRetention.Duration < 0 {
+		errs = append(errs, field.Invalid(templatesPath.Child("deprecatedTemplatesRetention"),
+			templates.DeprecatedTemplatesRetention.Duration.String(), "must not be negative"))
+	}
+	if configMap := templates.BundleConfigMap; configMap != nil {
+		configMapPath := templatesPath.Child("bundleConfigMap")
+		if configMap.Namespace == "" {
+			errs = append(errs, field.Required(configMapPath.Child("namespace"), "namespace of the bundle ConfigMap must be set"))
+		} else {
+			errs = append(errs, validateNamespaceName(configMapPath.Child("namespace"), configMap.Namespace)...)
+		}
+		if configMap.Name == "" {
+			errs = append(errs, field.Required(configMapPath.Child("name"), "name of the bundle ConfigMap must be set"))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(configMap.Name) {
+				errs = append(errs, field.Invalid(configMapPath.Child("name"), configMap.Name, msg))
+			}
+		}
+		if templates.VerifyBundleIntegrity {
+			errs = append(errs, field.Invalid(templatesPath.Child("verifyBundleIntegrity"), true,
+				"cannot be enabled together with bundleConfigMap, only the bundle file shipped with the operator can be verified"))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+func validateNamespaceName(path *field.Path, name string) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range validation.IsDNS1123Label(name) {
+		errs = append(errs, field.Invalid(path, name, msg))
+	}
+	return errs
+}
+
+func validateTemplateValidatorNamespace(ssp *SSP) error {
+	namespaceName := ssp.Spec.TemplateValidator.Namespace
+	if namespaceName == "" {
+		return nil
+	}
+	var namespace v1.Namespace
+	err := clt.Get(context.TODO(), client.ObjectKey{Name: namespaceName}, &namespace)
+	if err != nil {
+		return fmt.Errorf("the configured namespace for template validator does not exist: %v", namespaceName)
+	}
+	return nil
+}
+
+// validateTLSConfig checks that the configured cipher suites are known and secure.
+// Go does not allow configuring cipher suites for TLS 1.3.
+func validateTLSConfig(config *TLSConfig) error {
+	if config == nil || len(config.CipherSuites) == 0 {
+		return nil
+	}
+	if config.MinVersion == TLSVersion13 {
+		return fmt.Errorf("cipher suites cannot be configured with minimal TLS version %s", TLSVersion13)
+	}
+	allowed := make(map[string]struct{}, len(tls.CipherSuites()))
+	for _, suite := range tls.CipherSuites() {
+		allowed[suite.Name] = struct{}{}
+	}
+	for _, name := range config.CipherSuites {
+		if _, ok := allowed[name]; !ok {
+			return fmt.Errorf("invalid or insecure TLS cipher suite: %v", name)
+		}
+	}
+	return nil
+}
+
 func validatePlacement(ssp *SSP) error {
 	return validateOperandPlacement(ssp.Spec.TemplateValidator.Placement)
 }
@@ -160,3 +310,17 @@ func validateOperandPlacement(placement *api.NodePlacement) error {
 
 	return clt.Create(context.TODO(), deployment, &client.CreateOptions{DryRun: []string{metav1.DryRunAll}})
 }
+
+func validateWebhookURL(path *field.Path, value string) field.ErrorList {
+	parsed, err := url.ParseRequestURI(value)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, value, err.Error())}
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return field.ErrorList{field.Invalid(path, value, "scheme must be http or https")}
+	}
+	if parsed.Host == "" {
+		return field.ErrorList{field.Invalid(path, value, "host must be set")}
+	}
+	return nil
+}
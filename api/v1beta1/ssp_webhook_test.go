@@ -18,13 +18,16 @@ package v1beta1
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -116,27 +119,267 @@ var _ = Describe("SSP Validation", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("creation failed, the configured namespace for common templates does not exist: " + nonexistingNamespace))
 		})
-	})
 
-	It("should not allow update of commonTemplates.namespace", func() {
-		oldSsp := &SSP{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-ssp",
-				Namespace: "test-ns",
-			},
-			Spec: SSPSpec{
-				CommonTemplates: CommonTemplates{
-					Namespace: "old-ns",
+		It("should fail if template validator namespace does not exist", func() {
+			const nonexistingNamespace = "nonexisting-namespace"
+			ssp := &SSP{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ssp",
+					Namespace: "test-ns",
 				},
+				Spec: SSPSpec{
+					TemplateValidator: TemplateValidator{
+						Namespace: nonexistingNamespace,
+					},
+					CommonTemplates: CommonTemplates{
+						Namespace: templatesNamespace,
+					},
+				},
+			}
+			err := ssp.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("creation failed, the configured namespace for template validator does not exist: " + nonexistingNamespace))
+		})
+
+		Context("with invalid spec", func() {
+			newSsp := func() *SSP {
+				return &SSP{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-ssp",
+						Namespace: "test-ns",
+					},
+					Spec: SSPSpec{
+						CommonTemplates: CommonTemplates{
+							Namespace: templatesNamespace,
+						},
+					},
+				}
+			}
+
+			It("should accept valid spec", func() {
+				ssp := newSsp()
+				ssp.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(0)
+				ssp.Spec.TemplateValidator.AuditWebhookURL = "https://siem.example.com:8443/audit"
+				ssp.Spec.CommonTemplates.GoldenImagesNamespace = "custom-os-images"
+				ssp.Spec.CommonTemplates.ManageGoldenImagesNamespace = pointer.BoolPtr(false)
+				ssp.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: time.Hour}
+				ssp.Spec.CommonTemplates.BundleConfigMap = &BundleConfigMapReference{
+					Namespace: "bundle-ns",
+					Name:      "bundle.config",
+				}
+				Expect(ssp.ValidateCreate()).To(Succeed())
+			})
+
+			DescribeTable("should be rejected on create", func(modify func(*SSP), expectedErrors ...string) {
+				ssp := newSsp()
+				modify(ssp)
+				err := ssp.ValidateCreate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(HavePrefix("creation failed, "))
+				for _, expectedError := range expectedErrors {
+					Expect(err.Error()).To(ContainSubstring(expectedError))
+				}
 			},
-		}
+				Entry("without templates namespace", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.Namespace = ""
+				}, "spec.commonTemplates.namespace: Required value"),
+				Entry("with invalid templates namespace", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.Namespace = "Templates_NS"
+				}, `spec.commonTemplates.namespace: Invalid value: "Templates_NS": a lowercase RFC 1123 label`),
+				Entry("with invalid golden images namespace", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.GoldenImagesNamespace = "os-images-"
+				}, `spec.commonTemplates.goldenImagesNamespace: Invalid value: "os-images-"`),
+				Entry("with invalid additional golden images namespace", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{"team-images", "Team_Images"}
+				}, `spec.commonTemplates.additionalGoldenImageNamespaces[1]: Invalid value: "Team_Images"`),
+				Entry("with invalid template validator namespace", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.Namespace = "validator.ns"
+				}, `spec.templateValidator.namespace: Invalid value: "validator.ns"`),
+				Entry("with malformed audit webhook URL", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.AuditWebhookURL = "siem.example.com/audit"
+				}, `spec.templateValidator.auditWebhookURL: Invalid value: "siem.example.com/audit"`),
+				Entry("with audit webhook URL with unsupported scheme", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.AuditWebhookURL = "ftp://siem.example.com/audit"
+				}, `spec.templateValidator.auditWebhookURL: Invalid value: "ftp://siem.example.com/audit": scheme must be http or https`),
+				Entry("with audit webhook URL without host", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.AuditWebhookURL = "https:///audit"
+				}, `spec.templateValidator.auditWebhookURL: Invalid value: "https:///audit": host must be set`),
+				Entry("with zero max concurrent requests", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.MaxConcurrentRequests = pointer.Int32Ptr(0)
+				}, "spec.templateValidator.maxConcurrentRequests: Invalid value: 0: must be between 1 and 10000"),
+				Entry("with too high max concurrent requests", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.MaxConcurrentRequests = pointer.Int32Ptr(MaxConcurrentRequestsLimit + 1)
+				}, "spec.templateValidator.maxConcurrentRequests: Invalid value: 10001: must be between 1 and 10000"),
+				Entry("with negative log verbosity", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.LogVerbosity = pointer.Int32Ptr(-1)
+				}, "spec.templateValidator.logVerbosity: Invalid value: -1: must be between 0 and 10"),
+				Entry("with too high log verbosity", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.LogVerbosity = pointer.Int32Ptr(MaxLogVerbosity + 1)
+				}, "spec.templateValidator.logVerbosity: Invalid value: 11: must be between 0 and 10"),
+				Entry("with negative replicas", func(ssp *SSP) {
+					ssp.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(-1)
+				}, "spec.templateValidator.replicas: Invalid value: -1: must not be negative"),
+				Entry("with negative deprecated templates retention", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: -time.Hour}
+				}, `spec.commonTemplates.deprecatedTemplatesRetention: Invalid value: "-1h0m0s": must not be negative`),
+				Entry("with deletion of old golden images namespaces, when namespace is not managed", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.ManageGoldenImagesNamespace = pointer.BoolPtr(false)
+					ssp.Spec.CommonTemplates.DeleteOldGoldenImagesNamespaces = true
+				}, "spec.commonTemplates.deleteOldGoldenImagesNamespaces: Invalid value: true: cannot be enabled when manageGoldenImagesNamespace is false"),
+				Entry("with bundle ConfigMap without namespace and name", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.BundleConfigMap = &BundleConfigMapReference{}
+				}, "spec.commonTemplates.bundleConfigMap.namespace: Required value",
+					"spec.commonTemplates.bundleConfigMap.name: Required value"),
+				Entry("with invalid bundle ConfigMap name", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.BundleConfigMap = &BundleConfigMapReference{Namespace: "bundle-ns", Name: "Bundle"}
+				}, `spec.commonTemplates.bundleConfigMap.name: Invalid value: "Bundle"`),
+				Entry("with bundle integrity verification and bundle ConfigMap", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.VerifyBundleIntegrity = true
+					ssp.Spec.CommonTemplates.BundleConfigMap = &BundleConfigMapReference{Namespace: "bundle-ns", Name: "bundle"}
+				}, "spec.commonTemplates.verifyBundleIntegrity: Invalid value: true: cannot be enabled together with bundleConfigMap"),
+				Entry("with multiple problems", func(ssp *SSP) {
+					ssp.Spec.CommonTemplates.Namespace = ""
+					ssp.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(-1)
+				}, "spec.commonTemplates.namespace: Required value", "spec.templateValidator.replicas: Invalid value: -1"),
+			)
+
+			It("should be rejected on update", func() {
+				oldSsp := newSsp()
+				ssp := oldSsp.DeepCopy()
+				ssp.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(-1)
+
+				err := ssp.ValidateUpdate(oldSsp)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("update failed, spec.templateValidator.replicas: Invalid value: -1: must not be negative"))
+			})
+		})
+
+		It("should accept existing template validator namespace", func() {
+			ssp := &SSP{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ssp",
+					Namespace: "test-ns",
+				},
+				Spec: SSPSpec{
+					TemplateValidator: TemplateValidator{
+						Namespace: templatesNamespace,
+					},
+					CommonTemplates: CommonTemplates{
+						Namespace: templatesNamespace,
+					},
+				},
+			}
+			Expect(ssp.ValidateCreate()).ToNot(HaveOccurred())
+		})
+
+		Context("template validator TLS config", func() {
+			newSSP := func(config *TLSConfig) *SSP {
+				return &SSP{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-ssp",
+						Namespace: "test-ns",
+					},
+					Spec: SSPSpec{
+						TemplateValidator: TemplateValidator{
+							TLSConfig: config,
+						},
+						CommonTemplates: CommonTemplates{
+							Namespace: templatesNamespace,
+						},
+					},
+				}
+			}
 
-		newSsp := oldSsp.DeepCopy()
-		newSsp.Spec.CommonTemplates.Namespace = "new-ns"
+			It("should accept valid cipher suites", func() {
+				ssp := newSSP(&TLSConfig{
+					MinVersion:   TLSVersion12,
+					CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+				})
+				Expect(ssp.ValidateCreate()).ToNot(HaveOccurred())
+			})
 
-		err := newSsp.ValidateUpdate(oldSsp)
-		Expect(err).To(HaveOccurred())
-		Expect(err.Error()).To(ContainSubstring("commonTemplates.namespace cannot be changed."))
+			It("should reject unknown cipher suite", func() {
+				ssp := newSSP(&TLSConfig{CipherSuites: []string{"TLS_NOT_A_CIPHER"}})
+				err := ssp.ValidateCreate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid or insecure TLS cipher suite: TLS_NOT_A_CIPHER"))
+			})
+
+			It("should reject insecure cipher suite", func() {
+				ssp := newSSP(&TLSConfig{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}})
+				err := ssp.ValidateCreate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid or insecure TLS cipher suite: TLS_RSA_WITH_RC4_128_SHA"))
+			})
+
+			It("should reject cipher suites with TLS 1.3", func() {
+				ssp := newSSP(&TLSConfig{
+					MinVersion:   TLSVersion13,
+					CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+				})
+				err := ssp.ValidateCreate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cipher suites cannot be configured"))
+			})
+
+			It("should reject invalid cipher suite on update", func() {
+				oldSsp := newSSP(nil)
+				newSsp := newSSP(&TLSConfig{CipherSuites: []string{"TLS_NOT_A_CIPHER"}})
+				err := newSsp.ValidateUpdate(oldSsp)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("update failed"))
+			})
+		})
+	})
+
+	Context("updating commonTemplates.namespace", func() {
+		var oldSsp *SSP
+
+		BeforeEach(func() {
+			objects = append(objects, &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "new-ns",
+					ResourceVersion: "1",
+				},
+			})
+
+			oldSsp = &SSP{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ssp",
+					Namespace: "test-ns",
+				},
+				Spec: SSPSpec{
+					CommonTemplates: CommonTemplates{
+						Namespace: "old-ns",
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			objects = make([]runtime.Object, 0)
+		})
+
+		It("should allow change to an existing namespace", func() {
+			newSsp := oldSsp.DeepCopy()
+			newSsp.Spec.CommonTemplates.Namespace = "new-ns"
+
+			Expect(newSsp.ValidateUpdate(oldSsp)).To(Succeed())
+		})
+
+		It("should reject change to a namespace that does not exist", func() {
+			newSsp := oldSsp.DeepCopy()
+			newSsp.Spec.CommonTemplates.Namespace = "nonexisting-ns"
+
+			err := newSsp.ValidateUpdate(oldSsp)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("the configured namespace for common templates does not exist: nonexisting-ns"))
+		})
+
+		It("should not require the namespace to exist, if it is not changed", func() {
+			newSsp := oldSsp.DeepCopy()
+			Expect(newSsp.ValidateUpdate(oldSsp)).To(Succeed())
+		})
 	})
 })
 
@@ -21,12 +21,160 @@ limitations under the License.
 package v1beta1
 
 import (
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BundleConfigMapReference) DeepCopyInto(out *BundleConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BundleConfigMapReference.
+func (in *BundleConfigMapReference) DeepCopy() *BundleConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(BundleConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonTemplates) DeepCopyInto(out *CommonTemplates) {
 	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.GoldenImagesEnabled != nil {
+		in, out := &in.GoldenImagesEnabled, &out.GoldenImagesEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ManageGoldenImagesNamespace != nil {
+		in, out := &in.ManageGoldenImagesNamespace, &out.ManageGoldenImagesNamespace
+		*out = new(bool)
+		**out = **in
+	}
+	if in.GoldenImagesNamespaceLabels != nil {
+		in, out := &in.GoldenImagesNamespaceLabels, &out.GoldenImagesNamespaceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.GoldenImagesNamespaceAnnotations != nil {
+		in, out := &in.GoldenImagesNamespaceAnnotations, &out.GoldenImagesNamespaceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AdditionalViewRoleSubjects != nil {
+		in, out := &in.AdditionalViewRoleSubjects, &out.AdditionalViewRoleSubjects
+		*out = make([]rbacv1.Subject, len(*in))
+		copy(*out, *in)
+	}
+	if in.AggregateGoldenImagesViewRole != nil {
+		in, out := &in.AggregateGoldenImagesViewRole, &out.AggregateGoldenImagesViewRole
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AdditionalGoldenImageNamespaces != nil {
+		in, out := &in.AdditionalGoldenImageNamespaces, &out.AdditionalGoldenImageNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultVMLabels != nil {
+		in, out := &in.DefaultVMLabels, &out.DefaultVMLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DefaultVMAnnotations != nil {
+		in, out := &in.DefaultVMAnnotations, &out.DefaultVMAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DefaultMemoryBallooning != nil {
+		in, out := &in.DefaultMemoryBallooning, &out.DefaultMemoryBallooning
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SecureBootByOS != nil {
+		in, out := &in.SecureBootByOS, &out.SecureBootByOS
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TPMByOS != nil {
+		in, out := &in.TPMByOS, &out.TPMByOS
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DefaultAccessCredentials != nil {
+		in, out := &in.DefaultAccessCredentials, &out.DefaultAccessCredentials
+		*out = new(DefaultAccessCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DedicatedCPUForWorkloads != nil {
+		in, out := &in.DedicatedCPUForWorkloads, &out.DedicatedCPUForWorkloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnableHotplugForWorkloads != nil {
+		in, out := &in.EnableHotplugForWorkloads, &out.EnableHotplugForWorkloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SchedulerByWorkload != nil {
+		in, out := &in.SchedulerByWorkload, &out.SchedulerByWorkload
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DeprecatedTemplatesRetention != nil {
+		in, out := &in.DeprecatedTemplatesRetention, &out.DeprecatedTemplatesRetention
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.BundleConfigMap != nil {
+		in, out := &in.BundleConfigMap, &out.BundleConfigMap
+		*out = new(BundleConfigMapReference)
+		**out = **in
+	}
+	if in.AdditionalBundles != nil {
+		in, out := &in.AdditionalBundles, &out.AdditionalBundles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = new(TemplateFilters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpectedOSFamilies != nil {
+		in, out := &in.ExpectedOSFamilies, &out.ExpectedOSFamilies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxTemplateParameters != nil {
+		in, out := &in.MaxTemplateParameters, &out.MaxTemplateParameters
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonTemplates.
@@ -39,6 +187,51 @@ func (in *CommonTemplates) DeepCopy() *CommonTemplates {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CommonTemplatesStatus) DeepCopyInto(out *CommonTemplatesStatus) {
+	*out = *in
+	if in.FailedTemplateNames != nil {
+		in, out := &in.FailedTemplateNames, &out.FailedTemplateNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommonTemplatesStatus.
+func (in *CommonTemplatesStatus) DeepCopy() *CommonTemplatesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CommonTemplatesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultAccessCredentials) DeepCopyInto(out *DefaultAccessCredentials) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultAccessCredentials.
+func (in *DefaultAccessCredentials) DeepCopy() *DefaultAccessCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultAccessCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeLabeller) DeepCopyInto(out *NodeLabeller) {
 	*out = *in
@@ -58,6 +251,63 @@ func (in *NodeLabeller) DeepCopy() *NodeLabeller {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperandStatus) DeepCopyInto(out *OperandStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]conditionsv1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandStatus.
+func (in *OperandStatus) DeepCopy() *OperandStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperandStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeConfig) DeepCopyInto(out *ProbeConfig) {
+	*out = *in
+	if in.InitialDelay != nil {
+		in, out := &in.InitialDelay, &out.InitialDelay
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeConfig.
+func (in *ProbeConfig) DeepCopy() *ProbeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSP) DeepCopyInto(out *SSP) {
 	*out = *in
@@ -121,7 +371,7 @@ func (in *SSPList) DeepCopyObject() runtime.Object {
 func (in *SSPSpec) DeepCopyInto(out *SSPSpec) {
 	*out = *in
 	in.TemplateValidator.DeepCopyInto(&out.TemplateValidator)
-	out.CommonTemplates = in.CommonTemplates
+	in.CommonTemplates.DeepCopyInto(&out.CommonTemplates)
 	in.NodeLabeller.DeepCopyInto(&out.NodeLabeller)
 }
 
@@ -139,6 +389,28 @@ func (in *SSPSpec) DeepCopy() *SSPSpec {
 func (in *SSPStatus) DeepCopyInto(out *SSPStatus) {
 	*out = *in
 	in.Status.DeepCopyInto(&out.Status)
+	if in.CommonTemplates != nil {
+		in, out := &in.CommonTemplates, &out.CommonTemplates
+		*out = new(CommonTemplatesStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateValidator != nil {
+		in, out := &in.TemplateValidator, &out.TemplateValidator
+		*out = new(TemplateValidatorStatus)
+		**out = **in
+	}
+	if in.LastUpgrade != nil {
+		in, out := &in.LastUpgrade, &out.LastUpgrade
+		*out = new(UpgradeSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Operands != nil {
+		in, out := &in.Operands, &out.Operands
+		*out = make([]OperandStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSPStatus.
@@ -151,6 +423,56 @@ func (in *SSPStatus) DeepCopy() *SSPStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
+	*out = *in
+	if in.CipherSuites != nil {
+		in, out := &in.CipherSuites, &out.CipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfig.
+func (in *TLSConfig) DeepCopy() *TLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateFilters) DeepCopyInto(out *TemplateFilters) {
+	*out = *in
+	if in.OperatingSystems != nil {
+		in, out := &in.OperatingSystems, &out.OperatingSystems
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Flavors != nil {
+		in, out := &in.Flavors, &out.Flavors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateFilters.
+func (in *TemplateFilters) DeepCopy() *TemplateFilters {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateFilters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TemplateValidator) DeepCopyInto(out *TemplateValidator) {
 	*out = *in
@@ -163,6 +485,65 @@ func (in *TemplateValidator) DeepCopyInto(out *TemplateValidator) {
 		in, out := &in.Placement, &out.Placement
 		*out = (*in).DeepCopy()
 	}
+	if in.PodLabels != nil {
+		in, out := &in.PodLabels, &out.PodLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ScrapeAnnotations != nil {
+		in, out := &in.ScrapeAnnotations, &out.ScrapeAnnotations
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLSConfig != nil {
+		in, out := &in.TLSConfig, &out.TLSConfig
+		*out = new(TLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProbeConfig != nil {
+		in, out := &in.ProbeConfig, &out.ProbeConfig
+		*out = new(ProbeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxConcurrentRequests != nil {
+		in, out := &in.MaxConcurrentRequests, &out.MaxConcurrentRequests
+		*out = new(int32)
+		**out = **in
+	}
+	if in.LogVerbosity != nil {
+		in, out := &in.LogVerbosity, &out.LogVerbosity
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateValidator.
@@ -174,3 +555,37 @@ func (in *TemplateValidator) DeepCopy() *TemplateValidator {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateValidatorStatus) DeepCopyInto(out *TemplateValidatorStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateValidatorStatus.
+func (in *TemplateValidatorStatus) DeepCopy() *TemplateValidatorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateValidatorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSummary) DeepCopyInto(out *UpgradeSummary) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSummary.
+func (in *UpgradeSummary) DeepCopy() *UpgradeSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSummary)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"kubevirt.io/ssp-operator/internal/common"
+	"kubevirt.io/ssp-operator/internal/operands"
+)
+
+// operandReconcileDuration has buckets from 50ms to about 7 minutes,
+// because reconciling operands with many resources can be slow.
+var operandReconcileDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "ssp_operand_reconcile_duration_seconds",
+		Help:    "Duration of operand reconciliation in seconds",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+	},
+	[]string{"operand"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(operandReconcileDuration)
+}
+
+// reconcileOperand calls operand.Reconcile and observes its duration
+func reconcileOperand(operand operands.Operand, request *common.Request) ([]common.ResourceStatus, error) {
+	start := time.Now()
+	defer func() {
+		operandReconcileDuration.WithLabelValues(operand.Name()).Observe(time.Since(start).Seconds())
+	}()
+	return operand.Reconcile(request)
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+type testOperand struct {
+	name string
+}
+
+func (t *testOperand) AddWatchTypesToScheme(*runtime.Scheme) error { return nil }
+
+func (t *testOperand) WatchTypes() []client.Object { return nil }
+
+func (t *testOperand) WatchClusterTypes() []client.Object { return nil }
+
+func (t *testOperand) Reconcile(*common.Request) ([]common.ResourceStatus, error) { return nil, nil }
+
+func (t *testOperand) Cleanup(*common.Request) error { return nil }
+
+func (t *testOperand) Name() string { return t.name }
+
+func operandSampleCount(name string) uint64 {
+	metric := &dto.Metric{}
+	histogram := operandReconcileDuration.WithLabelValues(name).(prometheus.Histogram)
+	ExpectWithOffset(1, histogram.Write(metric)).To(Succeed())
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestControllers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Controllers Suite")
+}
+
+var _ = Describe("Operand reconcile duration metric", func() {
+	It("should be registered", func() {
+		err := metrics.Registry.Register(operandReconcileDuration)
+		Expect(err).To(BeAssignableToTypeOf(prometheus.AlreadyRegisteredError{}))
+	})
+
+	It("should be observed after operand reconcile", func() {
+		operand := &testOperand{name: "test-operand"}
+		before := operandSampleCount(operand.name)
+
+		_, err := reconcileOperand(operand, &common.Request{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(operandSampleCount(operand.name)).To(Equal(before + 1))
+	})
+})
@@ -19,8 +19,10 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
@@ -29,9 +31,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
 	lifecycleapi "kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -56,6 +61,12 @@ const (
 	finalizerName          = "ssp.kubevirt.io/finalizer"
 	oldFinalizerName       = "finalize.ssp.kubevirt.io"
 	defaultOperatorVersion = "devel"
+
+	// conditionPaused is set on the SSP resource while its reconciliation is paused
+	conditionPaused conditionsv1.ConditionType = "Paused"
+
+	// upgradeCompletedReason is the reason of the event summarizing a completed upgrade
+	upgradeCompletedReason = "UpgradeCompleted"
 )
 
 var sspOperands = []operands.Operand{
@@ -76,10 +87,17 @@ var kvsspCRDs = map[string]string{
 // SSPReconciler reconciles a SSP object
 type SSPReconciler struct {
 	client.Client
-	Log logr.Logger
+	Log      logr.Logger
+	Recorder record.EventRecorder
+
+	// APIReader reads objects that are not cached by the manager
+	APIReader client.Reader
 
 	LastSspSpec      ssp.SSPSpec
 	SubresourceCache common.VersionCache
+
+	// ManagedResources contains resources reconciled as cluster resources in the last successful reconciliation
+	ManagedResources *common.ResourceRegistry
 }
 
 var _ reconcile.Reconciler = &SSPReconciler{}
@@ -88,6 +106,7 @@ var _ reconcile.Reconciler = &SSPReconciler{}
 // +kubebuilder:rbac:groups=ssp.kubevirt.io,resources=ssps/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=ssp.kubevirt.io,resources=ssps/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=list
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=ssp.kubevirt.io,resources=kubevirtcommontemplatesbundles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ssp.kubevirt.io,resources=kubevirtmetricsaggregations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ssp.kubevirt.io,resources=kubevirtnodelabellerbundles,verbs=get;list;watch;create;update;patch;delete
@@ -120,6 +139,10 @@ func (r *SSPReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		Instance:     instance,
 		Logger:       reqLogger,
 		VersionCache: r.SubresourceCache,
+		Recorder:     r.Recorder,
+		APIReader:    r.APIReader,
+
+		ResourceRegistry: common.NewResourceRegistry(),
 	}
 
 	if !isInitialized(sspRequest.Instance) {
@@ -144,12 +167,18 @@ func (r *SSPReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	}
 
 	if isPaused(instance) {
-		if instance.Status.Paused {
+		if instance.Status.Paused && conditionsv1.IsStatusConditionTrue(instance.Status.Conditions, conditionPaused) {
 			return ctrl.Result{}, nil
 		}
 		reqLogger.Info(fmt.Sprintf("Pausing SSP operator on resource: %v/%v", instance.Namespace, instance.Name))
 		instance.Status.Paused = true
 		instance.Status.ObservedGeneration = instance.Generation
+		conditionsv1.SetStatusCondition(&instance.Status.Conditions, conditionsv1.Condition{
+			Type:    conditionPaused,
+			Status:  v1.ConditionTrue,
+			Reason:  "paused",
+			Message: "Reconciliation is paused by annotation",
+		})
 		err := r.Status().Update(ctx, instance)
 		return ctrl.Result{}, err
 	}
@@ -164,9 +193,15 @@ func (r *SSPReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	sspRequest.Logger.V(1).Info("Reconciling operands...")
 	statuses, err := reconcileOperands(sspRequest)
 	if err != nil {
+		updateCommonTemplatesStatus(sspRequest, statuses)
+		updateTemplateValidatorStatus(sspRequest, statuses)
+		updateUpgradeSummary(sspRequest, statuses, false)
 		return handleError(sspRequest, err)
 	}
 	sspRequest.Logger.V(1).Info("Operands reconciled")
+	if r.ManagedResources != nil {
+		r.ManagedResources.Replace(sspRequest.ResourceRegistry)
+	}
 
 	sspRequest.Logger.V(1).Info("Updating CR status post reconciliation...")
 	err = updateStatus(sspRequest, statuses)
@@ -175,7 +210,18 @@ func (r *SSPReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	}
 	sspRequest.Logger.V(1).Info("CR status updated")
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter(statuses)}, nil
+}
+
+// requeueAfter returns the shortest delay of reconciliation requested by the statuses, or zero
+func requeueAfter(statuses []common.ResourceStatus) time.Duration {
+	var result time.Duration
+	for _, status := range statuses {
+		if status.RequeueAfter > 0 && (result == 0 || status.RequeueAfter < result) {
+			result = status.RequeueAfter
+		}
+	}
+	return result
 }
 
 func (r *SSPReconciler) clearCacheIfNeeded(sspObj *ssp.SSP) {
@@ -195,18 +241,17 @@ func getOperatorVersion() string {
 }
 
 func isPaused(object metav1.Object) bool {
-	if object.GetAnnotations() == nil {
-		return false
-	}
-	pausedStr, ok := object.GetAnnotations()[ssp.OperatorPausedAnnotation]
-	if !ok {
-		return false
-	}
-	paused, err := strconv.ParseBool(pausedStr)
-	if err != nil {
-		return false
+	annotations := object.GetAnnotations()
+	for _, annotation := range []string{ssp.PausedAnnotation, ssp.OperatorPausedAnnotation} {
+		pausedStr, ok := annotations[annotation]
+		if !ok {
+			continue
+		}
+		if paused, err := strconv.ParseBool(pausedStr); err == nil && paused {
+			return true
+		}
 	}
-	return paused
+	return false
 }
 
 func isBeingDeleted(object metav1.Object) bool {
@@ -232,6 +277,12 @@ func updateSsp(request *common.Request) (bool, error) {
 		updated = true
 	}
 
+	// Add the finalizer if it was removed, so cluster resources are cleaned up on deletion
+	if !isBeingDeleted(request.Instance) && !controllerutil.ContainsFinalizer(request.Instance, finalizerName) {
+		controllerutil.AddFinalizer(request.Instance, finalizerName)
+		updated = true
+	}
+
 	if !updated {
 		return false, nil
 	}
@@ -261,12 +312,18 @@ func cleanup(request *common.Request) error {
 		if err != nil {
 			return err
 		}
+		// All operands are cleaned up, even if some of them fail.
+		// The finalizer is kept until all of them succeed, and the request is requeued.
+		var errs []error
 		for _, operand := range sspOperands {
-			err = operand.Cleanup(request)
-			if err != nil {
-				return err
+			if err := operand.Cleanup(request); err != nil {
+				request.Logger.Error(err, fmt.Sprintf("Failed to clean up operand %s", operand.Name()))
+				errs = append(errs, err)
 			}
 		}
+		if len(errs) > 0 {
+			return utilerrors.NewAggregate(errs)
+		}
 		controllerutil.RemoveFinalizer(request.Instance, finalizerName)
 		controllerutil.RemoveFinalizer(request.Instance, oldFinalizerName)
 		err = request.Client.Update(request.Context, request.Instance)
@@ -351,10 +408,12 @@ func reconcileOperands(sspRequest *common.Request) ([]common.ResourceStatus, err
 	allStatuses := make([]common.ResourceStatus, 0, len(sspOperands))
 	for _, operand := range sspOperands {
 		sspRequest.Logger.V(1).Info(fmt.Sprintf("Reconciling operand: %s", operand.Name()))
-		statuses, err := operand.Reconcile(sspRequest)
+		statuses, err := reconcileOperand(operand, sspRequest)
+		updateOperandConditions(sspRequest, operand.Name(), statuses, err)
 		if err != nil {
 			sspRequest.Logger.V(1).Info(fmt.Sprintf("Operand reconciliation failed: %s", err.Error()))
-			return nil, err
+			// Statuses returned with the error can still be reported
+			return append(allStatuses, statuses...), err
 		}
 		allStatuses = append(allStatuses, statuses...)
 	}
@@ -376,6 +435,7 @@ func preUpdateStatus(request *common.Request) error {
 			request.Instance.Namespace, request.Instance.Name))
 	}
 	sspStatus.Paused = false
+	conditionsv1.RemoveStatusCondition(&sspStatus.Conditions, conditionPaused)
 
 	if !conditionsv1.IsStatusConditionPresentAndEqual(sspStatus.Conditions, conditionsv1.ConditionAvailable, v1.ConditionFalse) {
 		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
@@ -408,6 +468,30 @@ func preUpdateStatus(request *common.Request) error {
 }
 
 func updateStatus(request *common.Request, statuses []common.ResourceStatus) error {
+	updateCommonTemplatesStatus(request, statuses)
+	updateTemplateValidatorStatus(request, statuses)
+
+	sspStatus := &request.Instance.Status
+	notAvailable, progressing, degraded := setResourceConditions(&sspStatus.Conditions, statuses, "SSP")
+
+	sspStatus.ObservedGeneration = request.Instance.Generation
+	deployed := notAvailable == 0 && progressing == 0 && degraded == 0
+	// The summary is updated before the observed version, which is used to detect the upgrade
+	updateUpgradeSummary(request, statuses, deployed)
+	if deployed {
+		sspStatus.Phase = lifecycleapi.PhaseDeployed
+		sspStatus.ObservedVersion = getOperatorVersion()
+	} else {
+		sspStatus.Phase = lifecycleapi.PhaseDeploying
+	}
+
+	return request.Client.Status().Update(request.Context, request.Instance)
+}
+
+// setResourceConditions sets the Available, Progressing and Degraded conditions from the statuses.
+// The subject names the owner of the resources in the condition messages.
+// It returns the number of resources that are not available, progressing and degraded.
+func setResourceConditions(conditions *[]conditionsv1.Condition, statuses []common.ResourceStatus, subject string) (int, int, int) {
 	notAvailable := make([]common.ResourceStatus, 0, len(statuses))
 	progressing := make([]common.ResourceStatus, 0, len(statuses))
 	degraded := make([]common.ResourceStatus, 0, len(statuses))
@@ -423,91 +507,117 @@ func updateStatus(request *common.Request, statuses []common.ResourceStatus) err
 		}
 	}
 
-	sspStatus := &request.Instance.Status
 	switch len(notAvailable) {
 	case 0:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionAvailable,
 			Status:  v1.ConditionTrue,
 			Reason:  "available",
-			Message: "All SSP resources are available",
+			Message: fmt.Sprintf("All %s resources are available", subject),
 		})
 	case 1:
 		status := notAvailable[0]
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionAvailable,
 			Status:  v1.ConditionFalse,
 			Reason:  "available",
 			Message: prefixResourceTypeAndName(*status.NotAvailable, status.Resource),
 		})
 	default:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionAvailable,
 			Status:  v1.ConditionFalse,
 			Reason:  "available",
-			Message: fmt.Sprintf("%d SSP resources are not available", len(notAvailable)),
+			Message: fmt.Sprintf("%d %s resources are not available", len(notAvailable), subject),
 		})
 	}
 
 	switch len(progressing) {
 	case 0:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionProgressing,
 			Status:  v1.ConditionFalse,
 			Reason:  "progressing",
-			Message: "No SSP resources are progressing",
+			Message: fmt.Sprintf("No %s resources are progressing", subject),
 		})
 	case 1:
 		status := progressing[0]
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionProgressing,
 			Status:  v1.ConditionTrue,
 			Reason:  "progressing",
 			Message: prefixResourceTypeAndName(*status.Progressing, status.Resource),
 		})
 	default:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionProgressing,
 			Status:  v1.ConditionTrue,
 			Reason:  "progressing",
-			Message: fmt.Sprintf("%d SSP resources are progressing", len(progressing)),
+			Message: fmt.Sprintf("%d %s resources are progressing", len(progressing), subject),
 		})
 	}
 
 	switch len(degraded) {
 	case 0:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionDegraded,
 			Status:  v1.ConditionFalse,
 			Reason:  "degraded",
-			Message: "No SSP resources are degraded",
+			Message: fmt.Sprintf("No %s resources are degraded", subject),
 		})
 	case 1:
 		status := degraded[0]
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionDegraded,
 			Status:  v1.ConditionTrue,
 			Reason:  "degraded",
 			Message: prefixResourceTypeAndName(*status.Degraded, status.Resource),
 		})
 	default:
-		conditionsv1.SetStatusCondition(&sspStatus.Conditions, conditionsv1.Condition{
+		conditionsv1.SetStatusCondition(conditions, conditionsv1.Condition{
 			Type:    conditionsv1.ConditionDegraded,
 			Status:  v1.ConditionTrue,
 			Reason:  "degraded",
-			Message: fmt.Sprintf("%d SSP resources are degraded", len(degraded)),
+			Message: fmt.Sprintf("%d %s resources are degraded", len(degraded), subject),
 		})
 	}
 
-	sspStatus.ObservedGeneration = request.Instance.Generation
-	if len(notAvailable) == 0 && len(progressing) == 0 && len(degraded) == 0 {
-		sspStatus.Phase = lifecycleapi.PhaseDeployed
-		sspStatus.ObservedVersion = getOperatorVersion()
-	} else {
-		sspStatus.Phase = lifecycleapi.PhaseDeploying
+	return len(notAvailable), len(progressing), len(degraded)
+}
+
+// updateOperandConditions sets conditions of the operand in the SSP status from the statuses
+// returned by its reconciliation. If the reconciliation failed, the error is reported
+// as degraded, unless the returned statuses already describe the failure.
+func updateOperandConditions(request *common.Request, name string, statuses []common.ResourceStatus, reconcileErr error) {
+	sspStatus := &request.Instance.Status
+	var operandStatus *ssp.OperandStatus
+	for i := range sspStatus.Operands {
+		if sspStatus.Operands[i].Name == name {
+			operandStatus = &sspStatus.Operands[i]
+			break
+		}
+	}
+	if operandStatus == nil {
+		sspStatus.Operands = append(sspStatus.Operands, ssp.OperandStatus{Name: name})
+		operandStatus = &sspStatus.Operands[len(sspStatus.Operands)-1]
 	}
 
-	return request.Client.Status().Update(request.Context, request.Instance)
+	_, _, degraded := setResourceConditions(&operandStatus.Conditions, statuses, name)
+	if reconcileErr != nil && degraded == 0 {
+		msg := fmt.Sprintf("Error: %v", reconcileErr)
+		conditionsv1.SetStatusCondition(&operandStatus.Conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionProgressing,
+			Status:  v1.ConditionTrue,
+			Reason:  "progressing",
+			Message: msg,
+		})
+		conditionsv1.SetStatusCondition(&operandStatus.Conditions, conditionsv1.Condition{
+			Type:    conditionsv1.ConditionDegraded,
+			Status:  v1.ConditionTrue,
+			Reason:  "degraded",
+			Message: msg,
+		})
+	}
 }
 
 func prefixResourceTypeAndName(message string, resource client.Object) string {
@@ -518,6 +628,86 @@ func prefixResourceTypeAndName(message string, resource client.Object) string {
 		message)
 }
 
+// updateCommonTemplatesStatus copies the common templates summary from the statuses to the SSP status.
+// The deployed version is updated only if no template failed.
+func updateCommonTemplatesStatus(request *common.Request, statuses []common.ResourceStatus) {
+	for _, status := range statuses {
+		if status.CommonTemplates != nil {
+			request.Instance.Status.CommonTemplates = status.CommonTemplates
+			if status.CommonTemplates.FailedTemplates == 0 {
+				request.Instance.Status.CommonTemplatesVersion = status.CommonTemplates.Version
+			}
+		}
+	}
+}
+
+// updateTemplateValidatorStatus copies the template validator state from the statuses to the SSP status
+func updateTemplateValidatorStatus(request *common.Request, statuses []common.ResourceStatus) {
+	for _, status := range statuses {
+		if status.TemplateValidator != nil {
+			request.Instance.Status.TemplateValidator = status.TemplateValidator
+		}
+	}
+}
+
+// updateUpgradeSummary adds changes reported by operands to the summary of the upgrade in progress.
+// An upgrade is in progress, if the operator version differs from the version observed
+// when all resources were last deployed. Changes from all reconciliations during the upgrade
+// are summed, and when it completes, the summary is also reported in an event.
+func updateUpgradeSummary(request *common.Request, statuses []common.ResourceStatus, completed bool) {
+	sspStatus := &request.Instance.Status
+	version := getOperatorVersion()
+	if sspStatus.ObservedVersion == "" || sspStatus.ObservedVersion == version {
+		// New installation, or the upgrade was already completed
+		return
+	}
+
+	summary := sspStatus.LastUpgrade
+	if summary == nil || summary.CompletionTime != nil ||
+		summary.PreviousVersion != sspStatus.ObservedVersion || summary.Version != version {
+		summary = &ssp.UpgradeSummary{
+			PreviousVersion: sspStatus.ObservedVersion,
+			Version:         version,
+		}
+		sspStatus.LastUpgrade = summary
+	}
+
+	for _, status := range statuses {
+		if status.Upgrade == nil {
+			continue
+		}
+		summary.AddedTemplates += status.Upgrade.AddedTemplates
+		summary.UpdatedTemplates += status.Upgrade.UpdatedTemplates
+		summary.DeprecatedTemplates += status.Upgrade.DeprecatedTemplates
+		if status.Upgrade.ValidatorImage != "" {
+			// The image may change more than once, the first one is kept as the previous image
+			if summary.PreviousValidatorImage == "" {
+				summary.PreviousValidatorImage = status.Upgrade.PreviousValidatorImage
+			}
+			summary.ValidatorImage = status.Upgrade.ValidatorImage
+		}
+	}
+
+	if !completed {
+		return
+	}
+	now := metav1.Now()
+	summary.CompletionTime = &now
+	request.Logger.Info(upgradeMessage(summary))
+	request.Event(v1.EventTypeNormal, upgradeCompletedReason, upgradeMessage(summary))
+}
+
+func upgradeMessage(summary *ssp.UpgradeSummary) string {
+	msg := fmt.Sprintf("Upgraded from %s to %s: %d templates added, %d updated, %d deprecated",
+		summary.PreviousVersion, summary.Version,
+		summary.AddedTemplates, summary.UpdatedTemplates, summary.DeprecatedTemplates)
+	if summary.ValidatorImage != "" {
+		msg += fmt.Sprintf(", template validator rolled from image %s to %s",
+			summary.PreviousValidatorImage, summary.ValidatorImage)
+	}
+	return msg
+}
+
 func handleError(request *common.Request, errParam error) (ctrl.Result, error) {
 	if errParam == nil {
 		return ctrl.Result{}, nil
@@ -562,13 +752,30 @@ func handleError(request *common.Request, errParam error) (ctrl.Result, error) {
 func (r *SSPReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.SubresourceCache = common.VersionCache{}
 
+	if err := indexFields(mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
+
 	builder := ctrl.NewControllerManagedBy(mgr)
 	watchSspResource(builder)
 	watchClusterResources(builder)
 	watchNamespacedResources(builder)
+	watchBundleConfigMaps(builder, mgr.GetClient())
 	return builder.Complete(r)
 }
 
+// indexFields registers field indexes used by operands
+func indexFields(indexer client.FieldIndexer) error {
+	for _, operand := range sspOperands {
+		if provider, ok := operand.(operands.FieldIndexesProvider); ok {
+			if err := provider.IndexFields(context.TODO(), indexer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func watchSspResource(bldr *ctrl.Builder) {
 	// Predicate is used to only reconcile on these changes to the SSP resource:
 	// - any change in spec - checked with generation
@@ -614,6 +821,30 @@ func watchClusterResources(builder *ctrl.Builder) {
 	)
 }
 
+// watchBundleConfigMaps reconciles SSP CRs, when the ConfigMap containing their templates bundle changes.
+// The cache is limited to ConfigMaps with the watched label, so changes of other ConfigMaps are not seen.
+func watchBundleConfigMaps(builder *ctrl.Builder, c client.Client) {
+	builder.Watches(&source.Kind{Type: &v1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		ssps := &ssp.SSPList{}
+		if err := c.List(context.TODO(), ssps); err != nil {
+			return nil
+		}
+		var requests []reconcile.Request
+		for i := range ssps.Items {
+			ref := ssps.Items[i].Spec.CommonTemplates.BundleConfigMap
+			if ref != nil && ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: ssps.Items[i].Namespace,
+						Name:      ssps.Items[i].Name,
+					},
+				})
+			}
+		}
+		return requests
+	}))
+}
+
 func watchResources(builder *ctrl.Builder, handler handler.EventHandler, watchTypesFunc func(operands.Operand) []client.Object) {
 	watchedTypes := make(map[reflect.Type]struct{})
 	for _, operand := range sspOperands {
@@ -628,6 +859,36 @@ func watchResources(builder *ctrl.Builder, handler handler.EventHandler, watchTy
 	}
 }
 
+// DebugHandlers returns HTTP handlers exposing debug information of operands, by their path
+func DebugHandlers() map[string]http.Handler {
+	handlers := map[string]http.Handler{}
+	for _, operand := range sspOperands {
+		provider, ok := operand.(operands.DebugHandlersProvider)
+		if !ok {
+			continue
+		}
+		for path, handler := range provider.DebugHandlers() {
+			handlers[path] = handler
+		}
+	}
+	return handlers
+}
+
+// CacheSelectors returns selectors of cached objects from all operands.
+// Only ConfigMaps with the watched label are cached, see watchBundleConfigMaps.
+func CacheSelectors() []common.CacheSelector {
+	selectors := []common.CacheSelector{{
+		Object:   &v1.ConfigMap{},
+		Selector: labels.SelectorFromSet(labels.Set{ssp.WatchedConfigMapLabel: "true"}),
+	}}
+	for _, operand := range sspOperands {
+		if provider, ok := operand.(operands.CacheSelectorsProvider); ok {
+			selectors = append(selectors, provider.CacheSelectors()...)
+		}
+	}
+	return selectors
+}
+
 func InitScheme(scheme *runtime.Scheme) error {
 	for _, operand := range sspOperands {
 		err := operand.AddWatchTypesToScheme(scheme)
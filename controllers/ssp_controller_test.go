@@ -0,0 +1,533 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	conditionsv1 "github.com/openshift/custom-resource-status/conditions/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	lifecycleapi "kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
+	"kubevirt.io/ssp-operator/internal/common"
+	"kubevirt.io/ssp-operator/internal/operands"
+)
+
+// writeCountingClient counts writes of objects, except for status updates
+type writeCountingClient struct {
+	client.Client
+	writes int
+}
+
+func (c *writeCountingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.writes++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.writes++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.writes++
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *writeCountingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.writes++
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *writeCountingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.writes++
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+var _ = Describe("Paused reconciliation", func() {
+	const (
+		namespace = "kubevirt"
+		name      = "test-ssp"
+	)
+
+	var (
+		reconciler  *SSPReconciler
+		writeClient *writeCountingClient
+		request     ctrl.Request
+	)
+
+	getSsp := func() *ssp.SSP {
+		instance := &ssp.SSP{}
+		ExpectWithOffset(1, writeClient.Get(context.Background(), request.NamespacedName, instance)).To(Succeed())
+		return instance
+	}
+
+	setPausedAnnotation := func(value string) {
+		instance := getSsp()
+		annotations := instance.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ssp.PausedAnnotation] = value
+		instance.SetAnnotations(annotations)
+		ExpectWithOffset(1, writeClient.Client.Update(context.Background(), instance)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(ssp.AddToScheme(s)).To(Succeed())
+		Expect(InitScheme(s)).To(Succeed())
+
+		instance := &ssp.SSP{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Finalizers:  []string{finalizerName},
+				Annotations: map[string]string{ssp.PausedAnnotation: "true"},
+			},
+			Spec: ssp.SSPSpec{
+				CommonTemplates: ssp.CommonTemplates{
+					Namespace: namespace,
+				},
+			},
+			Status: ssp.SSPStatus{
+				Status: lifecycleapi.Status{
+					Phase: lifecycleapi.PhaseDeployed,
+				},
+			},
+		}
+
+		writeClient = &writeCountingClient{Client: fake.NewFakeClientWithScheme(s, instance)}
+		reconciler = &SSPReconciler{
+			Client:           writeClient,
+			Log:              zap.New(zap.UseDevMode(true)),
+			SubresourceCache: common.VersionCache{},
+		}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	})
+
+	It("should not write any resources while paused", func() {
+		for i := 0; i < 2; i++ {
+			_, err := reconciler.Reconcile(context.Background(), request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(writeClient.writes).To(BeZero())
+
+		instance := getSsp()
+		Expect(instance.Status.Paused).To(BeTrue())
+		Expect(conditionsv1.IsStatusConditionTrue(instance.Status.Conditions, conditionPaused)).To(BeTrue())
+	})
+
+	It("should not write any resources when paused by the operator annotation", func() {
+		instance := getSsp()
+		instance.SetAnnotations(map[string]string{ssp.OperatorPausedAnnotation: "true"})
+		Expect(writeClient.Client.Update(context.Background(), instance)).To(Succeed())
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writeClient.writes).To(BeZero())
+		Expect(getSsp().Status.Paused).To(BeTrue())
+	})
+
+	It("should resume reconciliation when annotation is removed", func() {
+		_, err := reconciler.Reconcile(context.Background(), request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writeClient.writes).To(BeZero())
+
+		setPausedAnnotation("false")
+
+		// Operands may fail in the fake environment, only writes are checked
+		_, _ = reconciler.Reconcile(context.Background(), request)
+		Expect(writeClient.writes).ToNot(BeZero())
+
+		instance := getSsp()
+		Expect(instance.Status.Paused).To(BeFalse())
+		Expect(conditionsv1.FindStatusCondition(instance.Status.Conditions, conditionPaused)).To(BeNil())
+	})
+
+	It("should ignore invalid annotation value", func() {
+		setPausedAnnotation("invalid")
+		Expect(isPaused(getSsp())).To(BeFalse())
+	})
+})
+
+var _ = Describe("Common templates status", func() {
+	var request *common.Request
+
+	BeforeEach(func() {
+		request = &common.Request{Instance: &ssp.SSP{}}
+	})
+
+	It("should set deployed common templates version", func() {
+		summary := &ssp.CommonTemplatesStatus{Version: "v0.13.1", DeployedTemplates: 10}
+		updateCommonTemplatesStatus(request, []common.ResourceStatus{{}, {CommonTemplates: summary}})
+
+		Expect(request.Instance.Status.CommonTemplates).To(Equal(summary))
+		Expect(request.Instance.Status.CommonTemplatesVersion).To(Equal("v0.13.1"))
+	})
+
+	It("should keep previous version if some templates failed", func() {
+		request.Instance.Status.CommonTemplatesVersion = "v0.13.0"
+		summary := &ssp.CommonTemplatesStatus{Version: "v0.13.1", DeployedTemplates: 9, FailedTemplates: 1}
+		updateCommonTemplatesStatus(request, []common.ResourceStatus{{CommonTemplates: summary}})
+
+		Expect(request.Instance.Status.CommonTemplates).To(Equal(summary))
+		Expect(request.Instance.Status.CommonTemplatesVersion).To(Equal("v0.13.0"))
+	})
+
+	It("should not change version without common templates summary", func() {
+		request.Instance.Status.CommonTemplatesVersion = "v0.13.0"
+		updateCommonTemplatesStatus(request, []common.ResourceStatus{{}})
+		Expect(request.Instance.Status.CommonTemplatesVersion).To(Equal("v0.13.0"))
+	})
+})
+
+var _ = Describe("Template validator status", func() {
+	It("should set the validator namespace", func() {
+		request := &common.Request{Instance: &ssp.SSP{}}
+		request.Instance.Status.TemplateValidator = &ssp.TemplateValidatorStatus{Namespace: "old-ns"}
+
+		updateTemplateValidatorStatus(request, []common.ResourceStatus{{}})
+		Expect(request.Instance.Status.TemplateValidator.Namespace).To(Equal("old-ns"))
+
+		updateTemplateValidatorStatus(request, []common.ResourceStatus{{}, {
+			TemplateValidator: &ssp.TemplateValidatorStatus{Namespace: "new-ns"},
+		}})
+		Expect(request.Instance.Status.TemplateValidator.Namespace).To(Equal("new-ns"))
+	})
+})
+
+var _ = Describe("Cache selectors", func() {
+	It("should cache only watched ConfigMaps", func() {
+		var selector *common.CacheSelector
+		selectors := CacheSelectors()
+		for i := range selectors {
+			if _, ok := selectors[i].Object.(*v1.ConfigMap); ok {
+				selector = &selectors[i]
+			}
+		}
+		Expect(selector).ToNot(BeNil())
+
+		watched := labels.Set{ssp.WatchedConfigMapLabel: "true"}
+		Expect(selector.Selector.Matches(watched)).To(BeTrue())
+		Expect(selector.Selector.Matches(labels.Set{})).To(BeFalse())
+	})
+})
+
+var _ = Describe("Upgrade summary", func() {
+	const (
+		previousVersion = "v0.13.0"
+		version         = "v0.14.0"
+	)
+
+	var (
+		request  *common.Request
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		Expect(os.Setenv(common.OperatorVersionKey, version)).To(Succeed())
+		recorder = record.NewFakeRecorder(10)
+		request = &common.Request{
+			Instance: &ssp.SSP{},
+			Logger:   ctrl.Log,
+			Recorder: recorder,
+		}
+		request.Instance.Status.ObservedVersion = previousVersion
+	})
+
+	AfterEach(func() {
+		Expect(os.Unsetenv(common.OperatorVersionKey)).To(Succeed())
+	})
+
+	templatesChanges := func(added, updated, deprecated int) common.ResourceStatus {
+		return common.ResourceStatus{Upgrade: &ssp.UpgradeSummary{
+			AddedTemplates:      added,
+			UpdatedTemplates:    updated,
+			DeprecatedTemplates: deprecated,
+		}}
+	}
+
+	validatorChanges := func(previousImage, image string) common.ResourceStatus {
+		return common.ResourceStatus{Upgrade: &ssp.UpgradeSummary{
+			PreviousValidatorImage: previousImage,
+			ValidatorImage:         image,
+		}}
+	}
+
+	It("should sum changes until the upgrade completes", func() {
+		updateUpgradeSummary(request, []common.ResourceStatus{
+			{}, templatesChanges(2, 10, 1), validatorChanges("validator:v0.13.0", "validator:v0.14.0"),
+		}, false)
+		Expect(recorder.Events).To(BeEmpty())
+
+		updateUpgradeSummary(request, []common.ResourceStatus{templatesChanges(1, 0, 0)}, true)
+
+		summary := request.Instance.Status.LastUpgrade
+		Expect(summary).ToNot(BeNil())
+		Expect(summary.CompletionTime).ToNot(BeNil())
+		summary.CompletionTime = nil
+		Expect(summary).To(Equal(&ssp.UpgradeSummary{
+			PreviousVersion:        previousVersion,
+			Version:                version,
+			AddedTemplates:         3,
+			UpdatedTemplates:       10,
+			DeprecatedTemplates:    1,
+			PreviousValidatorImage: "validator:v0.13.0",
+			ValidatorImage:         "validator:v0.14.0",
+		}))
+
+		Expect(recorder.Events).To(HaveLen(1))
+		event := <-recorder.Events
+		Expect(event).To(ContainSubstring("Normal " + upgradeCompletedReason))
+		Expect(event).To(ContainSubstring("Upgraded from v0.13.0 to v0.14.0: 3 templates added, 10 updated, 1 deprecated"))
+		Expect(event).To(ContainSubstring("template validator rolled from image validator:v0.13.0 to validator:v0.14.0"))
+	})
+
+	It("should start a new summary after a completed upgrade", func() {
+		completed := metav1.Now()
+		request.Instance.Status.LastUpgrade = &ssp.UpgradeSummary{
+			PreviousVersion: "v0.12.0",
+			Version:         previousVersion,
+			CompletionTime:  &completed,
+			AddedTemplates:  5,
+		}
+
+		updateUpgradeSummary(request, []common.ResourceStatus{templatesChanges(1, 2, 3)}, false)
+		Expect(request.Instance.Status.LastUpgrade).To(Equal(&ssp.UpgradeSummary{
+			PreviousVersion:     previousVersion,
+			Version:             version,
+			AddedTemplates:      1,
+			UpdatedTemplates:    2,
+			DeprecatedTemplates: 3,
+		}))
+	})
+
+	It("should not report new installation", func() {
+		request.Instance.Status.ObservedVersion = ""
+		updateUpgradeSummary(request, []common.ResourceStatus{templatesChanges(10, 0, 0)}, true)
+		Expect(request.Instance.Status.LastUpgrade).To(BeNil())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should not report changes without version change", func() {
+		request.Instance.Status.ObservedVersion = version
+		updateUpgradeSummary(request, []common.ResourceStatus{templatesChanges(1, 0, 0)}, true)
+		Expect(request.Instance.Status.LastUpgrade).To(BeNil())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Requeue", func() {
+	It("should not requeue without requested delay", func() {
+		Expect(requeueAfter([]common.ResourceStatus{{}, {}})).To(BeZero())
+	})
+
+	It("should requeue after the shortest requested delay", func() {
+		Expect(requeueAfter([]common.ResourceStatus{
+			{RequeueAfter: time.Minute},
+			{},
+			{RequeueAfter: 10 * time.Second},
+		})).To(Equal(10 * time.Second))
+	})
+})
+
+var _ = Describe("Operand conditions", func() {
+	var request *common.Request
+
+	BeforeEach(func() {
+		request = &common.Request{Instance: &ssp.SSP{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ssp"},
+		}}
+	})
+
+	operandConditions := func(name string) []conditionsv1.Condition {
+		for _, operand := range request.Instance.Status.Operands {
+			if operand.Name == name {
+				return operand.Conditions
+			}
+		}
+		return nil
+	}
+
+	It("should report available operand", func() {
+		updateOperandConditions(request, "template-validator", []common.ResourceStatus{{}, {}}, nil)
+
+		conditions := operandConditions("template-validator")
+		Expect(conditionsv1.IsStatusConditionTrue(conditions, conditionsv1.ConditionAvailable)).To(BeTrue())
+		Expect(conditionsv1.IsStatusConditionFalse(conditions, conditionsv1.ConditionProgressing)).To(BeTrue())
+		Expect(conditionsv1.IsStatusConditionFalse(conditions, conditionsv1.ConditionDegraded)).To(BeTrue())
+		Expect(conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionAvailable).Message).
+			To(Equal("All template-validator resources are available"))
+	})
+
+	It("should report degraded common templates with failed templates", func() {
+		msg := "Failed to reconcile 1 templates: rhel8-server-tiny"
+		updateOperandConditions(request, "common-templates", []common.ResourceStatus{{}}, nil)
+		updateOperandConditions(request, "template-validator", []common.ResourceStatus{{}}, nil)
+		updateOperandConditions(request, "common-templates", []common.ResourceStatus{{
+			Resource: request.Instance,
+			Degraded: &msg,
+		}}, fmt.Errorf("failed to reconcile template"))
+
+		Expect(request.Instance.Status.Operands).To(HaveLen(2))
+		conditions := operandConditions("common-templates")
+		degraded := conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionDegraded)
+		Expect(degraded.Status).To(Equal(v1.ConditionTrue))
+		Expect(degraded.Message).To(ContainSubstring(msg))
+		Expect(conditionsv1.IsStatusConditionFalse(operandConditions("template-validator"), conditionsv1.ConditionDegraded)).To(BeTrue())
+	})
+
+	It("should report reconciliation error as degraded", func() {
+		updateOperandConditions(request, "node-labeller", nil, fmt.Errorf("test error"))
+
+		conditions := operandConditions("node-labeller")
+		Expect(conditionsv1.IsStatusConditionTrue(conditions, conditionsv1.ConditionProgressing)).To(BeTrue())
+		degraded := conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionDegraded)
+		Expect(degraded.Status).To(Equal(v1.ConditionTrue))
+		Expect(degraded.Message).To(Equal("Error: test error"))
+	})
+
+	It("should keep SSP condition messages", func() {
+		msg := "test progressing"
+		var conditions []conditionsv1.Condition
+		setResourceConditions(&conditions, []common.ResourceStatus{{}, {Progressing: &msg}, {Progressing: &msg}}, "SSP")
+
+		Expect(conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionAvailable).Message).
+			To(Equal("All SSP resources are available"))
+		Expect(conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionProgressing).Message).
+			To(Equal("2 SSP resources are progressing"))
+		Expect(conditionsv1.FindStatusCondition(conditions, conditionsv1.ConditionDegraded).Message).
+			To(Equal("No SSP resources are degraded"))
+	})
+})
+
+// cleanupOperand records calls to Cleanup and fails, if cleanupErr is set
+type cleanupOperand struct {
+	operands.Operand
+	name         string
+	cleanupErr   error
+	cleanupCalls int
+}
+
+func (o *cleanupOperand) Name() string {
+	return o.name
+}
+
+func (o *cleanupOperand) Cleanup(*common.Request) error {
+	o.cleanupCalls++
+	return o.cleanupErr
+}
+
+var _ = Describe("SSP deletion", func() {
+	const (
+		namespace = "kubevirt"
+		name      = "test-ssp"
+	)
+
+	var (
+		reconciler       *SSPReconciler
+		request          ctrl.Request
+		failingOperand   *cleanupOperand
+		succeededOperand *cleanupOperand
+		originalOperands []operands.Operand
+	)
+
+	getSsp := func() *ssp.SSP {
+		instance := &ssp.SSP{}
+		ExpectWithOffset(1, reconciler.Get(context.Background(), request.NamespacedName, instance)).To(Succeed())
+		return instance
+	}
+
+	BeforeEach(func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(ssp.AddToScheme(s)).To(Succeed())
+
+		deletionTime := metav1.Now()
+		instance := &ssp.SSP{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Finalizers:        []string{finalizerName},
+				DeletionTimestamp: &deletionTime,
+			},
+			Status: ssp.SSPStatus{
+				Status: lifecycleapi.Status{
+					Phase: lifecycleapi.PhaseDeployed,
+				},
+			},
+		}
+
+		reconciler = &SSPReconciler{
+			Client:           fake.NewFakeClientWithScheme(s, instance),
+			Log:              zap.New(zap.UseDevMode(true)),
+			SubresourceCache: common.VersionCache{},
+		}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+
+		failingOperand = &cleanupOperand{name: "failing", cleanupErr: fmt.Errorf("cleanup failed")}
+		succeededOperand = &cleanupOperand{name: "succeeded"}
+		originalOperands = sspOperands
+		sspOperands = []operands.Operand{failingOperand, succeededOperand}
+	})
+
+	AfterEach(func() {
+		sspOperands = originalOperands
+	})
+
+	It("should remove finalizer after all operands are cleaned up", func() {
+		failingOperand.cleanupErr = nil
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(failingOperand.cleanupCalls).To(Equal(1))
+		Expect(succeededOperand.cleanupCalls).To(Equal(1))
+		instance := getSsp()
+		Expect(instance.Finalizers).ToNot(ContainElement(finalizerName))
+		Expect(instance.Status.Phase).To(Equal(lifecycleapi.PhaseDeleted))
+	})
+
+	It("should keep finalizer if cleanup fails", func() {
+		_, err := reconciler.Reconcile(context.Background(), request)
+		Expect(err).To(MatchError(ContainSubstring("cleanup failed")))
+
+		// Other operands are cleaned up too
+		Expect(succeededOperand.cleanupCalls).To(Equal(1))
+		instance := getSsp()
+		Expect(instance.Finalizers).To(ContainElement(finalizerName))
+		Expect(instance.Status.Phase).To(Equal(lifecycleapi.PhaseDeleting))
+
+		failingOperand.cleanupErr = nil
+		_, err = reconciler.Reconcile(context.Background(), request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getSsp().Finalizers).ToNot(ContainElement(finalizerName))
+	})
+
+	It("should add removed finalizer to existing SSP", func() {
+		instance := getSsp()
+		instance.DeletionTimestamp = nil
+		instance.Finalizers = nil
+		Expect(reconciler.Update(context.Background(), instance)).To(Succeed())
+
+		_, err := reconciler.Reconcile(context.Background(), request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getSsp().Finalizers).To(ContainElement(finalizerName))
+	})
+})
@@ -1,6 +1,8 @@
 package common
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -19,8 +21,14 @@ type cacheValue struct {
 
 type VersionCache map[cacheKey]cacheValue
 
+// versionCacheLock guards all VersionCache instances,
+// because resources can be reconciled concurrently.
+var versionCacheLock sync.RWMutex
+
 func (v VersionCache) Contains(obj client.Object) bool {
+	versionCacheLock.RLock()
 	cached, ok := v[cacheKeyFromObj(obj)]
+	versionCacheLock.RUnlock()
 	if !ok {
 		return false
 	}
@@ -34,12 +42,22 @@ func (v VersionCache) Contains(obj client.Object) bool {
 	return cached.generation == obj.GetGeneration()
 }
 
+// UID returns the UID of the cached object with the same kind, name and namespace as obj
+func (v VersionCache) UID(obj client.Object) (types.UID, bool) {
+	versionCacheLock.RLock()
+	defer versionCacheLock.RUnlock()
+	cached, ok := v[cacheKeyFromObj(obj)]
+	return cached.uid, ok
+}
+
 func (v VersionCache) Add(obj client.Object) {
 	kind := obj.GetObjectKind().GroupVersionKind().Kind
-	if kind == "" {
-		// Do not cache objects without kind
+	if v == nil || kind == "" {
+		// Do not cache objects without kind, or if the request has no cache
 		return
 	}
+	versionCacheLock.Lock()
+	defer versionCacheLock.Unlock()
 	v[cacheKeyFromObj(obj)] = cacheValue{
 		uid:             obj.GetUID(),
 		resourceVersion: obj.GetResourceVersion(),
@@ -48,6 +66,8 @@ func (v VersionCache) Add(obj client.Object) {
 }
 
 func (v VersionCache) RemoveObj(obj client.Object) {
+	versionCacheLock.Lock()
+	defer versionCacheLock.Unlock()
 	delete(v, cacheKeyFromObj(obj))
 }
 
@@ -2,12 +2,21 @@ package common
 
 import (
 	"os"
+	"strconv"
 )
 
 const (
 	OperatorVersionKey = "OPERATOR_VERSION"
 
+	OperatorNamespaceKey = "OPERATOR_NAMESPACE"
+
 	TemplateValidatorImageKey = "VALIDATOR_IMAGE"
+
+	TemplatesReconcileParallelismKey = "TEMPLATES_RECONCILE_PARALLELISM"
+
+	TemplatesServerSideApplyKey = "TEMPLATES_SERVER_SIDE_APPLY"
+
+	TemplatesBundleDirKey = "COMMON_TEMPLATES_BUNDLE_DIR"
 )
 
 func EnvOrDefault(envName string, defVal string) string {
@@ -17,3 +26,19 @@ func EnvOrDefault(envName string, defVal string) string {
 	}
 	return val
 }
+
+func EnvOrDefaultInt(envName string, defVal int) int {
+	val, err := strconv.Atoi(os.Getenv(envName))
+	if err != nil {
+		return defVal
+	}
+	return val
+}
+
+func EnvOrDefaultBool(envName string, defVal bool) bool {
+	val, err := strconv.ParseBool(os.Getenv(envName))
+	if err != nil {
+		return defVal
+	}
+	return val
+}
@@ -0,0 +1,18 @@
+package common
+
+import "fmt"
+
+// CheckLeaderElectionNamespace returns an error, if the leader election lease is not
+// in the namespace where the operator is installed. Operators using leases in different
+// namespaces do not see each other, so both would reconcile the same resources.
+// If the lease namespace or the operator namespace is not known, no error is returned.
+func CheckLeaderElectionNamespace(leaseNamespace, operatorNamespace string) error {
+	if leaseNamespace == "" || operatorNamespace == "" {
+		return nil
+	}
+	if leaseNamespace != operatorNamespace {
+		return fmt.Errorf("leader election namespace \"%s\" does not match the operator namespace \"%s\"",
+			leaseNamespace, operatorNamespace)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package common
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Leader election namespace", func() {
+	DescribeTable("should accept lease configuration", func(leaseNamespace, operatorNamespace string) {
+		Expect(CheckLeaderElectionNamespace(leaseNamespace, operatorNamespace)).To(Succeed())
+	},
+		Entry("in the operator namespace", "kubevirt", "kubevirt"),
+		Entry("with default lease namespace", "", "kubevirt"),
+		Entry("with unknown operator namespace", "kubevirt", ""),
+	)
+
+	It("should reject lease in a different namespace", func() {
+		err := CheckLeaderElectionNamespace("default", "kubevirt")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`"default"`))
+		Expect(err.Error()).To(ContainSubstring(`"kubevirt"`))
+	})
+})
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourceScope is the scope of a managed resource
+type ResourceScope string
+
+const (
+	ClusterScope    ResourceScope = "Cluster"
+	NamespacedScope ResourceScope = "Namespaced"
+)
+
+// ManagedResource describes a resource managed by the operator without an owner reference
+type ManagedResource struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Scope     ResourceScope     `json:"scope"`
+	Operand   string            `json:"operand"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// ResourceRegistry contains resources reconciled by operands as cluster resources. These are
+// cluster-scoped resources, and namespaced resources outside of the SSP namespace.
+// It can be served as a JSON list for debugging.
+type ResourceRegistry struct {
+	lock      sync.RWMutex
+	resources map[cacheKey]ManagedResource
+}
+
+var _ http.Handler = &ResourceRegistry{}
+
+func NewResourceRegistry() *ResourceRegistry {
+	return &ResourceRegistry{resources: map[cacheKey]ManagedResource{}}
+}
+
+// Add records the resource as managed by the operand. Only app labels are stored.
+func (r *ResourceRegistry) Add(kind string, resource client.Object, operand string) {
+	labels := map[string]string{}
+	for key, value := range resource.GetLabels() {
+		if strings.HasPrefix(key, "app.kubernetes.io/") {
+			labels[key] = value
+		}
+	}
+
+	scope := ClusterScope
+	if resource.GetNamespace() != "" {
+		scope = NamespacedScope
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resources[cacheKey{Kind: kind, Name: resource.GetName(), Namespace: resource.GetNamespace()}] = ManagedResource{
+		Kind:      kind,
+		Name:      resource.GetName(),
+		Namespace: resource.GetNamespace(),
+		Scope:     scope,
+		Operand:   operand,
+		Labels:    labels,
+	}
+}
+
+// List returns managed resources sorted by kind, namespace and name
+func (r *ResourceRegistry) List() []ManagedResource {
+	r.lock.RLock()
+	result := make([]ManagedResource, 0, len(r.resources))
+	for _, resource := range r.resources {
+		result = append(result, resource)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Replace sets the content of this registry to the content of other
+func (r *ResourceRegistry) Replace(other *ResourceRegistry) {
+	other.lock.RLock()
+	resources := make(map[cacheKey]ManagedResource, len(other.resources))
+	for key, resource := range other.resources {
+		resources[key] = resource
+	}
+	other.lock.RUnlock()
+
+	r.lock.Lock()
+	r.resources = resources
+	r.lock.Unlock()
+}
+
+func (r *ResourceRegistry) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(r.List()); err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
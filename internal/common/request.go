@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -17,4 +18,41 @@ type Request struct {
 	Instance     *ssp.SSP
 	Logger       logr.Logger
 	VersionCache VersionCache
+	Recorder     record.EventRecorder
+
+	// APIReader reads directly from the API server. It is used for objects
+	// that should not be cached by the operator. It can be nil,
+	// then the Client is used, see UncachedReader.
+	APIReader client.Reader
+
+	// ResourceRegistry records cluster resources reconciled with this request, it can be nil
+	ResourceRegistry *ResourceRegistry
+
+	// DryRun enables dry-run mode for all resources reconciled with this request
+	DryRun bool
+}
+
+// UncachedReader returns a reader that does not start an informer for the read type
+func (r *Request) UncachedReader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+	return r.APIReader
+}
+
+// TemplateValidatorNamespace returns the namespace where the template validator is deployed
+func TemplateValidatorNamespace(request *Request) string {
+	namespace := request.Instance.Spec.TemplateValidator.Namespace
+	if namespace == "" {
+		return request.Namespace
+	}
+	return namespace
+}
+
+// Event records an event on the SSP instance. It does nothing if the request has no recorder.
+func (r *Request) Event(eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(r.Instance, eventType, reason, message)
 }
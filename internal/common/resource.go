@@ -1,15 +1,28 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	libhandler "github.com/operator-framework/operator-lib/handler"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 )
 
+// FieldManager is the field manager name used for server-side apply
+const FieldManager = "ssp-operator"
+
 type StatusMessage = *string
 
 type ResourceStatus struct {
@@ -17,6 +30,49 @@ type ResourceStatus struct {
 	Progressing  StatusMessage
 	NotAvailable StatusMessage
 	Degraded     StatusMessage
+
+	// Skipped is set if the resource was intentionally not fully reconciled.
+	Skipped StatusMessage
+
+	// CommonTemplates is the summary of reconciled common templates, it is copied to the SSP status.
+	CommonTemplates *ssp.CommonTemplatesStatus
+
+	// TemplateValidator is the state of the deployed template validator, it is copied to the SSP status.
+	TemplateValidator *ssp.TemplateValidatorStatus
+
+	// RequeueAfter requests another reconciliation after the duration,
+	// if the resource is waiting for a change that does not trigger reconciliation.
+	RequeueAfter time.Duration
+
+	// Upgrade contains changes made by the reconciliation, that are added to the upgrade summary
+	// in the SSP status, if the operator version changed.
+	Upgrade *ssp.UpgradeSummary
+
+	// DryRunResult is the operation that would be performed on the resource.
+	// It is only set in dry-run mode.
+	DryRunResult controllerutil.OperationResult
+
+	// ObjectRef references the resource, whose reconciliation failed.
+	ObjectRef *core.ObjectReference
+	// Error is the error message of the failed reconciliation.
+	Error StatusMessage
+}
+
+// FailedResourceStatus returns a status referencing the resource that failed to reconcile
+func FailedResourceStatus(resource client.Object, err error) ResourceStatus {
+	msg := err.Error()
+	gvk := resource.GetObjectKind().GroupVersionKind()
+	return ResourceStatus{
+		Resource: resource,
+		ObjectRef: &core.ObjectReference{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  resource.GetNamespace(),
+			Name:       resource.GetName(),
+			UID:        resource.GetUID(),
+		},
+		Error: &msg,
+	}
 }
 
 type ReconcileFunc = func(*Request) (ResourceStatus, error)
@@ -33,8 +89,54 @@ func CollectResourceStatus(request *Request, funcs ...ReconcileFunc) ([]Resource
 	return res, nil
 }
 
+// CollectResourceStatusParallel calls funcs using at most parallelism goroutines.
+// The returned statuses are in the same order as funcs. A failing function
+// does not stop the others, all errors are aggregated in the order of funcs.
+// Statuses are returned also with the error, so callers can report which resources failed.
+func CollectResourceStatusParallel(request *Request, parallelism int, funcs ...ReconcileFunc) ([]ResourceStatus, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	statuses := make([]ResourceStatus, len(funcs))
+	errs := make([]error, len(funcs))
+
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < parallelism && worker < len(funcs); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				statuses[i], errs[i] = funcs[i](request)
+			}
+		}()
+	}
+	for i := range funcs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return statuses, AggregateErrors(errs...)
+}
+
+// AggregateErrors returns an aggregate of the non-nil errors, or nil if there are none.
+// A single error is returned directly, so callers can inspect its type.
+func AggregateErrors(errs ...error) error {
+	aggregate := utilerrors.NewAggregate(errs)
+	if aggregate == nil {
+		return nil
+	}
+	if len(aggregate.Errors()) == 1 {
+		return aggregate.Errors()[0]
+	}
+	return aggregate
+}
+
 type ResourceUpdateFunc = func(expected, found client.Object)
 type ResourceStatusFunc = func(resource client.Object) ResourceStatus
+type ResourceExistingFunc = func(existing client.Object)
 
 type ReconcileBuilder interface {
 	NamespacedResource(client.Object) ReconcileBuilder
@@ -42,6 +144,11 @@ type ReconcileBuilder interface {
 	WithAppLabels(name string, component AppComponent) ReconcileBuilder
 	UpdateFunc(ResourceUpdateFunc) ReconcileBuilder
 	StatusFunc(ResourceStatusFunc) ReconcileBuilder
+	ExistingFunc(ResourceExistingFunc) ReconcileBuilder
+	ServerSideApply(enabled bool) ReconcileBuilder
+	WithDryRun() ReconcileBuilder
+	WithRetry(maxAttempts int, backoff time.Duration) ReconcileBuilder
+	Uncached() ReconcileBuilder
 
 	Reconcile() (ResourceStatus, error)
 }
@@ -55,8 +162,17 @@ type reconcileBuilder struct {
 	operandName      string
 	operandComponent AppComponent
 
-	updateFunc ResourceUpdateFunc
-	statusFunc ResourceStatusFunc
+	updateFunc   ResourceUpdateFunc
+	statusFunc   ResourceStatusFunc
+	existingFunc ResourceExistingFunc
+
+	serverSideApply bool
+	dryRun          bool
+
+	retryAttempts int
+	retryBackoff  time.Duration
+
+	uncached bool
 }
 
 var _ ReconcileBuilder = &reconcileBuilder{}
@@ -83,6 +199,43 @@ func (r *reconcileBuilder) StatusFunc(statusFunc ResourceStatusFunc) ReconcileBu
 	return r
 }
 
+// ExistingFunc is called with a copy of the resource read from the cluster, before it is updated.
+// It is not called, if the resource does not exist, or if it is reconciled with server-side apply.
+func (r *reconcileBuilder) ExistingFunc(existingFunc ResourceExistingFunc) ReconcileBuilder {
+	r.existingFunc = existingFunc
+	return r
+}
+
+// ServerSideApply enables server-side apply of the resource.
+// If the cluster does not support it, the resource is updated as usual.
+func (r *reconcileBuilder) ServerSideApply(enabled bool) ReconcileBuilder {
+	r.serverSideApply = enabled
+	return r
+}
+
+// WithDryRun sends all requests in dry-run mode, so no changes are persisted.
+// The resulting status describes the operation that would be performed.
+func (r *reconcileBuilder) WithDryRun() ReconcileBuilder {
+	r.dryRun = true
+	return r
+}
+
+// WithRetry retries the reconciliation of the resource up to maxAttempts times,
+// if it fails with a transient API error. The delay before each retry starts
+// at backoff and doubles after every attempt. Other errors are returned immediately.
+func (r *reconcileBuilder) WithRetry(maxAttempts int, backoff time.Duration) ReconcileBuilder {
+	r.retryAttempts = maxAttempts
+	r.retryBackoff = backoff
+	return r
+}
+
+// Uncached reads the resource directly from the API server, so no informer
+// is started for its type. Writes are sent with the request client as usual.
+func (r *reconcileBuilder) Uncached() ReconcileBuilder {
+	r.uncached = true
+	return r
+}
+
 func (r *reconcileBuilder) WithAppLabels(name string, component AppComponent) ReconcileBuilder {
 	r.addLabels = true
 	r.operandName = name
@@ -94,15 +247,104 @@ func (r *reconcileBuilder) Reconcile() (ResourceStatus, error) {
 	if r.addLabels {
 		AddAppLabels(r.request.Instance, r.operandName, r.operandComponent, r.resource)
 	}
+	if r.dryRun || r.request.DryRun {
+		return dryRunCreateOrUpdate(
+			r.request,
+			r.client(),
+			r.resource,
+			r.isClusterResource,
+			r.updateFunc,
+			r.statusFunc,
+			r.existingFunc,
+		)
+	}
+
+	status, err := r.reconcileResourceWithRetry()
+	if err != nil {
+		return status, err
+	}
+	if r.isClusterResource && r.request.ResourceRegistry != nil {
+		gvk, err := apiutil.GVKForObject(r.resource, r.request.Client.Scheme())
+		if err != nil {
+			return ResourceStatus{}, err
+		}
+		r.request.ResourceRegistry.Add(gvk.Kind, r.resource, r.operandName)
+	}
+	return status, nil
+}
+
+func (r *reconcileBuilder) reconcileResourceWithRetry() (ResourceStatus, error) {
+	delay := r.retryBackoff
+	for attempt := 1; ; attempt++ {
+		status, err := r.reconcileResource()
+		if err == nil || attempt >= r.retryAttempts || !isRetriableError(err) {
+			return status, err
+		}
+		r.request.Logger.V(1).Info(fmt.Sprintf("Resource reconciliation failed, retrying in %s: %v", delay, err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.request.Context.Done():
+			timer.Stop()
+			return status, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// isRetriableError returns true for errors caused by a temporary state of the API server
+func isRetriableError(err error) bool {
+	return errors.IsConflict(err) ||
+		errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) ||
+		errors.IsTooManyRequests(err)
+}
+
+func (r *reconcileBuilder) reconcileResource() (ResourceStatus, error) {
+	if r.serverSideApply {
+		status, err := apply(r.request, r.resource, r.isClusterResource, r.statusFunc)
+		if !errors.IsUnsupportedMediaType(err) {
+			return status, err
+		}
+		r.request.Logger.V(1).Info(fmt.Sprintf("Server-side apply is not supported, falling back to update: %v", err))
+	}
 	return createOrUpdate(
 		r.request,
+		r.client(),
 		r.resource,
 		r.isClusterResource,
 		r.updateFunc,
 		r.statusFunc,
+		r.existingFunc,
 	)
 }
 
+// client returns the client used to read and write the resource
+func (r *reconcileBuilder) client() client.Client {
+	if !r.uncached {
+		return r.request.Client
+	}
+	return &uncachedClient{
+		Client: r.request.Client,
+		reader: r.request.UncachedReader(),
+	}
+}
+
+// uncachedClient sends reads to the reader and all other requests to the client
+type uncachedClient struct {
+	client.Client
+	reader client.Reader
+}
+
+func (c *uncachedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return c.reader.Get(ctx, key, obj)
+}
+
+func (c *uncachedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.reader.List(ctx, list, opts...)
+}
+
 func CreateOrUpdate(request *Request) ReconcileBuilder {
 	if request == nil {
 		panic("Request should not be nil")
@@ -116,10 +358,13 @@ func CreateOrUpdate(request *Request) ReconcileBuilder {
 		statusFunc: func(_ client.Object) ResourceStatus {
 			return ResourceStatus{}
 		},
+		existingFunc: func(_ client.Object) {
+			// Empty function
+		},
 	}
 }
 
-func createOrUpdate(request *Request, resource client.Object, isClusterRes bool, updateResource ResourceUpdateFunc, statusFunc ResourceStatusFunc) (ResourceStatus, error) {
+func createOrUpdate(request *Request, cl client.Client, resource client.Object, isClusterRes bool, updateResource ResourceUpdateFunc, statusFunc ResourceStatusFunc, existingFunc ResourceExistingFunc) (ResourceStatus, error) {
 	err := setOwner(request, resource, isClusterRes)
 	if err != nil {
 		return ResourceStatus{}, err
@@ -128,7 +373,11 @@ func createOrUpdate(request *Request, resource client.Object, isClusterRes bool,
 	found := newEmptyResource(resource)
 	found.SetName(resource.GetName())
 	found.SetNamespace(resource.GetNamespace())
-	res, err := controllerutil.CreateOrUpdate(request.Context, request.Client, found, func() error {
+	res, err := controllerutil.CreateOrUpdate(request.Context, cl, found, func() error {
+		if found.GetResourceVersion() != "" {
+			existingFunc(found.DeepCopyObject().(client.Object))
+		}
+
 		// We expect users will not add any other owner references,
 		// if that is not correct, this code needs to be changed.
 		found.SetOwnerReferences(resource.GetOwnerReferences())
@@ -147,6 +396,16 @@ func createOrUpdate(request *Request, resource client.Object, isClusterRes bool,
 		return ResourceStatus{}, err
 	}
 
+	if found.GetObjectKind().GroupVersionKind().Empty() {
+		// Typed objects returned by create requests do not contain kind,
+		// it is needed to store them in the version cache.
+		gvk, err := apiutil.GVKForObject(found, request.Client.Scheme())
+		if err != nil {
+			return ResourceStatus{}, err
+		}
+		found.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+
 	request.VersionCache.Add(found)
 	logOperation(res, found, request.Logger)
 
@@ -155,10 +414,101 @@ func createOrUpdate(request *Request, resource client.Object, isClusterRes bool,
 	return status, nil
 }
 
+// dryRunCreateOrUpdate computes the same changes as createOrUpdate,
+// but sends the create or update request in dry-run mode.
+// The version cache is not used, so the update function is always called.
+func dryRunCreateOrUpdate(request *Request, cl client.Client, resource client.Object, isClusterRes bool, updateResource ResourceUpdateFunc, statusFunc ResourceStatusFunc, existingFunc ResourceExistingFunc) (ResourceStatus, error) {
+	err := setOwner(request, resource, isClusterRes)
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+
+	found := newEmptyResource(resource)
+	err = cl.Get(request.Context, client.ObjectKeyFromObject(resource), found)
+	if err != nil && !errors.IsNotFound(err) {
+		return ResourceStatus{}, err
+	}
+
+	var result controllerutil.OperationResult
+	if errors.IsNotFound(err) {
+		found = resource.DeepCopyObject().(client.Object)
+		err = cl.Create(request.Context, found, client.DryRunAll)
+		result = controllerutil.OperationResultCreated
+	} else {
+		existingFunc(found.DeepCopyObject().(client.Object))
+		existing := found.DeepCopyObject()
+		found.SetOwnerReferences(resource.GetOwnerReferences())
+		updateLabels(resource, found)
+		updateAnnotations(resource, found)
+		updateResource(resource, found)
+		if equality.Semantic.DeepEqual(existing, found) {
+			result = controllerutil.OperationResultNone
+		} else {
+			err = cl.Update(request.Context, found, client.DryRunAll)
+			result = controllerutil.OperationResultUpdated
+		}
+	}
+	if err != nil {
+		request.Logger.V(1).Info(fmt.Sprintf("Resource dry-run create/update failed: %v", err))
+		return ResourceStatus{}, err
+	}
+
+	status := statusFunc(found)
+	status.Resource = resource
+	status.DryRunResult = result
+	return status, nil
+}
+
+// apply sends the resource as a server-side apply patch. All fields set in the resource
+// are owned by the operator and conflicts with other managers are overridden.
+// Fields set by other managers and not present in the resource are kept.
+func apply(request *Request, resource client.Object, isClusterRes bool, statusFunc ResourceStatusFunc) (ResourceStatus, error) {
+	err := setOwner(request, resource, isClusterRes)
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+
+	gvk, err := apiutil.GVKForObject(resource, request.Client.Scheme())
+	if err != nil {
+		return ResourceStatus{}, err
+	}
+
+	applied := resource.DeepCopyObject().(client.Object)
+	applied.GetObjectKind().SetGroupVersionKind(gvk)
+	applied.SetResourceVersion("")
+	applied.SetManagedFields(nil)
+
+	err = request.Client.Patch(request.Context, applied, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership)
+	if err != nil {
+		request.Logger.V(1).Info(fmt.Sprintf("Resource apply failed: %v", err))
+		return ResourceStatus{}, err
+	}
+
+	request.VersionCache.Add(applied)
+
+	status := statusFunc(applied)
+	status.Resource = resource
+	return status, nil
+}
+
+// setOwner sets the SSP CR as the owner of the resource.
+//
+// Resources reconciled as cluster resources use owner annotations, which are used
+// to enqueue the SSP CR when they change. Kubernetes garbage collection does not allow
+// a namespaced owner for cluster-scoped resources, or for resources in other namespaces,
+// so these are only removed by the explicit operand Cleanup. If such a resource is
+// in the same namespace as the SSP CR, a controller reference is also set,
+// so it is garbage collected even if the CR is deleted while the operator is down.
 func setOwner(request *Request, resource client.Object, isClusterRes bool) error {
 	if isClusterRes {
 		resource.SetOwnerReferences(nil)
-		return libhandler.SetOwnerAnnotations(request.Instance, resource)
+		if err := libhandler.SetOwnerAnnotations(request.Instance, resource); err != nil {
+			return err
+		}
+		if resource.GetNamespace() == "" || resource.GetNamespace() != request.Instance.GetNamespace() {
+			return nil
+		}
+		return controllerutil.SetControllerReference(request.Instance, resource, request.Client.Scheme())
 	} else {
 		delete(resource.GetAnnotations(), libhandler.NamespacedNameAnnotation)
 		delete(resource.GetAnnotations(), libhandler.TypeAnnotation)
@@ -2,18 +2,27 @@ package common
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	libhandler "github.com/operator-framework/operator-lib/handler"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -112,6 +121,36 @@ var _ = Describe("Create or update resource", func() {
 		Expect(found.GetAnnotations()).To(HaveKey(libhandler.NamespacedNameAnnotation))
 	})
 
+	It("should set owner reference and annotations to cluster resource in the same namespace", func() {
+		_, err := CreateOrUpdate(&request).
+			ClusterResource(newTestResource(namespace)).
+			Reconcile()
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newTestResource(namespace))
+		found := &v1.Service{}
+		Expect(request.Client.Get(request.Context, key, found)).ToNot(HaveOccurred())
+
+		Expect(found.GetAnnotations()).To(HaveKeyWithValue(libhandler.TypeAnnotation, "SSP.ssp.kubevirt.io"))
+		Expect(found.GetOwnerReferences()).To(HaveLen(1))
+		Expect(found.GetOwnerReferences()[0].Kind).To(Equal("SSP"))
+		Expect(*found.GetOwnerReferences()[0].Controller).To(BeTrue())
+	})
+
+	It("should not set owner reference to cluster resource in other namespace", func() {
+		_, err := CreateOrUpdate(&request).
+			ClusterResource(newTestResource("other-namespace")).
+			Reconcile()
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newTestResource("other-namespace"))
+		found := &v1.Service{}
+		Expect(request.Client.Get(request.Context, key, found)).ToNot(HaveOccurred())
+
+		Expect(found.GetAnnotations()).To(HaveKey(libhandler.NamespacedNameAnnotation))
+		Expect(found.GetOwnerReferences()).To(BeEmpty())
+	})
+
 	It("should not update resource with cached version", func() {
 		resource := newTestResource(namespace)
 		resource.Spec.Ports[0].Name = "changed-name"
@@ -154,6 +193,514 @@ var _ = Describe("Create or update resource", func() {
 		Expect(err).ToNot(HaveOccurred())
 		expectEqualResourceExists(newTestResource(namespace), &request)
 	})
+
+	It("should call existing func with the resource before update", func() {
+		resource := newTestResource(namespace)
+		resource.Spec.Ports[0].Name = "changed-name"
+		Expect(request.Client.Create(request.Context, resource)).ToNot(HaveOccurred())
+
+		var existing *v1.Service
+		_, err := CreateOrUpdate(&request).
+			NamespacedResource(newTestResource(namespace)).
+			ExistingFunc(func(found client.Object) {
+				existing = found.(*v1.Service)
+			}).
+			UpdateFunc(func(expected, found client.Object) {
+				found.(*v1.Service).Spec = expected.(*v1.Service).Spec
+			}).
+			Reconcile()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(existing).ToNot(BeNil())
+		Expect(existing.Spec.Ports[0].Name).To(Equal("changed-name"))
+		expectEqualResourceExists(newTestResource(namespace), &request)
+	})
+
+	It("should not call existing func when resource is created", func() {
+		called := false
+		_, err := CreateOrUpdate(&request).
+			NamespacedResource(newTestResource(namespace)).
+			ExistingFunc(func(client.Object) {
+				called = true
+			}).
+			Reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(called).To(BeFalse())
+	})
+})
+
+var _ = Describe("Collect resource status in parallel", func() {
+	const funcCount = 50
+
+	var request Request
+
+	BeforeEach(func() {
+		request = Request{
+			Context: context.Background(),
+			Logger:  log,
+		}
+	})
+
+	newFuncs := func(failing map[int]bool) []ReconcileFunc {
+		funcs := make([]ReconcileFunc, 0, funcCount)
+		for i := 0; i < funcCount; i++ {
+			index := i
+			funcs = append(funcs, func(*Request) (ResourceStatus, error) {
+				if failing[index] {
+					return ResourceStatus{}, fmt.Errorf("func %d failed", index)
+				}
+				msg := fmt.Sprintf("%d", index)
+				return ResourceStatus{Progressing: &msg}, nil
+			})
+		}
+		return funcs
+	}
+
+	It("should return statuses in order", func() {
+		statuses, err := CollectResourceStatusParallel(&request, 7, newFuncs(nil)...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(HaveLen(funcCount))
+		for i, status := range statuses {
+			Expect(*status.Progressing).To(Equal(fmt.Sprintf("%d", i)))
+		}
+	})
+
+	It("should run all funcs and aggregate errors in order", func() {
+		var called int32
+		funcs := newFuncs(map[int]bool{3: true, 40: true})
+		for i := range funcs {
+			f := funcs[i]
+			funcs[i] = func(r *Request) (ResourceStatus, error) {
+				atomic.AddInt32(&called, 1)
+				return f(r)
+			}
+		}
+
+		_, err := CollectResourceStatusParallel(&request, 7, funcs...)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("[func 3 failed, func 40 failed]"))
+		Expect(atomic.LoadInt32(&called)).To(Equal(int32(funcCount)))
+	})
+
+	It("should return a single error unwrapped", func() {
+		_, err := CollectResourceStatusParallel(&request, 7, newFuncs(map[int]bool{5: true})...)
+		Expect(err).To(MatchError("func 5 failed"))
+	})
+
+	It("should return statuses of all funcs with the error", func() {
+		statuses, err := CollectResourceStatusParallel(&request, 7, newFuncs(map[int]bool{5: true})...)
+		Expect(err).To(HaveOccurred())
+		Expect(statuses).To(HaveLen(funcCount))
+		Expect(statuses[5].Progressing).To(BeNil())
+		Expect(*statuses[6].Progressing).To(Equal("6"))
+	})
+})
+
+var _ = Describe("Dry-run create or update", func() {
+	var request Request
+
+	BeforeEach(func() {
+		s := scheme.Scheme
+		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
+
+		request = Request{
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace,
+					Name:      name,
+				},
+			},
+			Client:  fake.NewFakeClientWithScheme(s),
+			Context: context.Background(),
+			Instance: &ssp.SSP{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "SSP",
+					APIVersion: ssp.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+			Logger:       log,
+			VersionCache: VersionCache{},
+		}
+	})
+
+	dryRunTestResource := func() (ResourceStatus, error) {
+		return CreateOrUpdate(&request).
+			NamespacedResource(newTestResource(namespace)).
+			WithDryRun().
+			UpdateFunc(func(expected, found client.Object) {
+				found.(*v1.Service).Spec = expected.(*v1.Service).Spec
+			}).
+			Reconcile()
+	}
+
+	It("should not create resource", func() {
+		status, err := dryRunTestResource()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.DryRunResult).To(Equal(controllerutil.OperationResultCreated))
+
+		err = request.Client.Get(request.Context, client.ObjectKeyFromObject(newTestResource(namespace)), &v1.Service{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not update resource", func() {
+		resource := newTestResource(namespace)
+		resource.Spec.Ports[0].Name = "changed-name"
+		Expect(request.Client.Create(request.Context, resource)).To(Succeed())
+
+		status, err := dryRunTestResource()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.DryRunResult).To(Equal(controllerutil.OperationResultUpdated))
+
+		found := &v1.Service{}
+		Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(resource), found)).To(Succeed())
+		Expect(found.Spec.Ports[0].Name).To(Equal("changed-name"))
+	})
+
+	It("should report unchanged resource", func() {
+		_, err := createOrUpdateTestResource(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		status, err := dryRunTestResource()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.DryRunResult).To(Equal(controllerutil.OperationResultNone))
+	})
+
+	It("should use dry-run mode from request", func() {
+		request.DryRun = true
+		status, err := createOrUpdateTestResource(&request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.DryRunResult).To(Equal(controllerutil.OperationResultCreated))
+
+		err = request.Client.Get(request.Context, client.ObjectKeyFromObject(newTestResource(namespace)), &v1.Service{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Server-side apply", func() {
+	var (
+		request     Request
+		applyClient *testApplyClient
+	)
+
+	BeforeEach(func() {
+		s := scheme.Scheme
+		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
+
+		applyClient = &testApplyClient{Client: fake.NewFakeClientWithScheme(s)}
+		request = Request{
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace,
+					Name:      name,
+				},
+			},
+			Client:  applyClient,
+			Context: context.Background(),
+			Instance: &ssp.SSP{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "SSP",
+					APIVersion: ssp.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+			Logger:       log,
+			VersionCache: VersionCache{},
+		}
+	})
+
+	applyTestResource := func() (ResourceStatus, error) {
+		return CreateOrUpdate(&request).
+			NamespacedResource(newTestResource(namespace)).
+			ServerSideApply(true).
+			UpdateFunc(func(expected, found client.Object) {
+				found.(*v1.Service).Spec = expected.(*v1.Service).Spec
+			}).
+			Reconcile()
+	}
+
+	It("should apply resource with field manager", func() {
+		_, err := applyTestResource()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(applyClient.patchType).To(Equal(types.ApplyPatchType))
+		Expect(applyClient.patchOptions.FieldManager).To(Equal(FieldManager))
+		Expect(*applyClient.patchOptions.Force).To(BeTrue())
+
+		applied := &v1.Service{}
+		Expect(json.Unmarshal(applyClient.patchData, applied)).To(Succeed())
+		Expect(applied.Kind).To(Equal("Service"))
+		Expect(applied.Labels).To(HaveKeyWithValue("test-label", "value1"))
+		Expect(applied.OwnerReferences).To(HaveLen(1))
+		Expect(applied.ResourceVersion).To(BeEmpty())
+	})
+
+	It("should fall back to update if apply is not supported", func() {
+		applyClient.unsupported = true
+
+		_, err := applyTestResource()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applyClient.patchType).To(Equal(types.ApplyPatchType))
+		expectEqualResourceExists(newTestResource(namespace), &request)
+	})
+
+	It("should return other apply errors", func() {
+		applyClient.err = fmt.Errorf("test error")
+
+		_, err := applyTestResource()
+		Expect(err).To(MatchError("test error"))
+		Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(newTestResource(namespace)), &v1.Service{})).
+			ToNot(Succeed())
+	})
+})
+
+// testApplyClient records apply patches, because they are not supported by the fake client
+type testApplyClient struct {
+	client.Client
+
+	unsupported bool
+	err         error
+
+	patchType    types.PatchType
+	patchData    []byte
+	patchOptions client.PatchOptions
+}
+
+func (c *testApplyClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+
+	c.patchType = patch.Type()
+	c.patchOptions.ApplyOptions(opts)
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	c.patchData = data
+
+	if c.unsupported {
+		return errors.NewGenericServerResponse(http.StatusUnsupportedMediaType, "patch", schema.GroupResource{}, obj.GetName(), "", 0, false)
+	}
+	return c.err
+}
+
+var _ = Describe("Retry create or update", func() {
+	var (
+		request     Request
+		retryClient *testRetryClient
+	)
+
+	BeforeEach(func() {
+		s := scheme.Scheme
+		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
+
+		retryClient = &testRetryClient{Client: fake.NewFakeClientWithScheme(s)}
+		request = Request{
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace,
+					Name:      name,
+				},
+			},
+			Client:  retryClient,
+			Context: context.Background(),
+			Instance: &ssp.SSP{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "SSP",
+					APIVersion: ssp.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+			Logger:       log,
+			VersionCache: VersionCache{},
+		}
+	})
+
+	createWithRetry := func(maxAttempts int) (ResourceStatus, error) {
+		return CreateOrUpdate(&request).
+			NamespacedResource(newTestResource(namespace)).
+			UpdateFunc(func(expected, found client.Object) {
+				found.(*v1.Service).Spec = expected.(*v1.Service).Spec
+			}).
+			WithRetry(maxAttempts, time.Millisecond).
+			Reconcile()
+	}
+
+	conflictErr := errors.NewConflict(schema.GroupResource{Resource: "services"}, "testservice", fmt.Errorf("test conflict"))
+
+	It("should retry after conflict", func() {
+		retryClient.errs = []error{conflictErr}
+
+		_, err := createWithRetry(3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(retryClient.creates).To(Equal(2))
+		expectEqualResourceExists(newTestResource(namespace), &request)
+	})
+
+	It("should retry after server timeout and too many requests", func() {
+		retryClient.errs = []error{
+			errors.NewServerTimeout(schema.GroupResource{Resource: "services"}, "create", 1),
+			errors.NewTooManyRequests("test", 1),
+		}
+
+		_, err := createWithRetry(3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(retryClient.creates).To(Equal(3))
+	})
+
+	It("should return error after all attempts failed", func() {
+		retryClient.errs = []error{conflictErr, conflictErr, conflictErr}
+
+		_, err := createWithRetry(2)
+		Expect(errors.IsConflict(err)).To(BeTrue())
+		Expect(retryClient.creates).To(Equal(2))
+	})
+
+	It("should not retry other errors", func() {
+		retryClient.errs = []error{fmt.Errorf("test error")}
+
+		_, err := createWithRetry(3)
+		Expect(err).To(MatchError("test error"))
+		Expect(retryClient.creates).To(Equal(1))
+	})
+
+	It("should not retry without WithRetry", func() {
+		retryClient.errs = []error{conflictErr}
+
+		_, err := createOrUpdateTestResource(&request)
+		Expect(errors.IsConflict(err)).To(BeTrue())
+		Expect(retryClient.creates).To(Equal(1))
+	})
+})
+
+// testRetryClient fails create requests with the queued errors, before passing them to the fake client
+type testRetryClient struct {
+	client.Client
+
+	errs    []error
+	creates int
+}
+
+func (c *testRetryClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.creates++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("Managed resource registry", func() {
+	var request Request
+
+	BeforeEach(func() {
+		s := scheme.Scheme
+		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
+
+		request = Request{
+			Request: reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: namespace,
+					Name:      name,
+				},
+			},
+			Client:  fake.NewFakeClientWithScheme(s),
+			Context: context.Background(),
+			Instance: &ssp.SSP{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "SSP",
+					APIVersion: ssp.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+			Logger:           log,
+			VersionCache:     VersionCache{},
+			ResourceRegistry: NewResourceRegistry(),
+		}
+	})
+
+	reconcileClusterResource := func(resource client.Object) {
+		_, err := CreateOrUpdate(&request).
+			ClusterResource(resource).
+			WithAppLabels("test-operand", AppComponentTemplating).
+			Reconcile()
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	}
+
+	It("should list reconciled cluster resources", func() {
+		reconcileClusterResource(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-namespace"}})
+		reconcileClusterResource(newTestResource(""))
+
+		resources := request.ResourceRegistry.List()
+		Expect(resources).To(HaveLen(2))
+
+		Expect(resources[0].Kind).To(Equal("Namespace"))
+		Expect(resources[0].Name).To(Equal("test-namespace"))
+		Expect(resources[0].Operand).To(Equal("test-operand"))
+		Expect(resources[0].Labels).To(HaveKeyWithValue(AppKubernetesComponentLabel, AppComponentTemplating.String()))
+		Expect(resources[0].Labels).To(HaveKeyWithValue(AppKubernetesManagedByLabel, "ssp-operator"))
+
+		Expect(resources[1].Kind).To(Equal("Service"))
+		Expect(resources[1].Name).To(Equal(newTestResource("").Name))
+		Expect(resources[1].Labels).ToNot(HaveKey("test-label"))
+	})
+
+	It("should list resources with the same name in different namespaces", func() {
+		reconcileClusterResource(newTestResource("namespace-a"))
+		reconcileClusterResource(newTestResource("namespace-b"))
+		reconcileClusterResource(newTestResource(""))
+
+		resources := request.ResourceRegistry.List()
+		Expect(resources).To(HaveLen(3))
+
+		Expect(resources[0].Namespace).To(BeEmpty())
+		Expect(resources[0].Scope).To(Equal(ClusterScope))
+		Expect(resources[1].Namespace).To(Equal("namespace-a"))
+		Expect(resources[1].Scope).To(Equal(NamespacedScope))
+		Expect(resources[2].Namespace).To(Equal("namespace-b"))
+		Expect(resources[2].Scope).To(Equal(NamespacedScope))
+	})
+
+	It("should not list namespaced resources", func() {
+		_, err := createOrUpdateTestResource(&request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(request.ResourceRegistry.List()).To(BeEmpty())
+	})
+
+	It("should not list resources in dry-run mode", func() {
+		request.DryRun = true
+		reconcileClusterResource(newTestResource(""))
+		Expect(request.ResourceRegistry.List()).To(BeEmpty())
+	})
+
+	It("should serve resources as JSON", func() {
+		reconcileClusterResource(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-namespace"}})
+
+		served := NewResourceRegistry()
+		served.Replace(request.ResourceRegistry)
+
+		recorder := httptest.NewRecorder()
+		served.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/managed-resources", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var resources []ManagedResource
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &resources)).To(Succeed())
+		Expect(resources).To(Equal(request.ResourceRegistry.List()))
+	})
 })
 
 func createOrUpdateTestResource(request *Request) (ResourceStatus, error) {
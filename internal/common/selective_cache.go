@@ -0,0 +1,283 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const defaultCacheResync = 10 * time.Hour
+
+var cacheLog = logf.Log.WithName("selective-cache")
+
+// CacheSelector restricts the objects of a type that are cached to the ones matching the selector
+type CacheSelector struct {
+	Object   client.Object
+	Selector labels.Selector
+}
+
+// SelectiveCacheBuilder returns a function creating a cache, that holds only objects matching
+// the selectors. Reads of objects outside of the selectors are served by the API server.
+// Types without a selector are cached as usual.
+func SelectiveCacheBuilder(selectors []CacheSelector) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		delegate, err := cache.New(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(selectors) == 0 {
+			return delegate, nil
+		}
+
+		if opts.Scheme == nil {
+			opts.Scheme = clientgoscheme.Scheme
+		}
+		if opts.Mapper == nil {
+			opts.Mapper, err = apiutil.NewDiscoveryRESTMapper(config)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resync := defaultCacheResync
+		if opts.Resync != nil {
+			resync = *opts.Resync
+		}
+
+		reader, err := client.New(config, client.Options{Scheme: opts.Scheme, Mapper: opts.Mapper})
+		if err != nil {
+			return nil, err
+		}
+
+		selective := newSelectiveCache(delegate, reader, opts.Scheme)
+		codecs := serializer.NewCodecFactory(opts.Scheme)
+		for _, selector := range selectors {
+			gvk, err := apiutil.GVKForObject(selector.Object, opts.Scheme)
+			if err != nil {
+				return nil, err
+			}
+			mapping, err := opts.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if apimeta.IsNoMatchError(err) {
+				// The API does not exist in the cluster, so there is nothing to filter
+				cacheLog.Info(fmt.Sprintf("API for %s not found, it is cached without selector", gvk.Kind))
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			restClient, err := apiutil.RESTClientForGVK(gvk, false, config, codecs)
+			if err != nil {
+				return nil, err
+			}
+			listWatch := toolscache.NewFilteredListWatchFromClient(restClient, mapping.Resource.Resource, opts.Namespace,
+				func(*metav1.ListOptions) {})
+			selective.addFiltered(gvk, selector, listWatch, resync)
+		}
+		return selective, nil
+	}
+}
+
+// filteredInformer caches objects of a single type matching the selector
+type filteredInformer struct {
+	informer toolscache.SharedIndexInformer
+	gvk      schema.GroupVersionKind
+	objType  reflect.Type
+	selector labels.Selector
+}
+
+// selectiveCache serves types with a selector from their filtered informers
+// and all other types from the delegate cache.
+type selectiveCache struct {
+	cache.Cache
+	reader   client.Reader
+	scheme   *runtime.Scheme
+	filtered map[schema.GroupVersionKind]*filteredInformer
+}
+
+var _ cache.Cache = &selectiveCache{}
+
+func newSelectiveCache(delegate cache.Cache, reader client.Reader, scheme *runtime.Scheme) *selectiveCache {
+	return &selectiveCache{
+		Cache:    delegate,
+		reader:   reader,
+		scheme:   scheme,
+		filtered: map[schema.GroupVersionKind]*filteredInformer{},
+	}
+}
+
+// addFiltered adds an informer for the type, listing and watching only objects matching the selector
+func (c *selectiveCache) addFiltered(gvk schema.GroupVersionKind, selector CacheSelector, listWatch toolscache.ListerWatcher, resync time.Duration) {
+	labelSelector := selector.Selector.String()
+	filteredListWatch := &toolscache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = labelSelector
+			return listWatch.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = labelSelector
+			return listWatch.Watch(options)
+		},
+	}
+	c.filtered[gvk] = &filteredInformer{
+		informer: toolscache.NewSharedIndexInformer(filteredListWatch, selector.Object, resync, toolscache.Indexers{
+			toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc,
+		}),
+		gvk:      gvk,
+		objType:  reflect.TypeOf(selector.Object),
+		selector: selector.Selector,
+	}
+}
+
+// filteredFor returns the filtered informer for the object, if it has the cached type
+func (c *selectiveCache) filteredFor(obj runtime.Object) *filteredInformer {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	return c.filtered[gvk]
+}
+
+func (c *selectiveCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	filtered := c.filteredFor(obj)
+	if filtered == nil {
+		return c.Cache.Get(ctx, key, obj)
+	}
+	if reflect.TypeOf(obj) != filtered.objType {
+		// Metadata only and unstructured objects are not cached
+		return c.reader.Get(ctx, key, obj)
+	}
+
+	item, exists, err := filtered.informer.GetIndexer().GetByKey(storeKey(key))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// The object may exist, but not match the selector
+		return c.reader.Get(ctx, key, obj)
+	}
+	reflect.Indirect(reflect.ValueOf(obj)).Set(reflect.Indirect(reflect.ValueOf(item.(runtime.Object).DeepCopyObject())))
+	obj.GetObjectKind().SetGroupVersionKind(filtered.gvk)
+	return nil
+}
+
+func (c *selectiveCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	filtered := c.filteredFor(list)
+	if filtered == nil {
+		return c.Cache.List(ctx, list, opts...)
+	}
+
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if !filtered.covers(list, listOpts) {
+		return c.reader.List(ctx, list, opts...)
+	}
+
+	var items []interface{}
+	var err error
+	if listOpts.Namespace != "" {
+		items, err = filtered.informer.GetIndexer().ByIndex(toolscache.NamespaceIndex, listOpts.Namespace)
+	} else {
+		items = filtered.informer.GetIndexer().List()
+	}
+	if err != nil {
+		return err
+	}
+
+	objects := make([]runtime.Object, 0, len(items))
+	for _, item := range items {
+		obj := item.(client.Object)
+		if listOpts.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+			objects = append(objects, obj.DeepCopyObject())
+		}
+	}
+	return apimeta.SetList(list, objects)
+}
+
+func (c *selectiveCache) GetInformer(ctx context.Context, obj client.Object) (cache.Informer, error) {
+	if filtered := c.filteredFor(obj); filtered != nil && reflect.TypeOf(obj) == filtered.objType {
+		return filtered.informer, nil
+	}
+	return c.Cache.GetInformer(ctx, obj)
+}
+
+func (c *selectiveCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	if filtered, ok := c.filtered[gvk]; ok {
+		return filtered.informer, nil
+	}
+	return c.Cache.GetInformerForKind(ctx, gvk)
+}
+
+func (c *selectiveCache) Start(ctx context.Context) error {
+	for _, filtered := range c.filtered {
+		go filtered.informer.Run(ctx.Done())
+	}
+	return c.Cache.Start(ctx)
+}
+
+func (c *selectiveCache) WaitForCacheSync(ctx context.Context) bool {
+	synced := make([]toolscache.InformerSynced, 0, len(c.filtered))
+	for _, filtered := range c.filtered {
+		synced = append(synced, filtered.informer.HasSynced)
+	}
+	if !toolscache.WaitForCacheSync(ctx.Done(), synced...) {
+		return false
+	}
+	return c.Cache.WaitForCacheSync(ctx)
+}
+
+func (c *selectiveCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	if filtered := c.filteredFor(obj); filtered != nil {
+		return fmt.Errorf("field indexes are not supported for %s, it is cached with a selector", filtered.gvk.Kind)
+	}
+	return c.Cache.IndexField(ctx, obj, field, extractValue)
+}
+
+// covers returns true, if all objects matching the list options are in the cache
+func (f *filteredInformer) covers(list client.ObjectList, opts *client.ListOptions) bool {
+	if reflect.TypeOf(list).Elem().Name() != f.objType.Elem().Name()+"List" {
+		// Metadata only and unstructured lists are not cached
+		return false
+	}
+	if opts.FieldSelector != nil || opts.LabelSelector == nil {
+		return false
+	}
+	requested, _ := opts.LabelSelector.Requirements()
+	required, _ := f.selector.Requirements()
+	for _, requirement := range required {
+		found := false
+		for _, r := range requested {
+			if r.String() == requirement.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func storeKey(key client.ObjectKey) string {
+	if key.Namespace == "" {
+		return key.Name
+	}
+	return key.Namespace + "/" + key.Name
+}
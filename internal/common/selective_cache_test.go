@@ -0,0 +1,187 @@
+package common
+
+import (
+	"context"
+	"sort"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	templatev1 "github.com/openshift/api/template/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// startedCache is the delegate cache, it is only started and synced
+type startedCache struct {
+	cache.Cache
+}
+
+func (c *startedCache) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c *startedCache) WaitForCacheSync(context.Context) bool {
+	return true
+}
+
+var _ = Describe("Selective cache", func() {
+	const (
+		typeLabel        = "template.kubevirt.io/type"
+		readerAnnotation = "test.kubevirt.io/from-reader"
+	)
+
+	var (
+		templates     []templatev1.Template
+		listSelectors []string
+		selective     *selectiveCache
+		cancel        context.CancelFunc
+	)
+
+	newTemplate := func(namespace, name string, labels map[string]string) templatev1.Template {
+		return templatev1.Template{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: templatev1.GroupVersion.String(),
+				Kind:       "Template",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+				Labels:    labels,
+			},
+		}
+	}
+
+	// listWatch simulates the API server, it lists only templates matching the label selector.
+	// It is used instead of envtest, because the test environment binaries are not available to unit tests.
+	listWatch := &toolscache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			listSelectors = append(listSelectors, options.LabelSelector)
+			selector, err := labels.Parse(options.LabelSelector)
+			if err != nil {
+				return nil, err
+			}
+			list := &templatev1.TemplateList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}}
+			for _, template := range templates {
+				if selector.Matches(labels.Set(template.Labels)) {
+					list.Items = append(list.Items, template)
+				}
+			}
+			return list, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}
+
+	cachedNames := func() []string {
+		var names []string
+		for _, item := range selective.filtered[templatev1.GroupVersion.WithKind("Template")].informer.GetStore().List() {
+			names = append(names, item.(client.Object).GetName())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	BeforeEach(func() {
+		templates = []templatev1.Template{
+			newTemplate("kubevirt", "base-template", map[string]string{typeLabel: "base"}),
+			newTemplate("other", "other-base-template", map[string]string{typeLabel: "base"}),
+			newTemplate("kubevirt", "user-template", map[string]string{"app": "user"}),
+		}
+		listSelectors = nil
+
+		s := runtime.NewScheme()
+		Expect(templatev1.Install(s)).To(Succeed())
+
+		// Objects read from the API server are annotated, so they can be distinguished from cached ones
+		var readerObjects []runtime.Object
+		for i := range templates {
+			template := templates[i].DeepCopy()
+			template.Annotations = map[string]string{readerAnnotation: "true"}
+			readerObjects = append(readerObjects, template)
+		}
+
+		selective = newSelectiveCache(&startedCache{}, fake.NewFakeClientWithScheme(s, readerObjects...), s)
+		selective.addFiltered(templatev1.GroupVersion.WithKind("Template"), CacheSelector{
+			Object:   &templatev1.Template{},
+			Selector: labels.SelectorFromSet(labels.Set{typeLabel: "base"}),
+		}, listWatch, defaultCacheResync)
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			defer GinkgoRecover()
+			Expect(selective.Start(ctx)).To(Succeed())
+		}()
+		Expect(selective.WaitForCacheSync(ctx)).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should cache only objects matching the selector", func() {
+		Expect(listSelectors).ToNot(BeEmpty())
+		for _, selector := range listSelectors {
+			Expect(selector).To(Equal(typeLabel + "=base"))
+		}
+		Expect(cachedNames()).To(Equal([]string{"base-template", "other-base-template"}))
+	})
+
+	It("should get cached object from the cache", func() {
+		template := &templatev1.Template{}
+		Expect(selective.Get(context.Background(), client.ObjectKey{Namespace: "kubevirt", Name: "base-template"}, template)).To(Succeed())
+		Expect(template.Labels).To(HaveKeyWithValue(typeLabel, "base"))
+		Expect(template.Annotations).ToNot(HaveKey(readerAnnotation))
+	})
+
+	It("should read object outside of the selector from the API server", func() {
+		template := &templatev1.Template{}
+		Expect(selective.Get(context.Background(), client.ObjectKey{Namespace: "kubevirt", Name: "user-template"}, template)).To(Succeed())
+		Expect(template.Annotations).To(HaveKey(readerAnnotation))
+		Expect(cachedNames()).ToNot(ContainElement("user-template"))
+	})
+
+	It("should list objects matching the selector from the cache", func() {
+		list := &templatev1.TemplateList{}
+		Expect(selective.List(context.Background(), list,
+			client.InNamespace("kubevirt"),
+			client.MatchingLabels{typeLabel: "base"},
+		)).To(Succeed())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].Name).To(Equal("base-template"))
+		Expect(list.Items[0].Annotations).ToNot(HaveKey(readerAnnotation))
+	})
+
+	It("should list from the API server if the selector does not cover the request", func() {
+		list := &templatev1.TemplateList{}
+		Expect(selective.List(context.Background(), list, client.InNamespace("kubevirt"))).To(Succeed())
+		Expect(list.Items).To(HaveLen(2))
+		for _, template := range list.Items {
+			Expect(template.Annotations).To(HaveKey(readerAnnotation))
+		}
+	})
+
+	It("should list metadata from the API server", func() {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(templatev1.GroupVersion.WithKind("TemplateList"))
+		Expect(selective.List(context.Background(), list, client.MatchingLabels{typeLabel: "base"})).To(Succeed())
+		Expect(list.Items).To(HaveLen(2))
+		for _, template := range list.Items {
+			Expect(template.Annotations).To(HaveKey(readerAnnotation))
+		}
+	})
+
+	It("should return the filtered informer", func() {
+		informer, err := selective.GetInformer(context.Background(), &templatev1.Template{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(informer).To(BeIdenticalTo(selective.filtered[templatev1.GroupVersion.WithKind("Template")].informer))
+	})
+})
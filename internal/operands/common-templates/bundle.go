@@ -0,0 +1,255 @@
+package common_templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+// templatesLoader loads templates from the bundle file,
+// and reloads them when the file changes.
+type templatesLoader struct {
+	// filename is the bundle file, if it is empty, the default bundle file is used
+	filename string
+
+	lock      sync.Mutex
+	modTime   time.Time
+	size      int64
+	checksum  [sha256.Size]byte
+	verified  bool
+	templates []templatev1.Template
+	// permissive is true, if the templates were loaded in permissive mode
+	permissive bool
+	// partialErr lists the documents that failed to load in permissive mode
+	partialErr error
+	// loadTime is the time when templates were last parsed from the file
+	loadTime time.Time
+}
+
+// bundleStatus compares the loaded templates with the current bundle file
+type bundleStatus struct {
+	Filename       string     `json:"filename"`
+	LoadedChecksum string     `json:"loadedChecksum,omitempty"`
+	FileChecksum   string     `json:"fileChecksum,omitempty"`
+	Matches        bool       `json:"matches"`
+	LastReload     *time.Time `json:"lastReload,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+func newTemplatesLoader(filename string) *templatesLoader {
+	return &templatesLoader{filename: filename}
+}
+
+// file returns the bundle file. The default bundle file is resolved when it is used,
+// so the bundle directory can be changed at startup, after the loader was created.
+func (l *templatesLoader) file() string {
+	if l.filename == "" {
+		return DefaultBundleFile()
+	}
+	return l.filename
+}
+
+// Load returns templates from the bundle file. The file is only
+// read again if its modification time or size has changed.
+// If verifyIntegrity is true, the file is verified against its checksum file.
+// In permissive mode, templates from valid documents are returned
+// with an error wrapping PartialBundleError, if some documents failed.
+func (l *templatesLoader) Load(verifyIntegrity, permissive bool) ([]templatev1.Template, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	filename := l.file()
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if l.templates != nil && info.ModTime().Equal(l.modTime) && info.Size() == l.size &&
+		(l.verified || !verifyIntegrity) && l.permissive == permissive {
+		return l.templates, l.partialErr
+	}
+
+	templates, checksum, err := readTemplatesFile(filename, verifyIntegrity, permissive)
+	if templates == nil {
+		return nil, err
+	}
+
+	if l.templates == nil || checksum != l.checksum {
+		l.loadTime = time.Now()
+	}
+	l.templates = templates
+	l.checksum = checksum
+	l.partialErr = err
+	l.modTime = info.ModTime()
+	l.size = info.Size()
+	l.verified = verifyIntegrity
+	l.permissive = permissive
+	return l.templates, l.partialErr
+}
+
+// Templates returns the last loaded templates, without checking the bundle file.
+func (l *templatesLoader) Templates() []templatev1.Template {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.templates
+}
+
+// Status returns whether the loaded templates match the current content of the bundle file.
+// The file is read, but the templates are not reloaded.
+func (l *templatesLoader) Status() bundleStatus {
+	l.lock.Lock()
+	filename := l.file()
+	status := bundleStatus{Filename: filename}
+	loaded := l.templates != nil
+	loadedChecksum := l.checksum
+	if loaded {
+		status.LoadedChecksum = hex.EncodeToString(loadedChecksum[:])
+		loadTime := l.loadTime
+		status.LastReload = &loadTime
+	}
+	l.lock.Unlock()
+
+	fileChecksum, err := fileChecksum(filename)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.FileChecksum = hex.EncodeToString(fileChecksum[:])
+	status.Matches = loaded && fileChecksum == loadedChecksum
+	return status
+}
+
+// ServeHTTP writes the bundle status as JSON
+func (l *templatesLoader) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(l.Status()); err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// checksumFilename returns the name of the file containing the SHA-256 checksum of the bundle
+func checksumFilename(bundleFilename string) string {
+	return bundleFilename + ".sha256"
+}
+
+// fileChecksum returns the SHA-256 checksum of the file, without reading it whole into memory
+func fileChecksum(filename string) ([sha256.Size]byte, error) {
+	var checksum [sha256.Size]byte
+	file, err := os.Open(filename)
+	if err != nil {
+		return checksum, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return checksum, err
+	}
+	copy(checksum[:], hash.Sum(nil))
+	return checksum, nil
+}
+
+// verifyBundleChecksum compares the checksum of the bundle with its checksum file.
+// The checksum file uses the format of the sha256sum tool.
+func verifyBundleChecksum(filename string, checksum [sha256.Size]byte) error {
+	checksumFile, err := ioutil.ReadFile(checksumFilename(filename))
+	if err != nil {
+		return fmt.Errorf("failed to read checksum of bundle %s: %w", filename, err)
+	}
+	fields := strings.Fields(string(checksumFile))
+	if len(fields) == 0 {
+		return fmt.Errorf("checksum file of bundle %s is empty", filename)
+	}
+
+	actual := hex.EncodeToString(checksum[:])
+	if !strings.EqualFold(fields[0], actual) {
+		return fmt.Errorf("bundle %s is corrupted, expected checksum %s, actual %s", filename, fields[0], actual)
+	}
+	return nil
+}
+
+// ValidateTemplates returns an error listing all malformed templates in the bundle.
+// A template is malformed, if it has no name, is missing any of the required labels,
+// contains objects that cannot be decoded, or defines a parameter multiple times.
+// An empty bundle is not valid.
+func ValidateTemplates(templates []templatev1.Template) error {
+	if len(templates) == 0 {
+		return fmt.Errorf("no templates could be found in the bundle")
+	}
+
+	var problems []string
+	malformed := 0
+	for i := range templates {
+		templateProblems := validateTemplate(&templates[i], i)
+		if len(templateProblems) > 0 {
+			malformed++
+			problems = append(problems, templateProblems...)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d malformed templates: %s", malformed, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validateTemplate returns the problems found in the template at the index in the bundle
+func validateTemplate(template *templatev1.Template, index int) []string {
+	name := template.Name
+	if name == "" {
+		name = fmt.Sprintf("at index %d", index)
+	}
+
+	var problems []string
+	if template.Name == "" {
+		problems = append(problems, fmt.Sprintf("template %s has no name", name))
+	}
+	if missing := missingTemplateLabels(template.Labels); len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("template %s is missing labels: %s", name, strings.Join(missing, ", ")))
+	}
+	for i, object := range template.Objects {
+		if object.Raw == nil {
+			continue
+		}
+		decoded := map[string]interface{}{}
+		if err := json.Unmarshal(object.Raw, &decoded); err != nil {
+			problems = append(problems, fmt.Sprintf("template %s has object %d that cannot be decoded: %v", name, i, err))
+		}
+	}
+	if duplicates := duplicateParameterNames(template); len(duplicates) > 0 {
+		problems = append(problems, fmt.Sprintf("template %s has duplicate parameters: %s", name, strings.Join(duplicates, ", ")))
+	}
+	return problems
+}
+
+func missingTemplateLabels(labels map[string]string) []string {
+	var missing []string
+	for _, label := range []string{TemplateTypeLabel, TemplateVersionLabel} {
+		if labels[label] == "" {
+			missing = append(missing, label)
+		}
+	}
+	for _, prefix := range []string{TemplateOsLabelPrefix, TemplateFlavorLabelPrefix, TemplateWorkloadLabelPrefix} {
+		if !hasLabelWithPrefix(labels, prefix) {
+			missing = append(missing, prefix+"*")
+		}
+	}
+	return missing
+}
+
+func hasLabelWithPrefix(labels map[string]string, prefix string) bool {
+	for key := range labels {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package common_templates
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	templatev1 "github.com/openshift/api/template/v1"
+)
+
+// bundleFileExtensions are extensions of files loaded from additional bundle directories
+var bundleFileExtensions = []string{".yaml", ".yml", ".json"}
+
+// loadedBundle contains templates loaded from a single source
+type loadedBundle struct {
+	source    string
+	templates []templatev1.Template
+}
+
+// additionalBundlesLoader loads templates from additional bundle files.
+// Each file has its own templatesLoader, so it is only parsed again when it changes.
+type additionalBundlesLoader struct {
+	lock    sync.Mutex
+	loaders map[string]*templatesLoader
+}
+
+func newAdditionalBundlesLoader() *additionalBundlesLoader {
+	return &additionalBundlesLoader{loaders: map[string]*templatesLoader{}}
+}
+
+// Load returns templates from the bundle files. Paths can reference files or directories,
+// from directories all files with a bundle extension are loaded.
+func (l *additionalBundlesLoader) Load(paths []string, verifyIntegrity bool) ([]loadedBundle, error) {
+	files, err := bundleFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	loaders := make(map[string]*templatesLoader, len(files))
+	bundles := make([]loadedBundle, 0, len(files))
+	for _, file := range files {
+		loader, ok := l.loaders[file]
+		if !ok {
+			loader = newTemplatesLoader(file)
+		}
+		loaders[file] = loader
+
+		templates, err := loader.Load(verifyIntegrity, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load additional templates bundle: %w", err)
+		}
+		bundles = append(bundles, loadedBundle{source: file, templates: templates})
+	}
+	// Loaders of files that are no longer configured are dropped
+	l.loaders = loaders
+	return bundles, nil
+}
+
+// bundleFiles expands directories in paths to the bundle files they contain
+func bundleFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read additional templates bundle: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read additional templates bundle directory: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && hasBundleExtension(entry.Name()) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func hasBundleExtension(filename string) bool {
+	extension := strings.ToLower(filepath.Ext(filename))
+	for _, bundleExtension := range bundleFileExtensions {
+		if extension == bundleExtension {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeBundles returns templates from all bundles.
+// A template name defined in more than one bundle is an error.
+func mergeBundles(bundles []loadedBundle) ([]templatev1.Template, error) {
+	total := 0
+	for _, bundle := range bundles {
+		total += len(bundle.templates)
+	}
+
+	merged := make([]templatev1.Template, 0, total)
+	sources := make(map[string]string, total)
+	var conflicts []string
+	for _, bundle := range bundles {
+		for _, template := range bundle.templates {
+			if firstSource, exists := sources[template.Name]; exists {
+				conflicts = append(conflicts, fmt.Sprintf("template %s is defined in %s and %s", template.Name, firstSource, bundle.source))
+				continue
+			}
+			sources[template.Name] = bundle.source
+			merged = append(merged, template)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("duplicate templates in bundles: %s", strings.Join(conflicts, "; "))
+	}
+	return merged, nil
+}
@@ -0,0 +1,63 @@
+package common_templates
+
+import (
+	"fmt"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+// defaultBundleConfigMapKey is the ConfigMap key containing the bundle, if not specified in the SSP CR
+const defaultBundleConfigMapKey = "common-templates.yaml"
+
+// loadConfigMapBundle reads templates from the ConfigMap referenced in the SSP CR.
+// If the ConfigMap does not exist or does not contain a valid bundle,
+// a degraded status is returned.
+func loadConfigMapBundle(request *common.Request) ([]templatev1.Template, *common.ResourceStatus, error) {
+	ref := request.Instance.Spec.CommonTemplates.BundleConfigMap
+	configMap := &core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ref.Name,
+			Namespace: ref.Namespace,
+		},
+	}
+
+	err := request.Client.Get(request.Context, client.ObjectKeyFromObject(configMap), configMap)
+	if errors.IsNotFound(err) {
+		return nil, bundleConfigMapDegraded(configMap,
+			fmt.Sprintf("ConfigMap %s/%s with common templates bundle does not exist", ref.Namespace, ref.Name)), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = defaultBundleConfigMapKey
+	}
+	data, ok := configMap.Data[key]
+	if !ok {
+		return nil, bundleConfigMapDegraded(configMap,
+			fmt.Sprintf("ConfigMap %s/%s does not contain key \"%s\"", ref.Namespace, ref.Name, key)), nil
+	}
+
+	templates, err := parseTemplates(strings.NewReader(data), false)
+	if err != nil {
+		return nil, bundleConfigMapDegraded(configMap,
+			fmt.Sprintf("ConfigMap %s/%s contains invalid common templates bundle: %v", ref.Namespace, ref.Name, err)), nil
+	}
+	return templates, nil, nil
+}
+
+func bundleConfigMapDegraded(configMap *core.ConfigMap, msg string) *common.ResourceStatus {
+	return &common.ResourceStatus{
+		Resource: configMap,
+		Degraded: &msg,
+	}
+}
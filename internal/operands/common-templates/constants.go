@@ -11,6 +11,57 @@ const (
 	TemplateWorkloadLabelPrefix  = "workload.template.kubevirt.io/"
 	TemplateDeprecatedAnnotation = "template.kubevirt.io/deprecated"
 
+	// TemplateDisplayNameAnnotation and TemplateDescriptionAnnotation are shown in the OpenShift console
+	TemplateDisplayNameAnnotation = "openshift.io/display-name"
+	TemplateDescriptionAnnotation = "description"
+	// DeprecatedTemplateMarker prefixes the display name and description of deprecated templates
+	DeprecatedTemplateMarker = "(deprecated) "
+
+	TemplateHashAnnotation           = "ssp.kubevirt.io/template-hash"
+	TemplateDeprecatedTimeAnnotation = "ssp.kubevirt.io/deprecated-time"
+
+	// TemplateUnmanagedAnnotation set to "true" on a template prevents the operator
+	// from overwriting its objects and parameters. Labels are still reconciled.
+	TemplateUnmanagedAnnotation = "ssp.kubevirt.io/unmanaged"
+
+	// AdditionalSubjectsAnnotation on the view RoleBinding lists the subjects added from the SSP CR
+	AdditionalSubjectsAnnotation = "ssp.kubevirt.io/additional-subjects"
+
+	// AdditionalGoldenImagesNamespaceLabel marks RBAC objects created in additional golden images namespaces
+	AdditionalGoldenImagesNamespaceLabel = "ssp.kubevirt.io/additional-golden-images-namespace"
+
+	// TemplateRecreatedReason is the reason of the event emitted when a deleted template is created again
+	TemplateRecreatedReason = "TemplateRecreated"
+	// TemplateRestoredReason is the reason of the event emitted when objects or parameters
+	// of a template were modified outside of the operator and were restored
+	TemplateRestoredReason = "TemplateRestored"
+	// DeprecatedTemplateInUseReason is the reason of the event emitted when an expired
+	// deprecated template is not deleted, because VirtualMachines reference it
+	DeprecatedTemplateInUseReason = "DeprecatedTemplateInUse"
+	// ForeignTemplateSkippedReason is the reason of the event emitted when an older base template
+	// is not deprecated, because it was not deployed by the operator
+	ForeignTemplateSkippedReason = "ForeignTemplateSkipped"
+	// TemplateDeprecatedReason is the reason of the event emitted when a template
+	// from an older bundle is marked as deprecated
+	TemplateDeprecatedReason = "TemplateDeprecated"
+
+	// BundleStatusPath is the debug endpoint reporting if the loaded templates match the bundle file
+	BundleStatusPath = "/debug/common-templates-bundle"
+
+	// CPUManagerNodeLabel is set to "true" by KubeVirt on nodes with the CPU manager enabled
+	CPUManagerNodeLabel = "cpumanager"
+
+	// HotplugVolumesFeatureGate is the KubeVirt feature gate enabling hot-plug of disks
+	HotplugVolumesFeatureGate = "HotplugVolumes"
+
+	// VMTemplateNameLabel and VMTemplateNamespaceLabel reference the template a VirtualMachine was created from
+	VMTemplateNameLabel      = "vm.kubevirt.io/template"
+	VMTemplateNamespaceLabel = "vm.kubevirt.io/template.namespace"
+
+	// MinTemplateValidatorVersion is the oldest template validator
+	// that supports all rules used by the bundled templates.
+	MinTemplateValidatorVersion = "v0.10.0"
+
 	CdiApiGroup = "cdi.kubevirt.io"
 	CdiApiVersion = "v1beta1"
 )
@@ -0,0 +1,43 @@
+package common_templates
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultBundleFile returns the path of the templates bundle deployed by the operator
+func DefaultBundleFile() string {
+	return filepath.Join(bundleDir(), "common-templates-"+Version+".yaml")
+}
+
+// DumpTemplates writes templates from the bundle file to the writer as YAML documents,
+// as they would be deployed to the namespace. Templates reference golden images
+// in the goldenImagesNamespace, or in the default one if it is empty.
+// An empty or malformed bundle is reported as an error.
+func DumpTemplates(w io.Writer, filename, namespace, goldenImagesNamespace string) error {
+	templates, err := ReadTemplates(filename, false)
+	if err != nil {
+		return err
+	}
+	if goldenImagesNamespace == "" {
+		goldenImagesNamespace = GoldenImagesNSname
+	}
+
+	for i := range templates {
+		template := &templates[i]
+		template.Namespace = namespace
+		setSourcePVCNamespace(template, goldenImagesNamespace)
+
+		data, err := yaml.Marshal(template)
+		if err != nil {
+			return fmt.Errorf("failed to encode template %s: %w", template.Name, err)
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package common_templates
+
+import (
+	templatev1 "github.com/openshift/api/template/v1"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
+)
+
+// filterTemplates splits templates to the ones matching the filters and the rest.
+// If filters are nil, all templates match.
+func filterTemplates(filters *ssp.TemplateFilters, templates []templatev1.Template) (matching, excluded []templatev1.Template) {
+	if filters == nil {
+		return templates, nil
+	}
+
+	for i := range templates {
+		if templateMatches(filters, &templates[i]) {
+			matching = append(matching, templates[i])
+		} else {
+			excluded = append(excluded, templates[i])
+		}
+	}
+	return matching, excluded
+}
+
+func templateMatches(filters *ssp.TemplateFilters, template *templatev1.Template) bool {
+	return hasAnyLabel(template.Labels, TemplateOsLabelPrefix, filters.OperatingSystems) &&
+		hasAnyLabel(template.Labels, TemplateWorkloadLabelPrefix, filters.Workloads) &&
+		hasAnyLabel(template.Labels, TemplateFlavorLabelPrefix, filters.Flavors)
+}
+
+// hasAnyLabel returns true if one of the prefixed labels is set to "true",
+// or if the list of names is empty.
+func hasAnyLabel(labels map[string]string, prefix string, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if labels[prefix+name] == "true" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,601 @@
+package common_templates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	libhandler "github.com/operator-framework/operator-lib/handler"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+const (
+	// sourcePVCNamespaceParameter is the template parameter that refers to the golden images namespace
+	sourcePVCNamespaceParameter = "SRC_PVC_NAMESPACE"
+
+	// namespaceTerminationRequeueDelay is the delay of the next reconciliation,
+	// while the golden images namespace is being deleted
+	namespaceTerminationRequeueDelay = 10 * time.Second
+)
+
+// goldenImagesNamespace returns the configured golden images namespace, or the default one
+func goldenImagesNamespace(request *common.Request) string {
+	namespace := request.Instance.Spec.CommonTemplates.GoldenImagesNamespace
+	if namespace == "" {
+		return GoldenImagesNSname
+	}
+	return namespace
+}
+
+// manageGoldenImagesNamespace returns true, if the operator creates and updates the golden images namespace
+func manageGoldenImagesNamespace(request *common.Request) bool {
+	manage := request.Instance.Spec.CommonTemplates.ManageGoldenImagesNamespace
+	return manage == nil || *manage
+}
+
+// copyStringMap returns a copy of the map, so the original is not modified
+// when labels or annotations are added to the object.
+func copyStringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+	}
+	return result
+}
+
+// checkGoldenImagesNSTerminating returns a status requesting reconciliation later, if the golden
+// images namespace is being deleted. No resources can be created in the namespace until it is removed.
+func checkGoldenImagesNSTerminating(request *common.Request) (*common.ResourceStatus, error) {
+	namespace := newGoldenImagesNS(goldenImagesNamespace(request))
+	found := &core.Namespace{}
+	err := request.Client.Get(request.Context, client.ObjectKeyFromObject(namespace), found)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.DeletionTimestamp == nil && found.Status.Phase != core.NamespaceTerminating {
+		return nil, nil
+	}
+
+	msg := fmt.Sprintf("Waiting for termination of golden images namespace \"%s\", it will be created again when it is deleted", namespace.Name)
+	if !manageGoldenImagesNamespace(request) {
+		msg = fmt.Sprintf("Waiting for termination of golden images namespace \"%s\"", namespace.Name)
+	}
+	return &common.ResourceStatus{
+		Resource:     namespace,
+		Progressing:  &msg,
+		Degraded:     &msg,
+		RequeueAfter: namespaceTerminationRequeueDelay,
+	}, nil
+}
+
+// checkGoldenImagesNS returns a degraded status, if the unmanaged golden images namespace does not exist
+func checkGoldenImagesNS(request *common.Request) (common.ResourceStatus, error) {
+	namespace := newGoldenImagesNS(goldenImagesNamespace(request))
+	err := request.Client.Get(request.Context, client.ObjectKeyFromObject(namespace), &core.Namespace{})
+	if err == nil {
+		return common.ResourceStatus{Resource: namespace}, nil
+	}
+	if !errors.IsNotFound(err) {
+		return common.ResourceStatus{}, err
+	}
+
+	msg := fmt.Sprintf("Golden images namespace \"%s\" does not exist", namespace.Name)
+	return common.ResourceStatus{
+		Resource: namespace,
+		Degraded: &msg,
+	}, nil
+}
+
+// setSourcePVCNamespace changes the default value of the source PVC namespace parameter,
+// if the template uses the default golden images namespace.
+func setSourcePVCNamespace(template *templatev1.Template, namespace string) {
+	if namespace == GoldenImagesNSname {
+		return
+	}
+	for i := range template.Parameters {
+		param := &template.Parameters[i]
+		if param.Name == sourcePVCNamespaceParameter && param.Value == GoldenImagesNSname {
+			param.Value = namespace
+		}
+	}
+}
+
+// reconcileOldGoldenImagesNamespaces finds golden images namespaces created by the operator
+// for this SSP, that are different from the current one. If enabled in the SSP CR,
+// they are deleted, unless they contain any PersistentVolumeClaims.
+// The view Role and RoleBinding are removed from old namespaces, even if they are kept.
+func reconcileOldGoldenImagesNamespaces(request *common.Request) ([]common.ResourceStatus, error) {
+	if err := deleteOldGoldenImagesRBAC(request); err != nil {
+		return nil, err
+	}
+	if !request.Instance.Spec.CommonTemplates.DeleteOldGoldenImagesNamespaces {
+		return nil, nil
+	}
+
+	namespaces := &core.NamespaceList{}
+	err := request.Client.List(request.Context, namespaces, client.MatchingLabels{
+		common.AppKubernetesNameLabel:      operandName,
+		common.AppKubernetesManagedByLabel: "ssp-operator",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	owner := request.Instance.Namespace + "/" + request.Instance.Name
+	currentNamespace := goldenImagesNamespace(request)
+	templatesNamespace := request.Instance.Spec.CommonTemplates.Namespace
+
+	var statuses []common.ResourceStatus
+	for i := range namespaces.Items {
+		namespace := &namespaces.Items[i]
+		if namespace.Name == currentNamespace || namespace.Name == templatesNamespace {
+			continue
+		}
+		if namespace.Annotations[libhandler.NamespacedNameAnnotation] != owner {
+			continue
+		}
+
+		pvcs := &core.PersistentVolumeClaimList{}
+		err := request.Client.List(request.Context, pvcs, client.InNamespace(namespace.Name), client.Limit(1))
+		if err != nil {
+			return nil, err
+		}
+		if len(pvcs.Items) > 0 {
+			msg := fmt.Sprintf("Old golden images namespace \"%s\" is not deleted, because it contains PersistentVolumeClaims", namespace.Name)
+			statuses = append(statuses, common.ResourceStatus{
+				Resource: namespace,
+				Degraded: &msg,
+			})
+			continue
+		}
+
+		request.Logger.Info(fmt.Sprintf("Deleting old golden images namespace \"%s\"", namespace.Name))
+		err = request.Client.Delete(request.Context, namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return statuses, nil
+}
+
+// deleteOldGoldenImagesRBAC deletes the view Roles and RoleBindings created by the operator for this SSP
+// in previous golden images namespaces. RBAC in additional golden images namespaces
+// is removed by deleteAdditionalGoldenImagesRBAC.
+func deleteOldGoldenImagesRBAC(request *common.Request) error {
+	selector := client.MatchingLabels{
+		common.AppKubernetesNameLabel:      operandName,
+		common.AppKubernetesManagedByLabel: "ssp-operator",
+	}
+	owner := request.Instance.Namespace + "/" + request.Instance.Name
+	keep := map[string]struct{}{
+		goldenImagesNamespace(request): {},
+	}
+	for _, namespace := range additionalGoldenImagesNamespaces(request) {
+		keep[namespace] = struct{}{}
+	}
+
+	deleteObject := func(kind string, obj client.Object) error {
+		if _, ok := keep[obj.GetNamespace()]; ok {
+			return nil
+		}
+		if obj.GetName() != ViewRoleName || obj.GetLabels()[AdditionalGoldenImagesNamespaceLabel] == "true" ||
+			obj.GetAnnotations()[libhandler.NamespacedNameAnnotation] != owner {
+			return nil
+		}
+		request.Logger.Info(fmt.Sprintf("Deleting %s \"%s\" in old golden images namespace \"%s\"", kind, obj.GetName(), obj.GetNamespace()))
+		err := request.Client.Delete(request.Context, obj)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	roles := &rbac.RoleList{}
+	if err := request.Client.List(request.Context, roles, selector); err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		if err := deleteObject("Role", &roles.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	bindings := &rbac.RoleBindingList{}
+	if err := request.Client.List(request.Context, bindings, selector); err != nil {
+		return err
+	}
+	for i := range bindings.Items {
+		if err := deleteObject("RoleBinding", &bindings.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// additionalGoldenImagesNamespaces returns the configured additional golden images namespaces,
+// without duplicates and without the main golden images namespace.
+func additionalGoldenImagesNamespaces(request *common.Request) []string {
+	seen := map[string]struct{}{
+		goldenImagesNamespace(request): {},
+	}
+	var namespaces []string
+	for _, namespace := range request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces {
+		if _, ok := seen[namespace]; ok {
+			continue
+		}
+		seen[namespace] = struct{}{}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces
+}
+
+// reconcileAdditionalGoldenImagesNamespaces creates the view Role and RoleBinding in each additional
+// golden images namespace, and removes them from namespaces that are no longer in the list.
+// The namespaces are not created by the operator, a missing namespace is reported as degraded.
+func reconcileAdditionalGoldenImagesNamespaces(request *common.Request) ([]common.ResourceStatus, error) {
+	namespaces := additionalGoldenImagesNamespaces(request)
+	keep := make(map[string]struct{}, len(namespaces))
+	for _, namespace := range namespaces {
+		keep[namespace] = struct{}{}
+	}
+	if err := deleteAdditionalGoldenImagesRBAC(request, keep); err != nil {
+		return nil, err
+	}
+
+	var statuses []common.ResourceStatus
+	for _, namespace := range namespaces {
+		ns := newGoldenImagesNS(namespace)
+		err := request.Client.Get(request.Context, client.ObjectKeyFromObject(ns), &core.Namespace{})
+		if errors.IsNotFound(err) {
+			msg := fmt.Sprintf("Additional golden images namespace \"%s\" does not exist", namespace)
+			statuses = append(statuses, common.ResourceStatus{
+				Resource: ns,
+				Degraded: &msg,
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		role := newViewRole(namespace)
+		role.Labels = map[string]string{AdditionalGoldenImagesNamespaceLabel: "true"}
+		roleStatus, err := createOrUpdateViewRole(request, role)
+		if err != nil {
+			return nil, err
+		}
+		binding := newViewRoleBinding(namespace)
+		binding.Labels = map[string]string{AdditionalGoldenImagesNamespaceLabel: "true"}
+		bindingStatus, err := createOrUpdateViewRoleBinding(request, binding)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, roleStatus, bindingStatus)
+	}
+	return statuses, nil
+}
+
+// deleteAdditionalGoldenImagesRBAC deletes the view Roles and RoleBindings created in additional
+// golden images namespaces, except for namespaces in keep.
+func deleteAdditionalGoldenImagesRBAC(request *common.Request, keep map[string]struct{}) error {
+	selector := client.MatchingLabels{
+		AdditionalGoldenImagesNamespaceLabel: "true",
+		common.AppKubernetesNameLabel:        operandName,
+		common.AppKubernetesManagedByLabel:   "ssp-operator",
+	}
+
+	deleteObject := func(kind string, obj client.Object) error {
+		if _, ok := keep[obj.GetNamespace()]; ok {
+			return nil
+		}
+		request.Logger.Info(fmt.Sprintf("Deleting %s \"%s\" in namespace \"%s\"", kind, obj.GetName(), obj.GetNamespace()))
+		err := request.Client.Delete(request.Context, obj)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	roles := &rbac.RoleList{}
+	if err := request.Client.List(request.Context, roles, selector); err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		if err := deleteObject("Role", &roles.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	bindings := &rbac.RoleBindingList{}
+	if err := request.Client.List(request.Context, bindings, selector); err != nil {
+		return err
+	}
+	for i := range bindings.Items {
+		if err := deleteObject("RoleBinding", &bindings.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyRulesEqual returns true, if both slices contain the same rules, regardless of their order
+// and the order of values in the rules. It is used to avoid updates of semantically equal roles.
+func policyRulesEqual(a, b []rbac.PolicyRule) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return equalKeys(policyRuleKeys(a), policyRuleKeys(b))
+}
+
+func policyRuleKeys(rules []rbac.PolicyRule) []string {
+	keys := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		keys = append(keys, strings.Join([]string{
+			sortedJoin(rule.APIGroups),
+			sortedJoin(rule.Resources),
+			sortedJoin(rule.ResourceNames),
+			sortedJoin(rule.NonResourceURLs),
+			sortedJoin(rule.Verbs),
+		}, ";"))
+	}
+	return keys
+}
+
+// subjectsEqual returns true, if both slices contain the same subjects, regardless of their order
+func subjectsEqual(a, b []rbac.Subject) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	subjectKeys := func(subjects []rbac.Subject) []string {
+		keys := make([]string, 0, len(subjects))
+		for _, subject := range subjects {
+			keys = append(keys, subjectKey(subject))
+		}
+		return keys
+	}
+	return equalKeys(subjectKeys(a), subjectKeys(b))
+}
+
+// subjectKey identifies the subject. The API group of users and groups is defaulted by the API server.
+func subjectKey(subject rbac.Subject) string {
+	subject = normalizeSubject(subject)
+	return strings.Join([]string{subject.Kind, subject.APIGroup, subject.Namespace, subject.Name}, ";")
+}
+
+func normalizeSubject(subject rbac.Subject) rbac.Subject {
+	if subject.APIGroup == "" && (subject.Kind == rbac.UserKind || subject.Kind == rbac.GroupKind) {
+		subject.APIGroup = rbac.GroupName
+	}
+	return subject
+}
+
+// mergeSubjects returns the required subjects followed by the existing ones,
+// that are not required and were not removed. Duplicate subjects are dropped.
+func mergeSubjects(required, existing, removed []rbac.Subject) []rbac.Subject {
+	removedKeys := make(map[string]struct{}, len(removed))
+	for _, subject := range removed {
+		removedKeys[subjectKey(subject)] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(required)+len(existing))
+	merged := make([]rbac.Subject, 0, len(required)+len(existing))
+	for _, subject := range required {
+		key := subjectKey(subject)
+		if _, duplicate := seen[key]; duplicate {
+			continue
+		}
+		seen[key] = struct{}{}
+		merged = append(merged, normalizeSubject(subject))
+	}
+	for _, subject := range existing {
+		key := subjectKey(subject)
+		_, duplicate := seen[key]
+		_, isRemoved := removedKeys[key]
+		if duplicate || isRemoved {
+			continue
+		}
+		seen[key] = struct{}{}
+		merged = append(merged, subject)
+	}
+	return merged
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func equalKeys(a, b []string) bool {
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// bindingSubjectsIndex is the field index of ClusterRoleBindings and RoleBindings by their subjects
+const bindingSubjectsIndex = "subjects"
+
+// subjectIndexKeys returns keys of the subjects in the bindingSubjectsIndex
+func subjectIndexKeys(subjects []rbac.Subject) []string {
+	keys := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		keys = append(keys, subjectIndexKey(subject.Kind, subject.Namespace, subject.Name))
+	}
+	return keys
+}
+
+func subjectIndexKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// defaultServiceAccountSubjectKeys returns index keys of all subjects,
+// that include the default ServiceAccount in the namespace
+func defaultServiceAccountSubjectKeys(namespace string) []string {
+	return []string{
+		subjectIndexKey(rbac.ServiceAccountKind, namespace, "default"),
+		subjectIndexKey(rbac.GroupKind, "", "system:serviceaccounts:"+namespace),
+		subjectIndexKey(rbac.GroupKind, "", "system:serviceaccounts"),
+		subjectIndexKey(rbac.GroupKind, "", "system:authenticated"),
+	}
+}
+
+// isDefaultServiceAccountSubject returns true, if the subject includes
+// the default ServiceAccount in the namespace.
+func isDefaultServiceAccountSubject(subject rbac.Subject, namespace string) bool {
+	switch subject.Kind {
+	case rbac.ServiceAccountKind:
+		return subject.Name == "default" && subject.Namespace == namespace
+	case rbac.GroupKind:
+		switch subject.Name {
+		case "system:serviceaccounts:" + namespace, "system:serviceaccounts", "system:authenticated":
+			return true
+		}
+	}
+	return false
+}
+
+// isOverprivilegedRule returns true for rules granting cluster-admin-like access,
+// or allowing privilege escalation. Importing golden images with CDI does not
+// require any of them for the default ServiceAccount.
+func isOverprivilegedRule(rule rbac.PolicyRule) bool {
+	verbs := map[string]struct{}{}
+	for _, verb := range rule.Verbs {
+		verbs[verb] = struct{}{}
+	}
+	for _, verb := range []string{"escalate", "bind", "impersonate"} {
+		if _, ok := verbs[verb]; ok {
+			return true
+		}
+	}
+	if _, ok := verbs[rbac.VerbAll]; !ok {
+		return false
+	}
+	for _, resource := range rule.Resources {
+		if resource == rbac.ResourceAll {
+			return true
+		}
+	}
+	for _, url := range rule.NonResourceURLs {
+		if url == rbac.NonResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedRules returns the rules of the role referenced by a binding,
+// or nil if the role does not exist.
+func referencedRules(request *common.Request, roleRef rbac.RoleRef, namespace string) ([]rbac.PolicyRule, error) {
+	var err error
+	var rules []rbac.PolicyRule
+	switch roleRef.Kind {
+	case "ClusterRole":
+		role := &rbac.ClusterRole{}
+		err = request.Client.Get(request.Context, client.ObjectKey{Name: roleRef.Name}, role)
+		rules = role.Rules
+	case "Role":
+		role := &rbac.Role{}
+		err = request.Client.Get(request.Context, client.ObjectKey{Name: roleRef.Name, Namespace: namespace}, role)
+		rules = role.Rules
+	}
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return rules, err
+}
+
+// checkGoldenImagesServiceAccount returns a degraded status, if the default ServiceAccount
+// in the golden images namespace is bound to an overprivileged role.
+func checkGoldenImagesServiceAccount(request *common.Request) (*common.ResourceStatus, error) {
+	namespace := goldenImagesNamespace(request)
+
+	type binding struct {
+		kind      string
+		name      string
+		namespace string
+		subjects  []rbac.Subject
+		roleRef   rbac.RoleRef
+	}
+	var bindings []binding
+	seen := map[string]struct{}{}
+	addBinding := func(b binding) {
+		key := b.kind + "/" + b.namespace + "/" + b.name
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			bindings = append(bindings, b)
+		}
+	}
+
+	// Only bindings of subjects including the default ServiceAccount are listed, using the index
+	for _, subjectKey := range defaultServiceAccountSubjectKeys(namespace) {
+		clusterRoleBindings := &rbac.ClusterRoleBindingList{}
+		if err := request.Client.List(request.Context, clusterRoleBindings, client.MatchingFields{bindingSubjectsIndex: subjectKey}); err != nil {
+			return nil, err
+		}
+		for _, item := range clusterRoleBindings.Items {
+			addBinding(binding{"ClusterRoleBinding", item.Name, "", item.Subjects, item.RoleRef})
+		}
+
+		roleBindings := &rbac.RoleBindingList{}
+		if err := request.Client.List(request.Context, roleBindings, client.InNamespace(namespace), client.MatchingFields{bindingSubjectsIndex: subjectKey}); err != nil {
+			return nil, err
+		}
+		for _, item := range roleBindings.Items {
+			addBinding(binding{"RoleBinding", item.Name, item.Namespace, item.Subjects, item.RoleRef})
+		}
+	}
+
+	var overprivileged []string
+	for _, b := range bindings {
+		boundToDefault := false
+		for _, subject := range b.subjects {
+			if isDefaultServiceAccountSubject(subject, namespace) {
+				boundToDefault = true
+				break
+			}
+		}
+		if !boundToDefault {
+			continue
+		}
+
+		rules, err := referencedRules(request, b.roleRef, b.namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			if isOverprivilegedRule(rule) {
+				overprivileged = append(overprivileged,
+					fmt.Sprintf("%s \"%s\" (%s \"%s\")", b.kind, b.name, b.roleRef.Kind, b.roleRef.Name))
+				break
+			}
+		}
+	}
+	if len(overprivileged) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(overprivileged)
+	msg := fmt.Sprintf("Default ServiceAccount in golden images namespace \"%s\" is overprivileged by: %s",
+		namespace, strings.Join(overprivileged, ", "))
+	return &common.ResourceStatus{
+		Resource: newGoldenImagesNS(namespace),
+		Degraded: &msg,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package common_templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// templateHash returns a checksum of the template objects and parameters
+func templateHash(template *templatev1.Template) (string, error) {
+	data, err := json.Marshal(struct {
+		Objects    interface{}
+		Parameters interface{}
+	}{
+		Objects:    template.Objects,
+		Parameters: template.Parameters,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// templateContentEqual compares objects and parameters of two templates.
+// Objects are compared after decoding, because the API server
+// can serialize them differently than the bundle.
+func templateContentEqual(a, b *templatev1.Template) bool {
+	if !equality.Semantic.DeepEqual(a.Parameters, b.Parameters) {
+		return false
+	}
+	if len(a.Objects) != len(b.Objects) {
+		return false
+	}
+	for i := range a.Objects {
+		aObj, err := decodeRawObject(a.Objects[i].MarshalJSON())
+		if err != nil {
+			return false
+		}
+		bObj, err := decodeRawObject(b.Objects[i].MarshalJSON())
+		if err != nil {
+			return false
+		}
+		if !equality.Semantic.DeepEqual(aObj, bObj) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeRawObject(data []byte, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
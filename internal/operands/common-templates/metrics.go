@@ -0,0 +1,73 @@
+package common_templates
+
+import (
+	"sort"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	templatesTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ssp_common_templates_total",
+		Help: "Number of common templates reconciled in the last reconciliation",
+	})
+	templatesFailed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ssp_common_templates_failed",
+		Help: "Number of common templates that failed to reconcile in the last reconciliation",
+	})
+	templatesLabelsRepaired = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ssp_common_templates_labels_repaired_total",
+		Help: "Number of times labels removed from common templates were restored",
+	})
+	// templatesRestored is labeled by the template workload and not by the template name,
+	// to keep the number of time series low.
+	templatesRestored = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ssp_common_templates_restored_total",
+		Help: "Number of times objects or parameters of common templates modified outside of the operator were restored, by template workload",
+	}, []string{"workload"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(templatesTotal, templatesFailed, templatesLabelsRepaired, templatesRestored)
+}
+
+// setTemplatesMetrics updates the gauges from the number of reconciled
+// templates and the error returned by their reconciliation.
+func setTemplatesMetrics(total int, err error) {
+	templatesTotal.Set(float64(total))
+	templatesFailed.Set(float64(len(templateErrors(err))))
+}
+
+// templateErrors returns the errors of individual templates from the error of their reconciliation
+func templateErrors(err error) []error {
+	if aggregate, ok := err.(utilerrors.Aggregate); ok {
+		return aggregate.Errors()
+	}
+	if err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// noWorkload is the workload metric label value of templates without a workload label
+const noWorkload = "none"
+
+// templateWorkload returns the first workload of the template in alphabetical order,
+// or noWorkload if the template has no workload label
+func templateWorkload(template *templatev1.Template) string {
+	var workloads []string
+	for key, value := range template.Labels {
+		if strings.HasPrefix(key, TemplateWorkloadLabelPrefix) && value == "true" {
+			workloads = append(workloads, strings.TrimPrefix(key, TemplateWorkloadLabelPrefix))
+		}
+	}
+	if len(workloads) == 0 {
+		return noWorkload
+	}
+	sort.Strings(workloads)
+	return workloads[0]
+}
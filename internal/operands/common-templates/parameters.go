@@ -0,0 +1,132 @@
+package common_templates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+var (
+	// reservedParameterNames are names of template fields. Parameters with these names
+	// are confused with the fields by tools processing templates.
+	reservedParameterNames = map[string]struct{}{
+		"OBJECTS":    {},
+		"PARAMETERS": {},
+		"LABELS":     {},
+		"MESSAGE":    {},
+	}
+
+	// validParameterName matches names accepted by the template API
+	validParameterName = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	// conventionalParameterName matches names following the naming convention of common templates
+	conventionalParameterName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// validateTemplateParameters returns an error, if any parameter name would cause
+// the template processing to fail. Names that are valid, but do not follow
+// the naming convention, are returned as warnings.
+func validateTemplateParameters(template *templatev1.Template) ([]string, error) {
+	var warnings, problems []string
+	seen := make(map[string]struct{}, len(template.Parameters))
+	for _, parameter := range template.Parameters {
+		name := parameter.Name
+		if _, duplicate := seen[name]; duplicate {
+			problems = append(problems, fmt.Sprintf("parameter %q is defined multiple times", name))
+			continue
+		}
+		seen[name] = struct{}{}
+
+		switch {
+		case !validParameterName.MatchString(name):
+			problems = append(problems, fmt.Sprintf("parameter name %q contains invalid characters", name))
+		case isReservedParameterName(name):
+			problems = append(problems, fmt.Sprintf("parameter name %q is reserved", name))
+		case !conventionalParameterName.MatchString(name):
+			warnings = append(warnings, fmt.Sprintf("parameter name %q in template %s should contain only uppercase letters, digits and underscores", name, template.Name))
+		}
+	}
+	if len(problems) > 0 {
+		return warnings, fmt.Errorf("template %s has invalid parameters: %s", template.Name, strings.Join(problems, ", "))
+	}
+	return warnings, nil
+}
+
+// duplicateParameterNames returns names of parameters defined multiple times in the template
+func duplicateParameterNames(template *templatev1.Template) []string {
+	var duplicates []string
+	seen := make(map[string]int, len(template.Parameters))
+	for _, parameter := range template.Parameters {
+		seen[parameter.Name]++
+		if seen[parameter.Name] == 2 {
+			duplicates = append(duplicates, parameter.Name)
+		}
+	}
+	return duplicates
+}
+
+// mergeParameterDefaults returns the bundle parameters, where the value and generator
+// of parameters existing in the cluster template are kept. Parameters missing
+// in the bundle are dropped, all other fields are taken from the bundle.
+func mergeParameterDefaults(bundle, found []templatev1.Parameter) []templatev1.Parameter {
+	if len(bundle) == 0 || len(found) == 0 {
+		return bundle
+	}
+	foundByName := make(map[string]*templatev1.Parameter, len(found))
+	for i := range found {
+		foundByName[found[i].Name] = &found[i]
+	}
+
+	merged := make([]templatev1.Parameter, 0, len(bundle))
+	for _, parameter := range bundle {
+		if foundParameter, ok := foundByName[parameter.Name]; ok {
+			// The generator input belongs to the generator, so it is kept with it
+			parameter.Value = foundParameter.Value
+			parameter.Generate = foundParameter.Generate
+			parameter.From = foundParameter.From
+		}
+		merged = append(merged, parameter)
+	}
+	return merged
+}
+
+func isReservedParameterName(name string) bool {
+	_, reserved := reservedParameterNames[strings.ToUpper(name)]
+	return reserved
+}
+
+// checkParameterLimit returns a degraded status listing the templates with more parameters
+// than the configured maximum. In strict mode, these templates are returned as excluded
+// and only the remaining templates are deployed.
+func checkParameterLimit(request *common.Request, templates []templatev1.Template) (deployed, excluded []templatev1.Template, status *common.ResourceStatus) {
+	maxParameters := request.Instance.Spec.CommonTemplates.MaxTemplateParameters
+	if maxParameters == nil {
+		return templates, nil, nil
+	}
+
+	var offenders []string
+	for i := range templates {
+		count := len(templates[i].Parameters)
+		if count <= int(*maxParameters) {
+			deployed = append(deployed, templates[i])
+			continue
+		}
+		offenders = append(offenders, fmt.Sprintf("%s (%d)", templates[i].Name, count))
+		excluded = append(excluded, templates[i])
+	}
+	if len(offenders) == 0 {
+		return templates, nil, nil
+	}
+
+	msg := fmt.Sprintf("Templates have more than %d parameters: %s", *maxParameters, strings.Join(offenders, ", "))
+	if !request.Instance.Spec.CommonTemplates.StrictMaxTemplateParameters {
+		request.Logger.Info(msg)
+		return templates, nil, &common.ResourceStatus{Resource: request.Instance, Degraded: &msg}
+	}
+	msg += ", they are not deployed"
+	request.Logger.Info(msg)
+	return deployed, excluded, &common.ResourceStatus{Resource: request.Instance, Degraded: &msg}
+}
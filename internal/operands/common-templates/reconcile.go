@@ -1,55 +1,167 @@
 package common_templates
 
 import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
 	"fmt"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"path/filepath"
-	"sync"
 
 	templatev1 "github.com/openshift/api/template/v1"
+	libhandler "github.com/operator-framework/operator-lib/handler"
+	coordination "k8s.io/api/coordination/v1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/clock"
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 	"kubevirt.io/ssp-operator/internal/common"
 	"kubevirt.io/ssp-operator/internal/operands"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-var (
-	loadTemplatesOnce sync.Once
-	templatesBundle   []templatev1.Template
-)
-
 // Define RBAC rules needed by this operand:
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=template.openshift.io,resources=templates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=template.openshift.io,resources=templates,verbs=get;list;watch;create;update;patch;delete;deletecollection
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=kubevirt.io,resources=virtualmachines,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=list
+// +kubebuilder:rbac:groups=kubevirt.io,resources=kubevirts,verbs=list
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=migrations.kubevirt.io,resources=migrationpolicies,verbs=get
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get
 
 // RBAC for created roles
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cdi.kubevirt.io,resources=datavolumes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cdi.kubevirt.io,resources=datavolumes/source,verbs=create
+// +kubebuilder:rbac:groups=cdi.kubevirt.io,resources=datasources;dataimportcrons,verbs=get;list;watch;create;update;patch;delete
 
-type commonTemplates struct{}
+type commonTemplates struct {
+	// parallelism is the maximum number of templates reconciled concurrently
+	parallelism int
+	// serverSideApply enables server-side apply of templates
+	serverSideApply bool
+	// clock is used to compute the age of deprecated templates
+	clock clock.Clock
+	// templateHashes contains the hash of the last reconciled content of each template
+	templateHashes sync.Map
+	// bundleLoader loads templates from the bundle file. The loaded templates are shared
+	// between reconciliations, so they have to be copied before modification.
+	bundleLoader *templatesLoader
+	// additionalBundles loads templates from the additional bundle files
+	additionalBundles *additionalBundlesLoader
+}
 
 var _ operands.Operand = &commonTemplates{}
+var _ operands.DebugHandlersProvider = &commonTemplates{}
 
 func GetOperand() operands.Operand {
-	return &commonTemplates{}
+	return &commonTemplates{
+		parallelism:     common.EnvOrDefaultInt(common.TemplatesReconcileParallelismKey, defaultParallelism),
+		serverSideApply: common.EnvOrDefaultBool(common.TemplatesServerSideApplyKey, false),
+		clock:           clock.RealClock{},
+		bundleLoader:    newTemplatesLoader(""),
+
+		additionalBundles: newAdditionalBundlesLoader(),
+	}
+}
+
+// bundleDirOverride is the templates bundle directory set by SetBundleDir
+var bundleDirOverride string
+
+// SetBundleDir overrides the directory containing the templates bundles.
+// It takes precedence over the environment variable. It has to be called
+// at startup, before templates are reconciled.
+func SetBundleDir(dir string) {
+	bundleDirOverride = dir
+}
+
+// bundleDir returns the directory containing the templates bundles.
+// It can be overridden by SetBundleDir or by an environment variable.
+func bundleDir() string {
+	if bundleDirOverride != "" {
+		return bundleDirOverride
+	}
+	return common.EnvOrDefault(common.TemplatesBundleDirKey, BundleDir)
+}
+
+// CheckBundleDir returns an error, if the templates bundle directory does not exist
+func CheckBundleDir() error {
+	dir := bundleDir()
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("common templates bundle directory %s cannot be read, "+
+			"it can be set by the --templates-bundle-dir flag or the %s environment variable: %w",
+			dir, common.TemplatesBundleDirKey, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("common templates bundle directory %s is not a directory", dir)
+	}
+	return nil
 }
 
 func (c *commonTemplates) Name() string {
 	return operandName
 }
 
+// CacheSelectors limits the cached templates to base templates, so templates
+// created by users are not kept in memory.
+// The cache is not restricted to the templates namespace. The namespace is configured
+// in the SSP, which is read only after the cache is started, and it can be changed later.
+// Templates in the previous namespace still have to be found to remove them.
+func (c *commonTemplates) CacheSelectors() []common.CacheSelector {
+	return []common.CacheSelector{{
+		Object:   &templatev1.Template{},
+		Selector: labels.SelectorFromSet(labels.Set{TemplateTypeLabel: "base"}),
+	}}
+}
+
+// IndexFields indexes bindings by their subjects, so bindings of the default
+// ServiceAccount in the golden images namespace are found without listing all of them.
+func (c *commonTemplates) IndexFields(ctx context.Context, indexer client.FieldIndexer) error {
+	err := indexer.IndexField(ctx, &rbac.ClusterRoleBinding{}, bindingSubjectsIndex, func(obj client.Object) []string {
+		return subjectIndexKeys(obj.(*rbac.ClusterRoleBinding).Subjects)
+	})
+	if err != nil {
+		return err
+	}
+	return indexer.IndexField(ctx, &rbac.RoleBinding{}, bindingSubjectsIndex, func(obj client.Object) []string {
+		return subjectIndexKeys(obj.(*rbac.RoleBinding).Subjects)
+	})
+}
+
+func (c *commonTemplates) DebugHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		BundleStatusPath: c.bundleLoader,
+	}
+}
+
 const (
 	operandName      = "common-templates"
 	operandComponent = common.AppComponentTemplating
+
+	defaultParallelism = 10
+
+	templatesListPageSize = 500
 )
 
 func (c *commonTemplates) AddWatchTypesToScheme(s *runtime.Scheme) error {
@@ -70,36 +182,296 @@ func (c *commonTemplates) WatchTypes() []client.Object {
 	return nil
 }
 
+// Reconcile reconciles golden images and templates. A failure of one of them does not prevent
+// the other one from being reconciled, and statuses are returned together with the errors,
+// so conditions of the successfully reconciled resources are still reported.
 func (c *commonTemplates) Reconcile(request *common.Request) ([]common.ResourceStatus, error) {
-	funcs := []common.ReconcileFunc{
-		reconcileGoldenImagesNS,
-		reconcileViewRole,
-		reconcileViewRoleBinding,
-		reconcileEditRole,
+	if templatesEnabled(request) {
+		if status := checkNamespaceOverlap(request); status != nil {
+			return []common.ResourceStatus{*status}, nil
+		}
 	}
 
-	oldTemplateFuncs, err := reconcileOlderTemplates(request)
+	// The golden images namespace and RBAC are reconciled first,
+	// so they exist before any template is created.
+	statuses, goldenImagesErr := reconcileGoldenImagesIfEnabled(request)
+	templateStatuses, templatesErr := c.reconcileTemplatesIfEnabled(request)
+	return append(statuses, templateStatuses...), common.AggregateErrors(goldenImagesErr, templatesErr)
+}
+
+// reconcileGoldenImagesIfEnabled reconciles golden images, or deletes their RBAC if they are disabled
+func reconcileGoldenImagesIfEnabled(request *common.Request) ([]common.ResourceStatus, error) {
+	if !goldenImagesEnabled(request) {
+		return nil, deleteGoldenImagesRBAC(request)
+	}
+	return reconcileGoldenImages(request)
+}
+
+// reconcileTemplatesIfEnabled reconciles templates from the bundle, or deletes them if they are disabled
+func (c *commonTemplates) reconcileTemplatesIfEnabled(request *common.Request) ([]common.ResourceStatus, error) {
+	if !templatesEnabled(request) {
+		if err := deleteTemplates(request); err != nil {
+			return nil, err
+		}
+		// The empty summary reports that no templates are deployed
+		return []common.ResourceStatus{{
+			Resource:        request.Instance,
+			CommonTemplates: &ssp.CommonTemplatesStatus{},
+		}}, nil
+	}
+
+	if err := checkTemplatesNamespace(request); err != nil {
+		return nil, err
+	}
+
+	templatesBundle, bundleStatus, err := c.loadBundle(request)
 	if err != nil {
 		return nil, err
 	}
+	var statuses []common.ResourceStatus
+	if bundleStatus != nil {
+		statuses = append(statuses, *bundleStatus)
+		if templatesBundle == nil {
+			return statuses, nil
+		}
+	}
+
+	deployedTemplates, excludedTemplates := filterTemplates(request.Instance.Spec.CommonTemplates.Filters, templatesBundle)
+	deployedTemplates, overLimitTemplates, parameterLimitStatus := checkParameterLimit(request, deployedTemplates)
+	excludedTemplates = append(excludedTemplates, overLimitTemplates...)
+	if parameterLimitStatus != nil {
+		statuses = append(statuses, *parameterLimitStatus)
+	}
 
-	funcs = append(funcs, oldTemplateFuncs...)
-	funcs = append(funcs, reconcileTemplatesFuncs(request)...)
+	defaults, checkStatuses, err := checkTemplateDefaults(request, templatesBundle, deployedTemplates)
+	statuses = append(statuses, checkStatuses...)
+	if err != nil {
+		return statuses, err
+	}
 
-	return common.CollectResourceStatus(request, funcs...)
+	deployStatuses, err := c.deployTemplates(request, templatesBundle, deployedTemplates, excludedTemplates, defaults)
+	return append(statuses, deployStatuses...), err
+}
+
+// checkTemplateDefaults returns the defaults added to template VMs, and statuses
+// of the checks of cluster features they depend on. Defaults that cannot work are not set.
+func checkTemplateDefaults(request *common.Request, templatesBundle, deployedTemplates []templatev1.Template) (*vmDefaults, []common.ResourceStatus, error) {
+	var statuses []common.ResourceStatus
+	addStatus := func(status *common.ResourceStatus) {
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+	}
+
+	defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+	snapshotClassStatus, err := checkSnapshotClass(request)
+	if err != nil {
+		return nil, statuses, err
+	}
+	if snapshotClassStatus != nil {
+		defaults.snapshotClass = ""
+	}
+	addStatus(snapshotClassStatus)
+
+	policyLabels, migrationPolicyStatus, err := migrationPolicyLabels(request)
+	if err != nil {
+		return nil, statuses, err
+	}
+	defaults.migrationPolicyLabels = policyLabels
+	addStatus(migrationPolicyStatus)
+
+	accessCredentialsStatus, err := checkAccessCredentialsSecret(request)
+	if err != nil {
+		return nil, statuses, err
+	}
+	if accessCredentialsStatus != nil {
+		defaults.accessCredentials = nil
+	}
+	addStatus(accessCredentialsStatus)
+
+	// The remaining checks only report problems, the defaults are set anyway
+	for _, check := range []func(*common.Request) (*common.ResourceStatus, error){
+		checkCPUManager,
+		checkWorkloadSchedulers,
+		checkHotplugFeatureGate,
+		checkTPMStorage,
+	} {
+		status, err := check(request)
+		if err != nil {
+			return nil, statuses, err
+		}
+		addStatus(status)
+	}
+	addStatus(checkOSFamilyCoverage(request, templatesBundle))
+	removedFieldsStatus, err := checkRemovedVMFields(request, deployedTemplates)
+	if err != nil {
+		return nil, statuses, err
+	}
+	addStatus(removedFieldsStatus)
+	return defaults, statuses, nil
+}
+
+// deployTemplates reconciles the deployed templates and templates of older bundle versions,
+// and removes templates that should no longer exist. The returned statuses end with the templates summary.
+func (c *commonTemplates) deployTemplates(request *common.Request, templatesBundle, deployedTemplates, excludedTemplates []templatev1.Template, defaults *vmDefaults) ([]common.ResourceStatus, error) {
+	summary := &templatesSummary{}
+	oldTemplateFuncs, err := reconcileOlderTemplates(request, summary, c.clock.Now(), templateNames(templatesBundle),
+		olderBundleVersions(c.bundleLoader.file()))
+	if err != nil {
+		return nil, err
+	}
+
+	summary.deprecated = len(oldTemplateFuncs)
+	templateFuncs := append(oldTemplateFuncs, summary.countDeployed(c.reconcileTemplatesFuncs(request, summary, deployedTemplates, defaults))...)
+	templateStatuses, err := common.CollectResourceStatusParallel(request, c.parallelism, templateFuncs...)
+	setTemplatesMetrics(len(templateFuncs), err)
+	if err != nil {
+		// The summary is returned with the error, so failed templates are visible in the SSP status.
+		// The previous namespace is kept in the summary, so the migration is retried.
+		namespace := previousTemplatesNamespace(request)
+		if namespace == "" {
+			namespace = request.Instance.Spec.CommonTemplates.Namespace
+		}
+		return []common.ResourceStatus{summary.resourceStatus(request, namespace, templateStatuses, err)}, err
+	}
+
+	// Templates are removed from the previous namespace only after they were deployed to the new one.
+	// If this fails, the summary is not updated, so the previous namespace is kept in the SSP status.
+	if previousNamespace := previousTemplatesNamespace(request); previousNamespace != "" {
+		request.Logger.Info(fmt.Sprintf("Removing common templates from previous namespace \"%s\"", previousNamespace))
+		if err := deleteTemplatesFromNamespace(request, previousNamespace); err != nil {
+			return nil, err
+		}
+	}
+	summaryStatus := summary.resourceStatus(request, request.Instance.Spec.CommonTemplates.Namespace, templateStatuses, nil)
+
+	if err := deleteExcludedTemplates(request, excludedTemplates); err != nil {
+		return nil, err
+	}
+
+	if request.Instance.Spec.CommonTemplates.PruneRemovedTemplates {
+		if err := pruneRemovedTemplates(request, templatesBundle); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(templateStatuses, summaryStatus), nil
 }
 
 func (c *commonTemplates) Cleanup(request *common.Request) error {
+	if err := deleteTemplates(request); err != nil {
+		return err
+	}
+	if err := deleteGoldenImagesRBAC(request); err != nil {
+		return err
+	}
+	if manageGoldenImagesNamespace(request) {
+		err := request.Client.Delete(request.Context, newGoldenImagesNS(goldenImagesNamespace(request)))
+		if err != nil && !errors.IsNotFound(err) {
+			request.Logger.Error(err, fmt.Sprintf("Error deleting golden images namespace: %s", err))
+			return err
+		}
+	}
+	return nil
+}
+
+// templatesEnabled returns true, if common templates should be deployed
+func templatesEnabled(request *common.Request) bool {
+	enabled := request.Instance.Spec.CommonTemplates.Enabled
+	return enabled == nil || *enabled
+}
+
+// goldenImagesEnabled returns true, if the golden images namespace and RBAC should be reconciled
+func goldenImagesEnabled(request *common.Request) bool {
+	enabled := request.Instance.Spec.CommonTemplates.GoldenImagesEnabled
+	return enabled == nil || *enabled
+}
+
+// reconcileGoldenImages reconciles the golden images namespace and RBAC,
+// including additional and previous golden images namespaces.
+// Statuses of the resources reconciled before a failure are returned with the error.
+func reconcileGoldenImages(request *common.Request) ([]common.ResourceStatus, error) {
+	namespaceStatus, err := reconcileGoldenImagesNS(request)
+	if err != nil {
+		return nil, err
+	}
+	// Roles cannot be created in a terminating namespace, they are created after the namespace is removed
+	rbacFuncs := []common.ReconcileFunc{reconcileEditRole}
+	namespaceTerminating := namespaceStatus.RequeueAfter > 0
+	if !namespaceTerminating {
+		rbacFuncs = append([]common.ReconcileFunc{reconcileViewRole, reconcileViewRoleBinding}, rbacFuncs...)
+	}
+	statuses := []common.ResourceStatus{namespaceStatus}
+	rbacStatuses, err := common.CollectResourceStatus(request, rbacFuncs...)
+	if err != nil {
+		return statuses, err
+	}
+	statuses = append(statuses, rbacStatuses...)
+
+	if aggregateGoldenImagesViewRole(request) {
+		aggregatedRoleStatus, err := reconcileAggregatedViewRole(request)
+		if err != nil {
+			return statuses, err
+		}
+		statuses = append(statuses, aggregatedRoleStatus)
+	} else if err := deleteAggregatedViewRole(request); err != nil {
+		return statuses, err
+	}
+
+	oldNamespaceStatuses, err := reconcileOldGoldenImagesNamespaces(request)
+	if err != nil {
+		return statuses, err
+	}
+	statuses = append(statuses, oldNamespaceStatuses...)
+
+	additionalNamespaceStatuses, err := reconcileAdditionalGoldenImagesNamespaces(request)
+	if err != nil {
+		return statuses, err
+	}
+	statuses = append(statuses, additionalNamespaceStatuses...)
+
+	if namespaceTerminating {
+		return statuses, nil
+	}
+	serviceAccountStatus, err := checkGoldenImagesServiceAccount(request)
+	if err != nil {
+		return statuses, err
+	}
+	if serviceAccountStatus != nil {
+		statuses = append(statuses, *serviceAccountStatus)
+	}
+	return statuses, nil
+}
+
+// deleteTemplates deletes templates deployed by the operator,
+// including templates in the previous templates namespace.
+func deleteTemplates(request *common.Request) error {
+	if err := deleteAllTemplates(request); err != nil {
+		request.Logger.Error(err, fmt.Sprintf("Error deleting templates: %s", err))
+		return err
+	}
+	if previousNamespace := previousTemplatesNamespace(request); previousNamespace != "" {
+		if err := deleteTemplatesFromNamespace(request, previousNamespace); err != nil {
+			request.Logger.Error(err, fmt.Sprintf("Error deleting templates from previous namespace: %s", err))
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteGoldenImagesRBAC deletes the roles and role bindings of golden images namespaces
+func deleteGoldenImagesRBAC(request *common.Request) error {
+	if err := deleteAdditionalGoldenImagesRBAC(request, nil); err != nil {
+		request.Logger.Error(err, fmt.Sprintf("Error deleting RBAC in additional golden images namespaces: %s", err))
+		return err
+	}
+
+	goldenImagesNS := goldenImagesNamespace(request)
 	objects := []client.Object{
-		newGoldenImagesNS(GoldenImagesNSname),
-		newViewRole(GoldenImagesNSname),
-		newViewRoleBinding(GoldenImagesNSname),
+		newViewRole(goldenImagesNS),
+		newViewRoleBinding(goldenImagesNS),
 		newEditRole(),
-	}
-	namespace := request.Instance.Spec.CommonTemplates.Namespace
-	for index := range templatesBundle {
-		templatesBundle[index].ObjectMeta.Namespace = namespace
-		objects = append(objects, &templatesBundle[index])
+		newAggregatedViewRole(),
 	}
 	for _, obj := range objects {
 		err := request.Client.Delete(request.Context, obj)
@@ -111,38 +483,500 @@ func (c *commonTemplates) Cleanup(request *common.Request) error {
 	return nil
 }
 
+// deleteAllTemplates deletes all templates deployed by the operator in the templates namespace,
+// including templates from older bundle versions. Templates without the operator labels are kept.
+func deleteAllTemplates(request *common.Request) error {
+	err := request.Client.DeleteAllOf(request.Context, &templatev1.Template{},
+		client.InNamespace(request.Instance.Spec.CommonTemplates.Namespace),
+		client.MatchingLabels{
+			common.AppKubernetesNameLabel:      operandName,
+			common.AppKubernetesManagedByLabel: "ssp-operator",
+		},
+	)
+	// The Template API may already be removed from the cluster
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil
+	}
+	return err
+}
+
+// previousTemplatesNamespace returns the namespace where templates were deployed before
+// the configured namespace was changed. It returns an empty string, if there was no change.
+func previousTemplatesNamespace(request *common.Request) string {
+	status := request.Instance.Status.CommonTemplates
+	if status == nil || status.Namespace == request.Instance.Spec.CommonTemplates.Namespace {
+		return ""
+	}
+	return status.Namespace
+}
+
+// deleteTemplatesFromNamespace deletes templates deployed by the operator for this SSP from the namespace.
+// Templates created by users, or owned by a different SSP, are kept.
+func deleteTemplatesFromNamespace(request *common.Request, namespace string) error {
+	selector := labels.SelectorFromSet(labels.Set{
+		common.AppKubernetesNameLabel:      operandName,
+		common.AppKubernetesManagedByLabel: "ssp-operator",
+	})
+	templates, err := listTemplatesMetadataInNamespace(request, namespace, selector)
+	// The Template API may already be removed from the cluster
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	owner := request.Instance.Namespace + "/" + request.Instance.Name
+	for i := range templates {
+		if templates[i].Annotations[libhandler.NamespacedNameAnnotation] != owner {
+			continue
+		}
+		template := &templatev1.Template{ObjectMeta: metav1.ObjectMeta{
+			Name:      templates[i].Name,
+			Namespace: templates[i].Namespace,
+		}}
+		err := request.Client.Delete(request.Context, template)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTemplatesNamespace returns an error if the namespace for templates does not exist,
+// so a single error is reported instead of a failure for each template.
+func checkTemplatesNamespace(request *common.Request) error {
+	namespace := request.Instance.Spec.CommonTemplates.Namespace
+	err := request.Client.Get(request.Context, client.ObjectKey{Name: namespace}, &core.Namespace{})
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("namespace \"%s\" for common templates does not exist", namespace)
+	}
+	return err
+}
+
+// checkSnapshotClass returns a degraded status, if the configured
+// default snapshot class does not exist in the cluster.
+func checkSnapshotClass(request *common.Request) (*common.ResourceStatus, error) {
+	snapshotClass := request.Instance.Spec.CommonTemplates.DefaultSnapshotClass
+	if snapshotClass == "" {
+		return nil, nil
+	}
+
+	err := request.Client.Get(request.Context, client.ObjectKey{Name: snapshotClass}, newVolumeSnapshotClass())
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf("VolumeSnapshotClass \"%s\" does not exist, it will not be set in templates", snapshotClass)
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// migrationPolicyLabels returns the VirtualMachine instance labels selected by the default migration policy.
+// If the policy does not exist, a degraded status is returned and no labels are set in templates.
+func migrationPolicyLabels(request *common.Request) (map[string]string, *common.ResourceStatus, error) {
+	policyName := request.Instance.Spec.CommonTemplates.DefaultMigrationPolicy
+	if policyName == "" {
+		return nil, nil, nil
+	}
+
+	policy := newMigrationPolicy()
+	err := request.Client.Get(request.Context, client.ObjectKey{Name: policyName}, policy)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		msg := fmt.Sprintf("MigrationPolicy \"%s\" does not exist, it will not be set in templates", policyName)
+		return nil, &common.ResourceStatus{
+			Resource: request.Instance,
+			Degraded: &msg,
+		}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	labels, _, err := unstructured.NestedStringMap(policy.Object, "spec", "selectors", "virtualMachineInstanceSelector")
+	if err != nil {
+		return nil, nil, err
+	}
+	return labels, nil, nil
+}
+
+// checkAccessCredentialsSecret returns a degraded status, if the secret
+// of the default access credentials does not exist in the golden images namespace.
+func checkAccessCredentialsSecret(request *common.Request) (*common.ResourceStatus, error) {
+	accessCredentials := request.Instance.Spec.CommonTemplates.DefaultAccessCredentials
+	if accessCredentials == nil {
+		return nil, nil
+	}
+
+	// Only the existence is checked, so the secret metadata is read directly
+	// from the API server and secrets are not cached by the operator.
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(core.SchemeGroupVersion.WithKind("Secret"))
+	key := client.ObjectKey{Name: accessCredentials.SecretName, Namespace: goldenImagesNamespace(request)}
+	err := request.UncachedReader().Get(request.Context, key, secret)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	msg := fmt.Sprintf("Secret \"%s\" does not exist in namespace \"%s\", access credentials will not be set in templates",
+		key.Name, key.Namespace)
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// checkCPUManager returns a degraded status, if dedicated CPU placement is enabled
+// for some workloads, but no node has the CPU manager enabled.
+// Dedicated CPU placement is still set in templates, because the CPU manager can be enabled later.
+func checkCPUManager(request *common.Request) (*common.ResourceStatus, error) {
+	if len(request.Instance.Spec.CommonTemplates.DedicatedCPUForWorkloads) == 0 {
+		return nil, nil
+	}
+
+	// Nodes are read directly from the API server, so the operator does not cache all nodes
+	nodes := &metav1.PartialObjectMetadataList{}
+	nodes.SetGroupVersionKind(core.SchemeGroupVersion.WithKind("NodeList"))
+	err := request.UncachedReader().List(request.Context, nodes,
+		client.MatchingLabels{CPUManagerNodeLabel: "true"},
+		client.Limit(1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes.Items) > 0 {
+		return nil, nil
+	}
+
+	msg := "No node has the CPU manager enabled, VirtualMachines with dedicated CPU placement cannot be scheduled"
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// schedulerLeaseNamespace is the namespace, where schedulers are expected to hold their leader election Lease
+const schedulerLeaseNamespace = "kube-system"
+
+// checkWorkloadSchedulers returns a degraded status, if a scheduler configured for some workloads
+// does not seem to run. Schedulers are not API objects, so a scheduler is expected to hold
+// a leader election Lease with its name in the kube-system namespace, which was renewed recently.
+// The scheduler is still set in templates, because it can be deployed later.
+func checkWorkloadSchedulers(request *common.Request) (*common.ResourceStatus, error) {
+	schedulers := map[string]struct{}{}
+	for _, scheduler := range request.Instance.Spec.CommonTemplates.SchedulerByWorkload {
+		if scheduler != "" && scheduler != core.DefaultSchedulerName {
+			schedulers[scheduler] = struct{}{}
+		}
+	}
+	if len(schedulers) == 0 {
+		return nil, nil
+	}
+
+	var missing []string
+	for scheduler := range schedulers {
+		running, err := schedulerRunning(request, scheduler, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if !running {
+			missing = append(missing, scheduler)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(missing)
+	msg := fmt.Sprintf("Schedulers %s are not running, VirtualMachines using them cannot be scheduled", strings.Join(missing, ", "))
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// schedulerRunning returns true, if the leader election Lease of the scheduler has a holder,
+// and it was renewed within its lease duration. The Lease is read directly from the API server,
+// so leases are not cached by the operator.
+func schedulerRunning(request *common.Request, scheduler string, now time.Time) (bool, error) {
+	lease := &coordination.Lease{}
+	key := client.ObjectKey{Name: scheduler, Namespace: schedulerLeaseNamespace}
+	err := request.UncachedReader().Get(request.Context, key, lease)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" || spec.RenewTime == nil {
+		return false, nil
+	}
+	if spec.LeaseDurationSeconds == nil {
+		return true, nil
+	}
+	expiry := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	return now.Before(expiry), nil
+}
+
+// checkHotplugFeatureGate returns a degraded status, if hot-plug is enabled
+// for some workloads, but KubeVirt does not have the HotplugVolumes feature gate enabled.
+// Hot-plug is still enabled in templates, because the feature gate can be enabled later.
+func checkHotplugFeatureGate(request *common.Request) (*common.ResourceStatus, error) {
+	if len(request.Instance.Spec.CommonTemplates.EnableHotplugForWorkloads) == 0 {
+		return nil, nil
+	}
+
+	kubeVirts, err := listKubeVirts(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, kubeVirt := range kubeVirts {
+		featureGates, _, err := unstructured.NestedStringSlice(kubeVirt.Object,
+			"spec", "configuration", "developerConfiguration", "featureGates")
+		if err != nil {
+			return nil, err
+		}
+		for _, featureGate := range featureGates {
+			if featureGate == HotplugVolumesFeatureGate {
+				return nil, nil
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("KubeVirt does not have the %s feature gate enabled, disks cannot be hot-plugged to VirtualMachines", HotplugVolumesFeatureGate)
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// checkTPMStorage returns a degraded status, if TPM is enabled for some operating systems,
+// but KubeVirt does not have a storage class configured for the persistent VM state.
+// TPM is still enabled in templates, because the storage class can be configured later.
+func checkTPMStorage(request *common.Request) (*common.ResourceStatus, error) {
+	tpmEnabled := false
+	for _, enabled := range request.Instance.Spec.CommonTemplates.TPMByOS {
+		tpmEnabled = tpmEnabled || enabled
+	}
+	if !tpmEnabled {
+		return nil, nil
+	}
+
+	kubeVirts, err := listKubeVirts(request)
+	if err != nil {
+		return nil, err
+	}
+	for _, kubeVirt := range kubeVirts {
+		storageClass, _, err := unstructured.NestedString(kubeVirt.Object, "spec", "configuration", "vmStateStorageClass")
+		if err != nil {
+			return nil, err
+		}
+		if storageClass != "" {
+			return nil, nil
+		}
+	}
+
+	msg := "KubeVirt does not have the VM state storage class configured, VirtualMachines with persistent TPM cannot be started"
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// listKubeVirts returns KubeVirt objects in the cluster. If KubeVirt is not installed, the list is empty.
+func listKubeVirts(request *common.Request) ([]unstructured.Unstructured, error) {
+	kubeVirts := newKubeVirtList()
+	err := request.Client.List(request.Context, kubeVirts)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	return kubeVirts.Items, nil
+}
+
+// checkOSFamilyCoverage returns a degraded status, if some of the expected OS families
+// are not covered by any template in the bundle, that is not deprecated.
+// A template covers a family, if the name of one of its operating systems starts with the family.
+func checkOSFamilyCoverage(request *common.Request, templates []templatev1.Template) *common.ResourceStatus {
+	expected := request.Instance.Spec.CommonTemplates.ExpectedOSFamilies
+	if len(expected) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, family := range expected {
+		if !osFamilyCovered(family, templates) {
+			missing = append(missing, family)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Templates bundle does not contain active templates for OS families: %s", strings.Join(missing, ", "))
+	request.Logger.Info(msg)
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}
+}
+
+func osFamilyCovered(family string, templates []templatev1.Template) bool {
+	for i := range templates {
+		if templates[i].Annotations[TemplateDeprecatedAnnotation] == "true" {
+			continue
+		}
+		for label, value := range templates[i].Labels {
+			if value == "true" && strings.HasPrefix(label, TemplateOsLabelPrefix+family) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkNamespaceOverlap returns a degraded status, if the templates would be
+// deployed to the same namespace as the template validator.
+// In that case, no resources are reconciled.
+func checkNamespaceOverlap(request *common.Request) *common.ResourceStatus {
+	templatesNamespace := request.Instance.Spec.CommonTemplates.Namespace
+	if templatesNamespace != common.TemplateValidatorNamespace(request) {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Common templates namespace \"%s\" must be different from the template validator namespace", templatesNamespace)
+	request.Logger.Info(msg)
+	return &common.ResourceStatus{
+		Resource:     request.Instance,
+		NotAvailable: &msg,
+		Degraded:     &msg,
+	}
+}
+
 func reconcileGoldenImagesNS(request *common.Request) (common.ResourceStatus, error) {
+	terminatingStatus, err := checkGoldenImagesNSTerminating(request)
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
+	if terminatingStatus != nil {
+		return *terminatingStatus, nil
+	}
+	if !manageGoldenImagesNamespace(request) {
+		return checkGoldenImagesNS(request)
+	}
+	namespace := newGoldenImagesNS(goldenImagesNamespace(request))
+	// Labels and annotations are merged with the existing ones, so keys added by users are kept
+	namespace.Labels = copyStringMap(request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceLabels)
+	namespace.Annotations = copyStringMap(request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceAnnotations)
 	return common.CreateOrUpdate(request).
-		ClusterResource(newGoldenImagesNS(GoldenImagesNSname)).
+		ClusterResource(namespace).
 		WithAppLabels(operandName, operandComponent).
 		Reconcile()
 }
 
 func reconcileViewRole(request *common.Request) (common.ResourceStatus, error) {
+	return createOrUpdateViewRole(request, newViewRole(goldenImagesNamespace(request)))
+}
+
+func createOrUpdateViewRole(request *common.Request, role *rbac.Role) (common.ResourceStatus, error) {
 	return common.CreateOrUpdate(request).
-		ClusterResource(newViewRole(GoldenImagesNSname)).
+		ClusterResource(role).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			foundRole := foundRes.(*rbac.Role)
 			newRole := newRes.(*rbac.Role)
-			foundRole.Rules = newRole.Rules
+			if !policyRulesEqual(foundRole.Rules, newRole.Rules) {
+				foundRole.Rules = newRole.Rules
+			}
 		}).
 		Reconcile()
 }
 
 func reconcileViewRoleBinding(request *common.Request) (common.ResourceStatus, error) {
+	return createOrUpdateViewRoleBinding(request, newViewRoleBinding(goldenImagesNamespace(request)))
+}
+
+// createOrUpdateViewRoleBinding reconciles the view RoleBinding with the additional subjects from the SSP CR.
+// Subjects added in the cluster are kept. The additional subjects are stored in an annotation,
+// so the ones removed from the SSP CR can be removed from the RoleBinding.
+func createOrUpdateViewRoleBinding(request *common.Request, binding *rbac.RoleBinding) (common.ResourceStatus, error) {
+	additionalSubjects := request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects
+	binding.Subjects = mergeSubjects(binding.Subjects, additionalSubjects, nil)
+	additionalSubjectsJson, err := json.Marshal(additionalSubjects)
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
 	return common.CreateOrUpdate(request).
-		ClusterResource(newViewRoleBinding(GoldenImagesNSname)).
+		ClusterResource(binding).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			newBinding := newRes.(*rbac.RoleBinding)
 			foundBinding := foundRes.(*rbac.RoleBinding)
-			foundBinding.Subjects = newBinding.Subjects
+
+			var previousSubjects []rbac.Subject
+			if previous, ok := foundBinding.Annotations[AdditionalSubjectsAnnotation]; ok {
+				if err := json.Unmarshal([]byte(previous), &previousSubjects); err != nil {
+					request.Logger.Info(fmt.Sprintf("Ignoring invalid annotation %s on RoleBinding %s/%s: %v",
+						AdditionalSubjectsAnnotation, foundBinding.Namespace, foundBinding.Name, err))
+				}
+			}
+			subjects := mergeSubjects(newBinding.Subjects, foundBinding.Subjects, previousSubjects)
+			if !subjectsEqual(foundBinding.Subjects, subjects) {
+				foundBinding.Subjects = subjects
+			}
 			foundBinding.RoleRef = newBinding.RoleRef
+
+			if len(additionalSubjects) == 0 {
+				delete(foundBinding.Annotations, AdditionalSubjectsAnnotation)
+				return
+			}
+			if foundBinding.Annotations == nil {
+				foundBinding.Annotations = map[string]string{}
+			}
+			foundBinding.Annotations[AdditionalSubjectsAnnotation] = string(additionalSubjectsJson)
+		}).
+		Reconcile()
+}
+
+// aggregateGoldenImagesViewRole returns true, if the golden images view permissions
+// are aggregated into the default view and edit ClusterRoles
+func aggregateGoldenImagesViewRole(request *common.Request) bool {
+	aggregate := request.Instance.Spec.CommonTemplates.AggregateGoldenImagesViewRole
+	return aggregate == nil || *aggregate
+}
+
+func reconcileAggregatedViewRole(request *common.Request) (common.ResourceStatus, error) {
+	return common.CreateOrUpdate(request).
+		ClusterResource(newAggregatedViewRole()).
+		WithAppLabels(operandName, operandComponent).
+		UpdateFunc(func(newRes, foundRes client.Object) {
+			newRole := newRes.(*rbac.ClusterRole)
+			foundRole := foundRes.(*rbac.ClusterRole)
+			if !policyRulesEqual(foundRole.Rules, newRole.Rules) {
+				foundRole.Rules = newRole.Rules
+			}
 		}).
 		Reconcile()
 }
 
+func deleteAggregatedViewRole(request *common.Request) error {
+	err := request.Client.Delete(request.Context, newAggregatedViewRole())
+	if err != nil && !errors.IsNotFound(err) {
+		request.Logger.Error(err, fmt.Sprintf("Error deleting \"%s\": %s", AggregatedViewClusterRoleName, err))
+		return err
+	}
+	return nil
+}
+
 func reconcileEditRole(request *common.Request) (common.ResourceStatus, error) {
 	return common.CreateOrUpdate(request).
 		ClusterResource(newEditRole()).
@@ -150,12 +984,18 @@ func reconcileEditRole(request *common.Request) (common.ResourceStatus, error) {
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			newRole := newRes.(*rbac.ClusterRole)
 			foundRole := foundRes.(*rbac.ClusterRole)
-			foundRole.Rules = newRole.Rules
+			if !policyRulesEqual(foundRole.Rules, newRole.Rules) {
+				foundRole.Rules = newRole.Rules
+			}
 		}).
 		Reconcile()
 }
 
-func reconcileOlderTemplates(request *common.Request) ([]common.ReconcileFunc, error) {
+// reconcileOlderTemplates returns functions deprecating templates from older bundle versions.
+// Templates that are part of the current bundle are skipped, even if their version label differs.
+// Only templates with the operator managed-by label, or with a version label of an older bundle
+// are considered. Other base templates were not deployed by the operator, they are reported in an event.
+func reconcileOlderTemplates(request *common.Request, summary *templatesSummary, now time.Time, bundleNames, olderVersions map[string]struct{}) ([]common.ReconcileFunc, error) {
 	// Append functions to take ownership of previously deployed templates during an upgrade
 	templatesSelector := func() labels.Selector {
 		baseRequirement, err := labels.NewRequirement(TemplateTypeLabel, selection.Equals, []string{"base"})
@@ -172,78 +1012,494 @@ func reconcileOlderTemplates(request *common.Request) ([]common.ReconcileFunc, e
 		return labels.NewSelector().Add(*baseRequirement, *versionRequirement)
 	}()
 
-	existingTemplates := &templatev1.TemplateList{}
-	err := request.Client.List(request.Context, existingTemplates, &client.ListOptions{
-		LabelSelector: templatesSelector,
-		Namespace:     request.Instance.Spec.CommonTemplates.Namespace,
-	})
-
-	// There might not be any templates (in case of a fresh deployment), so a NotFound error is accepted
-	if err != nil && !errors.IsNotFound(err) {
+	existingTemplates, err := listTemplatesMetadata(request, templatesSelector)
+	if err != nil {
 		return nil, err
 	}
 
-	funcs := make([]common.ReconcileFunc, 0, len(existingTemplates.Items))
-	for i := range existingTemplates.Items {
-		template := &existingTemplates.Items[i]
+	retention := request.Instance.Spec.CommonTemplates.DeprecatedTemplatesRetention
+
+	funcs := make([]common.ReconcileFunc, 0, len(existingTemplates))
+	for i := range existingTemplates {
+		if _, inBundle := bundleNames[existingTemplates[i].Name]; inBundle {
+			continue
+		}
+		// Only templates deployed by the operator are deprecated, user templates labeled as "base" are left alone
+		if !isOlderCommonTemplate(&existingTemplates[i], olderVersions) {
+			request.Event(core.EventTypeNormal, ForeignTemplateSkippedReason,
+				fmt.Sprintf("Skipped foreign template %s/%s, it was not deployed by the operator",
+					existingTemplates[i].Namespace, existingTemplates[i].Name))
+			continue
+		}
+		// Only metadata of the template is needed, the update function modifies just labels
+		template := &templatev1.Template{ObjectMeta: existingTemplates[i].ObjectMeta}
+		if retention != nil && deprecationExpired(template, retention.Duration, now) {
+			funcs = append(funcs, withFailedStatus(template, func(request *common.Request) (common.ResourceStatus, error) {
+				return deleteDeprecatedTemplate(request, template)
+			}))
+			continue
+		}
+
+		newlyDeprecated := template.Annotations[TemplateDeprecatedAnnotation] != "true"
 		if template.Annotations == nil {
 			template.Annotations = make(map[string]string)
 		}
 		template.Annotations[TemplateDeprecatedAnnotation] = "true"
-		funcs = append(funcs, func(*common.Request) (common.ResourceStatus, error) {
-			return common.CreateOrUpdate(request).
+		if _, ok := template.Annotations[TemplateDeprecatedTimeAnnotation]; !ok {
+			template.Annotations[TemplateDeprecatedTimeAnnotation] = now.UTC().Format(time.RFC3339)
+		}
+		markDeprecated(template.Annotations)
+		funcs = append(funcs, withFailedStatus(template, func(*common.Request) (common.ResourceStatus, error) {
+			status, err := common.CreateOrUpdate(request).
 				ClusterResource(template).
 				WithAppLabels(operandName, operandComponent).
 				UpdateFunc(func(_, foundRes client.Object) {
 					foundTemplate := foundRes.(*templatev1.Template)
 					for key := range foundTemplate.Labels {
-						if strings.HasPrefix(key, TemplateOsLabelPrefix) ||
-							strings.HasPrefix(key, TemplateFlavorLabelPrefix) ||
-							strings.HasPrefix(key, TemplateWorkloadLabelPrefix) {
+						if isSelectorLabel(key) {
 							delete(foundTemplate.Labels, key)
 						}
 					}
 				}).
 				Reconcile()
-		})
+			if err == nil && newlyDeprecated && !request.DryRun {
+				summary.countDeprecated()
+				request.Event(core.EventTypeNormal, TemplateDeprecatedReason,
+					fmt.Sprintf("Template %s/%s from version %s is deprecated, it is not in the current templates bundle",
+						template.Namespace, template.Name, template.Labels[TemplateVersionLabel]))
+			}
+			return status, err
+		}))
 	}
 
 	return funcs, nil
 }
 
-func reconcileTemplatesFuncs(request *common.Request) []common.ReconcileFunc {
-	loadTemplates := func() {
-		var err error
-		filename := filepath.Join(BundleDir, "common-templates-"+Version+".yaml")
-		templatesBundle, err = ReadTemplates(filename)
+// isOlderCommonTemplate returns true, if the template is managed by the operator,
+// or has the version label of an older templates bundle
+func isOlderCommonTemplate(template *metav1.PartialObjectMetadata, olderVersions map[string]struct{}) bool {
+	if template.Labels[common.AppKubernetesManagedByLabel] == "ssp-operator" {
+		return true
+	}
+	_, olderVersion := olderVersions[template.Labels[TemplateVersionLabel]]
+	return olderVersion
+}
+
+// olderBundleVersions returns versions of the older templates bundles shipped in the directory
+// with the current bundle. If the directory cannot be read, no versions are returned.
+func olderBundleVersions(bundleFile string) map[string]struct{} {
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(bundleFile), "common-templates-*.yaml"))
+	if err != nil {
+		return nil
+	}
+	versions := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "common-templates-"), ".yaml")
+		if version != Version {
+			versions[version] = struct{}{}
+		}
+	}
+	return versions
+}
+
+// markDeprecated prefixes the display name and description of a deprecated template,
+// so it can be distinguished in the console. Annotations already prefixed are not changed.
+func markDeprecated(annotations map[string]string) {
+	for _, key := range []string{TemplateDisplayNameAnnotation, TemplateDescriptionAnnotation} {
+		value, ok := annotations[key]
+		if ok && !strings.HasPrefix(value, DeprecatedTemplateMarker) {
+			annotations[key] = DeprecatedTemplateMarker + value
+		}
+	}
+}
+
+// loadBundle returns templates from the ConfigMap referenced in the SSP CR, or from the bundle file.
+// Templates from additional bundles are appended to them.
+func (c *commonTemplates) loadBundle(request *common.Request) ([]templatev1.Template, *common.ResourceStatus, error) {
+	var templates []templatev1.Template
+	var status *common.ResourceStatus
+	if request.Instance.Spec.CommonTemplates.BundleConfigMap != nil {
+		configMapTemplates, configMapStatus, err := loadConfigMapBundle(request)
+		if err != nil || configMapStatus != nil {
+			return nil, configMapStatus, err
+		}
+		templates = configMapTemplates
+	} else {
+		bundleTemplates, bundleStatus, err := c.loadTemplatesBundle(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = bundleTemplates
+		status = bundleStatus
+	}
+
+	if len(request.Instance.Spec.CommonTemplates.AdditionalBundles) == 0 {
+		return templates, status, nil
+	}
+	additionalBundles, err := c.additionalBundles.Load(
+		request.Instance.Spec.CommonTemplates.AdditionalBundles,
+		request.Instance.Spec.CommonTemplates.VerifyBundleIntegrity,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	mainBundle := loadedBundle{source: "main bundle", templates: templates}
+	merged, err := mergeBundles(append([]loadedBundle{mainBundle}, additionalBundles...))
+	return merged, status, err
+}
+
+// listTemplatesMetadata lists metadata of templates in the common templates namespace
+// matching the selector. Templates are listed in pages of templatesListPageSize.
+func listTemplatesMetadata(request *common.Request, selector labels.Selector) ([]metav1.PartialObjectMetadata, error) {
+	return listTemplatesMetadataInNamespace(request, request.Instance.Spec.CommonTemplates.Namespace, selector)
+}
+
+func listTemplatesMetadataInNamespace(request *common.Request, namespace string, selector labels.Selector) ([]metav1.PartialObjectMetadata, error) {
+	var result []metav1.PartialObjectMetadata
+	continueToken := ""
+	for {
+		page := &metav1.PartialObjectMetadataList{}
+		page.SetGroupVersionKind(templatev1.GroupVersion.WithKind("TemplateList"))
+		err := request.Client.List(request.Context, page,
+			client.MatchingLabelsSelector{Selector: selector},
+			client.InNamespace(namespace),
+			client.Limit(templatesListPageSize),
+			client.Continue(continueToken),
+		)
+		// There might not be any templates (in case of a fresh deployment), so a NotFound error is accepted
+		if errors.IsNotFound(err) {
+			return result, nil
+		}
 		if err != nil {
-			request.Logger.Error(err, fmt.Sprintf("Error reading from template bundle, %v", err))
-			panic(err)
+			return nil, err
 		}
-		if len(templatesBundle) == 0 {
-			panic("No templates could be found in the installed bundle")
+
+		result = append(result, page.Items...)
+		continueToken = page.Continue
+		if continueToken == "" {
+			return result, nil
 		}
 	}
-	// Only load templates Once
-	loadTemplatesOnce.Do(loadTemplates)
+}
+
+// loadTemplatesBundle returns the templates from the bundle file. If the file cannot be loaded,
+// the previously loaded templates are used. An error is only returned if there are none.
+// In permissive mode, templates from valid documents are returned with a degraded status.
+func (c *commonTemplates) loadTemplatesBundle(request *common.Request) ([]templatev1.Template, *common.ResourceStatus, error) {
+	templatesBundle, err := c.bundleLoader.Load(
+		request.Instance.Spec.CommonTemplates.VerifyBundleIntegrity,
+		request.Instance.Spec.CommonTemplates.PermissiveBundleLoading,
+	)
+	partialErr := &PartialBundleError{}
+	if err != nil && templatesBundle != nil && goerrors.As(err, &partialErr) {
+		msg := fmt.Sprintf("Templates from documents %v of the bundle are not deployed: %v", partialErr.Documents, err)
+		request.Logger.Info(msg)
+		return templatesBundle, &common.ResourceStatus{Resource: request.Instance, Degraded: &msg}, nil
+	}
+	if err != nil {
+		request.Logger.Error(err, fmt.Sprintf("Error reading from template bundle, %v", err))
+		if templatesBundle = c.bundleLoader.Templates(); templatesBundle == nil {
+			return nil, nil, fmt.Errorf("failed to load common templates bundle: %w", err)
+		}
+		// Keep using the previously loaded templates
+	}
+	return templatesBundle, nil, nil
+}
+
+// deprecationExpired returns true if the template was deprecated longer than the retention.
+// Templates without a valid deprecation time are not expired.
+func deprecationExpired(template *templatev1.Template, retention time.Duration, now time.Time) bool {
+	deprecatedTime, err := time.Parse(time.RFC3339, template.Annotations[TemplateDeprecatedTimeAnnotation])
+	if err != nil {
+		return false
+	}
+	return now.Sub(deprecatedTime) > retention
+}
+
+func deleteDeprecatedTemplate(request *common.Request, template *templatev1.Template) (common.ResourceStatus, error) {
+	inUse, err := templateInUse(request, template)
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
+	if inUse {
+		msg := fmt.Sprintf("Deprecated template %s/%s is not deleted, because it is used by VirtualMachines", template.Namespace, template.Name)
+		request.Logger.Info(msg)
+		request.Event(core.EventTypeWarning, DeprecatedTemplateInUseReason, msg)
+		return common.ResourceStatus{Resource: template}, nil
+	}
+
+	request.Logger.Info(fmt.Sprintf("Deleting deprecated template \"%s\", retention period expired", template.Name))
+	err = request.Client.Delete(request.Context, template)
+	if err != nil && !errors.IsNotFound(err) {
+		return common.ResourceStatus{}, err
+	}
+	return common.ResourceStatus{Resource: template}, nil
+}
+
+// templateInUse returns true, if any VirtualMachine was created from the template.
+// If KubeVirt is not installed, no VirtualMachine can use the template.
+func templateInUse(request *common.Request, template *templatev1.Template) (bool, error) {
+	vms := newVirtualMachineList()
+	err := request.Client.List(request.Context, vms,
+		client.MatchingLabels{
+			VMTemplateNameLabel:      template.Name,
+			VMTemplateNamespaceLabel: template.Namespace,
+		},
+		client.Limit(1),
+	)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(vms.Items) > 0, nil
+}
 
+func (c *commonTemplates) reconcileTemplatesFuncs(request *common.Request, summary *templatesSummary, templatesBundle []templatev1.Template, defaults *vmDefaults) []common.ReconcileFunc {
 	namespace := request.Instance.Spec.CommonTemplates.Namespace
 	funcs := make([]common.ReconcileFunc, 0, len(templatesBundle))
 	for i := range templatesBundle {
-		template := &templatesBundle[i]
+		// The bundle is shared between reconciliations, so it has to be copied before modification
+		template := templatesBundle[i].DeepCopy()
 		template.ObjectMeta.Namespace = namespace
-		funcs = append(funcs, func(request *common.Request) (common.ResourceStatus, error) {
-			return common.CreateOrUpdate(request).
+		setSourcePVCNamespace(template, goldenImagesNamespace(request))
+		funcs = append(funcs, withFailedStatus(template, func(request *common.Request) (common.ResourceStatus, error) {
+			warnings, err := validateTemplateParameters(template)
+			for _, warning := range warnings {
+				request.Logger.Info(warning)
+			}
+			if err != nil {
+				// Processing of the template would fail, so it is not reconciled
+				msg := err.Error()
+				return common.ResourceStatus{Resource: template, Degraded: &msg}, nil
+			}
+			if err := defaults.apply(template); err != nil {
+				if isSecureBootConflict(err) {
+					// The template is not reconciled, until the conflicting default is changed
+					msg := err.Error()
+					return common.ResourceStatus{Resource: template, Degraded: &msg}, nil
+				}
+				return common.ResourceStatus{}, err
+			}
+			hash, err := templateHash(template)
+			if err != nil {
+				return common.ResourceStatus{}, err
+			}
+			if c.serverSideApply {
+				// With server-side apply, the UpdateFunc is only used as a fallback
+				// and the hash annotation is owned by the operator.
+				if template.Annotations == nil {
+					template.Annotations = map[string]string{}
+				}
+				template.Annotations[TemplateHashAnnotation] = hash
+			}
+			if previousHash, ok := c.templateHashes.Load(template.Name); ok && previousHash != hash {
+				// The bundle changed, so the cached version of the template cannot be used
+				request.VersionCache.RemoveObj(template)
+			}
+			preserveParameters := request.Instance.Spec.CommonTemplates.PreserveParameterDefaults
+			// existing is the template found in the cluster before it was updated, or nil if it was created
+			var existing *templatev1.Template
+			observeExisting := func(found *templatev1.Template) {
+				existing = found
+				if missing := missingSelectorLabels(found, template); len(missing) > 0 {
+					request.Logger.Info(fmt.Sprintf("Restoring labels removed from template %s: %s", template.Name, strings.Join(missing, ", ")))
+					templatesLabelsRepaired.Inc()
+				}
+			}
+			// In dry-run mode, the template is always read and updated as usual
+			serverSideApply := c.serverSideApply && !request.DryRun
+			if serverSideApply {
+				// Apply does not read the template, so it is read here from the cache.
+				found := &templatev1.Template{}
+				err := request.Client.Get(request.Context, client.ObjectKeyFromObject(template), found)
+				if err != nil && !errors.IsNotFound(err) {
+					return common.ResourceStatus{}, err
+				}
+				if err == nil {
+					observeExisting(found)
+					if preserveParameters {
+						// The hash is computed from the bundle content before merging,
+						// so the kept values are not reported as modified.
+						template.Parameters = mergeParameterDefaults(template.Parameters, found.Parameters)
+					}
+					// Apply would overwrite the objects of an unmanaged template,
+					// so it is updated as usual instead.
+					serverSideApply = !isTemplateUnmanaged(found)
+				}
+			}
+			previousUID, wasCached := request.VersionCache.UID(template)
+			restored := false
+			status, err := common.CreateOrUpdate(request).
 				ClusterResource(template).
 				WithAppLabels(operandName, operandComponent).
+				ServerSideApply(serverSideApply).
+				ExistingFunc(func(found client.Object) {
+					if existing == nil {
+						// With server-side apply, the template may have been read already
+						observeExisting(found.(*templatev1.Template))
+					}
+				}).
 				UpdateFunc(func(newRes, foundRes client.Object) {
 					newTemplate := newRes.(*templatev1.Template)
 					foundTemplate := foundRes.(*templatev1.Template)
+					if preserveParameters {
+						// The hash is computed from the bundle content before merging,
+						// so the kept values are not reported as modified.
+						newTemplate.Parameters = mergeParameterDefaults(newTemplate.Parameters, foundTemplate.Parameters)
+					}
+					// Missing labels are added by CreateOrUpdate, here selector labels not in the bundle are removed
+					for key := range foundTemplate.Labels {
+						if _, desired := newTemplate.Labels[key]; isSelectorLabel(key) && !desired {
+							delete(foundTemplate.Labels, key)
+						}
+					}
+					if isTemplateUnmanaged(foundTemplate) {
+						return
+					}
+					// The hash annotation is not part of the new template,
+					// so here it still contains the previous value.
+					if foundTemplate.Annotations[TemplateHashAnnotation] == hash {
+						if templateContentEqual(newTemplate, foundTemplate) {
+							return
+						}
+						// The desired content did not change since the last update,
+						// so the template was modified by someone else.
+						restored = true
+					}
 					foundTemplate.Objects = newTemplate.Objects
 					foundTemplate.Parameters = newTemplate.Parameters
+					if foundTemplate.Annotations == nil {
+						foundTemplate.Annotations = map[string]string{}
+					}
+					foundTemplate.Annotations[TemplateHashAnnotation] = hash
+				}).
+				StatusFunc(func(foundRes client.Object) common.ResourceStatus {
+					if !isTemplateUnmanaged(foundRes.(*templatev1.Template)) {
+						return common.ResourceStatus{}
+					}
+					msg := fmt.Sprintf("Template %s is unmanaged, its objects and parameters are not updated", foundRes.GetName())
+					return common.ResourceStatus{Skipped: &msg}
 				}).
 				Reconcile()
-		})
+			if err != nil {
+				return status, err
+			}
+			c.templateHashes.Store(template.Name, hash)
+			if !request.DryRun {
+				switch {
+				case existing == nil:
+					summary.countAdded()
+				case isTemplateUnmanaged(existing):
+					// Objects of unmanaged templates are not updated
+				case existing.Labels[TemplateVersionLabel] != template.Labels[TemplateVersionLabel]:
+					summary.countUpdated()
+				}
+			}
+			if restored && !request.DryRun {
+				request.Logger.Info(fmt.Sprintf("Template %s was modified and restored", template.Name))
+				request.Event(core.EventTypeWarning, TemplateRestoredReason,
+					fmt.Sprintf("Template %s/%s was modified and restored", template.Namespace, template.Name))
+				templatesRestored.WithLabelValues(templateWorkload(template)).Inc()
+			}
+			if uid, ok := request.VersionCache.UID(template); wasCached && ok && uid != previousUID {
+				// The template was deleted since the last reconciliation and created again.
+				// Objects referencing the old template by UID are no longer valid.
+				request.Logger.Info(fmt.Sprintf("Template %s was recreated", template.Name))
+				request.Event(core.EventTypeWarning, TemplateRecreatedReason,
+					fmt.Sprintf("Template %s/%s was recreated, its UID changed from %s to %s",
+						template.Namespace, template.Name, previousUID, uid))
+			}
+			return status, nil
+		}))
 	}
 	return funcs
 }
+
+// isTemplateUnmanaged returns true, if the user opted the template out of reconciliation
+func isTemplateUnmanaged(template *templatev1.Template) bool {
+	return template.Annotations[TemplateUnmanagedAnnotation] == "true"
+}
+
+// isSelectorLabel returns true for the OS, flavor and workload labels, used to select templates
+func isSelectorLabel(key string) bool {
+	return strings.HasPrefix(key, TemplateOsLabelPrefix) ||
+		strings.HasPrefix(key, TemplateFlavorLabelPrefix) ||
+		strings.HasPrefix(key, TemplateWorkloadLabelPrefix)
+}
+
+// missingSelectorLabels returns the selector labels of the desired template, that are missing in the found template
+func missingSelectorLabels(found, desired *templatev1.Template) []string {
+	var missing []string
+	for key, value := range desired.Labels {
+		if foundValue, ok := found.Labels[key]; isSelectorLabel(key) && (!ok || foundValue != value) {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// pruneRemovedTemplates deletes templates of the current version that were
+// deployed by the operator, but are no longer present in the bundle.
+// Templates from older versions are handled by reconcileOlderTemplates.
+func pruneRemovedTemplates(request *common.Request, templatesBundle []templatev1.Template) error {
+	bundleNames := templateNames(templatesBundle)
+	return deleteOwnedTemplates(request, func(template *templatev1.Template) bool {
+		_, ok := bundleNames[template.Name]
+		return !ok
+	})
+}
+
+// deleteExcludedTemplates deletes templates deployed by the operator,
+// that are excluded by the template filters.
+func deleteExcludedTemplates(request *common.Request, excludedTemplates []templatev1.Template) error {
+	if len(excludedTemplates) == 0 {
+		return nil
+	}
+	excludedNames := templateNames(excludedTemplates)
+	return deleteOwnedTemplates(request, func(template *templatev1.Template) bool {
+		_, ok := excludedNames[template.Name]
+		return ok
+	})
+}
+
+// deleteOwnedTemplates deletes templates of the current version deployed by the operator,
+// for which shouldDelete returns true. Templates without the operator labels are never deleted.
+func deleteOwnedTemplates(request *common.Request, shouldDelete func(*templatev1.Template) bool) error {
+	existingTemplates := &templatev1.TemplateList{}
+	err := request.Client.List(request.Context, existingTemplates,
+		client.InNamespace(request.Instance.Spec.CommonTemplates.Namespace),
+		client.MatchingLabels{
+			TemplateTypeLabel:                  "base",
+			TemplateVersionLabel:               Version,
+			common.AppKubernetesNameLabel:      operandName,
+			common.AppKubernetesManagedByLabel: "ssp-operator",
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range existingTemplates.Items {
+		template := &existingTemplates.Items[i]
+		if !shouldDelete(template) {
+			continue
+		}
+		request.Logger.Info(fmt.Sprintf("Deleting template \"%s\"", template.Name))
+		err := request.Client.Delete(request.Context, template)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func templateNames(templates []templatev1.Template) map[string]struct{} {
+	names := make(map[string]struct{}, len(templates))
+	for i := range templates {
+		names[templates[i].Name] = struct{}{}
+	}
+	return names
+}
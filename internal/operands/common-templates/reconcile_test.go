@@ -1,16 +1,45 @@
 package common_templates
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	runtime_ "runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	coordination "k8s.io/api/coordination/v1"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	. "kubevirt.io/ssp-operator/internal/test-utils"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -19,33 +48,369 @@ import (
 	libhandler "github.com/operator-framework/operator-lib/handler"
 	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 	"kubevirt.io/ssp-operator/internal/common"
+	"kubevirt.io/ssp-operator/internal/operands"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
-	log     = logf.Log.WithName("common-templates-operand")
-	operand = GetOperand()
+	log = logf.Log.WithName("common-templates-operand")
+
+	// operand and its bundleLoader are created for each test, so tests do not share state
+	operand      operands.Operand
+	bundleLoader *templatesLoader
+
+	parseBundleOnce sync.Once
+	parsedBundle    *templatesLoader
 )
 
+// newParsedBundleLoader returns a new loader with the templates from the default bundle.
+// The bundle is large, so it is parsed only once and each loader gets a copy of the templates.
+// The file is not read again by Load, unless the loader is called with other arguments.
+func newParsedBundleLoader() *templatesLoader {
+	parseBundleOnce.Do(func() {
+		parsedBundle = newTemplatesLoader("")
+		_, err := parsedBundle.Load(false, false)
+		ExpectWithOffset(2, err).ToNot(HaveOccurred())
+	})
+
+	parsedBundle.lock.Lock()
+	defer parsedBundle.lock.Unlock()
+	templates := make([]templatev1.Template, 0, len(parsedBundle.templates))
+	for i := range parsedBundle.templates {
+		templates = append(templates, *parsedBundle.templates[i].DeepCopy())
+	}
+	return &templatesLoader{
+		filename:   parsedBundle.filename,
+		modTime:    parsedBundle.modTime,
+		size:       parsedBundle.size,
+		checksum:   parsedBundle.checksum,
+		verified:   parsedBundle.verified,
+		templates:  templates,
+		permissive: parsedBundle.permissive,
+		partialErr: parsedBundle.partialErr,
+		loadTime:   parsedBundle.loadTime,
+	}
+}
+
 const (
-	namespace = "kubevirt"
-	name      = "test-ssp"
+	namespace    = "kubevirt"
+	sspNamespace = "kubevirt-ssp"
+	name         = "test-ssp"
 
 	testOsLabel       = TemplateOsLabelPrefix + "some-os"
 	testFlavorLabel   = TemplateFlavorLabelPrefix + "test"
 	testWorkflowLabel = TemplateWorkloadLabelPrefix + "server"
 )
 
+const testTemplateYaml = `---
+apiVersion: template.openshift.io/v1
+kind: Template
+metadata:
+  name: %s
+  labels:
+    template.kubevirt.io/type: base
+    template.kubevirt.io/version: %s
+    os.template.kubevirt.io/some-os: "true"
+    flavor.template.kubevirt.io/test: "true"
+    workload.template.kubevirt.io/server: "true"
+objects: []
+`
+
+const testTemplateWithOsYaml = `---
+apiVersion: template.openshift.io/v1
+kind: Template
+metadata:
+  name: %s
+  labels:
+    template.kubevirt.io/type: base
+    template.kubevirt.io/version: %s
+    flavor.template.kubevirt.io/test: "true"
+    workload.template.kubevirt.io/server: "true"
+    %s: "true"
+objects: []
+`
+
+// templateWriteCounter counts create and update calls of templates
+type templateWriteCounter struct {
+	client.Client
+	writes int32
+}
+
+func (c *templateWriteCounter) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*templatev1.Template); ok {
+		atomic.AddInt32(&c.writes, 1)
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *templateWriteCounter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*templatev1.Template); ok {
+		atomic.AddInt32(&c.writes, 1)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *templateWriteCounter) Writes() int32 {
+	return atomic.LoadInt32(&c.writes)
+}
+
+// rbacUpdateCounter counts updates of roles and role bindings
+type rbacUpdateCounter struct {
+	client.Client
+	updates int
+}
+
+func (c *rbacUpdateCounter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	switch obj.(type) {
+	case *rbac.Role, *rbac.ClusterRole, *rbac.RoleBinding:
+		c.updates++
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// uidSettingClient sets a unique UID to created objects, like the API server does
+type uidSettingClient struct {
+	client.Client
+	counter int32
+}
+
+func (c *uidSettingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	obj.SetUID(types.UID(fmt.Sprintf("test-uid-%d", atomic.AddInt32(&c.counter, 1))))
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// failingTemplateClient fails to create templates with the given names
+type failingTemplateClient struct {
+	client.Client
+	names map[string]struct{}
+}
+
+func (c *failingTemplateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*templatev1.Template); ok {
+		if _, fail := c.names[obj.GetName()]; fail {
+			return fmt.Errorf("failed to create template %s", obj.GetName())
+		}
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// failingTemplateDeleteClient fails to delete templates in the given namespace
+type failingTemplateDeleteClient struct {
+	client.Client
+	namespace string
+}
+
+func (c *failingTemplateDeleteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if _, ok := obj.(*templatev1.Template); ok && obj.GetNamespace() == c.namespace {
+		return fmt.Errorf("failed to delete template %s", obj.GetName())
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// noTemplatesAPIClient simulates a cluster where the Template API was already removed
+type noTemplatesAPIClient struct {
+	client.Client
+}
+
+func (c *noTemplatesAPIClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if _, ok := obj.(*templatev1.Template); ok {
+		return &meta.NoKindMatchError{GroupKind: templatev1.GroupVersion.WithKind("Template").GroupKind()}
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// paginatingClient splits metadata lists to pages, because the fake client ignores limits
+type paginatingClient struct {
+	client.Client
+	limits []int64
+}
+
+func (c *paginatingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	metadataList, ok := list.(*metav1.PartialObjectMetadataList)
+	if !ok {
+		return c.Client.List(ctx, list, opts...)
+	}
+
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	c.limits = append(c.limits, listOpts.Limit)
+
+	// Continue and Limit are not passed, the fake client would ignore them
+	allOpts := &client.ListOptions{LabelSelector: listOpts.LabelSelector, Namespace: listOpts.Namespace}
+	if err := c.Client.List(ctx, metadataList, allOpts); err != nil {
+		return err
+	}
+
+	offset := 0
+	if listOpts.Continue != "" {
+		offset, _ = strconv.Atoi(listOpts.Continue)
+	}
+	end := offset + int(listOpts.Limit)
+	if listOpts.Limit == 0 || end >= len(metadataList.Items) {
+		end = len(metadataList.Items)
+		metadataList.Continue = ""
+	} else {
+		metadataList.Continue = strconv.Itoa(end)
+	}
+	// Items are copied, so the page does not reference the whole list
+	metadataList.Items = append([]metav1.PartialObjectMetadata(nil), metadataList.Items[offset:end]...)
+	return nil
+}
+
+func counterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	ExpectWithOffset(1, counter.Write(metric)).To(Succeed())
+	return metric.GetCounter().GetValue()
+}
+
+func gaugeValue(gauge prometheus.Gauge) float64 {
+	metric := &dto.Metric{}
+	ExpectWithOffset(1, gauge.Write(metric)).To(Succeed())
+	return metric.GetGauge().GetValue()
+}
+
+func newTestTemplate(name string) *templatev1.Template {
+	return &templatev1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
+
+func getTemplateVM(name string, request common.Request) *unstructured.Unstructured {
+	template := newTestTemplate(name)
+	ExpectWithOffset(1, request.Client.Get(request.Context, client.ObjectKeyFromObject(template), template)).To(Succeed())
+	for _, object := range template.Objects {
+		vm := &unstructured.Unstructured{}
+		ExpectWithOffset(1, json.Unmarshal(object.Raw, &vm.Object)).To(Succeed())
+		if vm.GetKind() == "VirtualMachine" {
+			return vm
+		}
+	}
+	Fail("template does not contain a VirtualMachine: " + name)
+	return nil
+}
+
 func TestTemplates(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Common Templates Suite")
 }
 
+// latencyClient delays each API call, to simulate a loaded API server
+type latencyClient struct {
+	client.Client
+	latency time.Duration
+}
+
+func (c *latencyClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	time.Sleep(c.latency)
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *latencyClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	time.Sleep(c.latency)
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *latencyClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *latencyClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	time.Sleep(c.latency)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func BenchmarkReconcileTemplates(b *testing.B) {
+	const (
+		templatesCount = 200
+		apiLatency     = time.Millisecond
+	)
+
+	dir, err := ioutil.TempDir("", "common-templates-benchmark")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	bundle := strings.Builder{}
+	for i := 0; i < templatesCount; i++ {
+		bundle.WriteString(fmt.Sprintf(testTemplateYaml, fmt.Sprintf("template-%d", i), Version))
+	}
+	bundleFile := filepath.Join(dir, "bundle.yaml")
+	if err := ioutil.WriteFile(bundleFile, []byte(bundle.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	s := runtime.NewScheme()
+	if err := core.AddToScheme(s); err != nil {
+		b.Fatal(err)
+	}
+	if err := rbac.AddToScheme(s); err != nil {
+		b.Fatal(err)
+	}
+	if err := ssp.AddToScheme(s); err != nil {
+		b.Fatal(err)
+	}
+	if err := templatev1.Install(s); err != nil {
+		b.Fatal(err)
+	}
+
+	newRequest := func() *common.Request {
+		fakeClient := fake.NewFakeClientWithScheme(s,
+			&core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+		return &common.Request{
+			Client:  &latencyClient{Client: fakeClient, latency: apiLatency},
+			Context: context.Background(),
+			Instance: &ssp.SSP{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "SSP",
+					APIVersion: ssp.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: sspNamespace,
+				},
+				Spec: ssp.SSPSpec{
+					CommonTemplates: ssp.CommonTemplates{
+						Namespace: namespace,
+					},
+				},
+			},
+			Logger:       log,
+			VersionCache: common.VersionCache{},
+		}
+	}
+
+	for _, parallelism := range []int{1, defaultParallelism} {
+		b.Run(fmt.Sprintf("parallelism-%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				benchOperand := GetOperand().(*commonTemplates)
+				benchOperand.parallelism = parallelism
+				benchOperand.bundleLoader = newTemplatesLoader(bundleFile)
+				request := newRequest()
+				b.StartTimer()
+
+				if _, err := benchOperand.Reconcile(request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 var _ = Describe("Common-Templates operand", func() {
 
 	var request common.Request
 
 	BeforeEach(func() {
+		operand = GetOperand()
+		bundleLoader = newParsedBundleLoader()
+		operand.(*commonTemplates).bundleLoader = bundleLoader
+
 		s := scheme.Scheme
 		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
 		Expect(operand.AddWatchTypesToScheme(s)).ToNot(HaveOccurred())
@@ -54,7 +419,7 @@ var _ = Describe("Common-Templates operand", func() {
 		request = common.Request{
 			Request: reconcile.Request{
 				NamespacedName: types.NamespacedName{
-					Namespace: namespace,
+					Namespace: sspNamespace,
 					Name:      name,
 				},
 			},
@@ -65,49 +430,3987 @@ var _ = Describe("Common-Templates operand", func() {
 					Kind:       "SSP",
 					APIVersion: ssp.GroupVersion.String(),
 				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: sspNamespace,
+				},
+				Spec: ssp.SSPSpec{
+					CommonTemplates: ssp.CommonTemplates{
+						Namespace: namespace,
+					},
+				},
+			},
+			Logger:       log,
+			VersionCache: common.VersionCache{},
+		}
+		templatesNamespace := &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+		Expect(client.Create(request.Context, templatesNamespace)).To(Succeed())
+	})
+
+	Context("with overlapping namespaces", func() {
+		BeforeEach(func() {
+			request.Instance.Spec.TemplateValidator.Namespace = namespace
+		})
+
+		It("should report degraded status", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].Resource).To(Equal(request.Instance))
+			Expect(statuses[0].Degraded).ToNot(BeNil())
+			Expect(statuses[0].NotAvailable).ToNot(BeNil())
+		})
+
+		It("should not create RBAC and templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceNotExists(newViewRole(GoldenImagesNSname), request)
+			ExpectResourceNotExists(newViewRoleBinding(GoldenImagesNSname), request)
+			ExpectResourceNotExists(newEditRole(), request)
+			for _, template := range bundleLoader.Templates() {
+				template.Namespace = namespace
+				ExpectResourceNotExists(&template, request)
+			}
+		})
+
+		It("should report degraded status when validator uses SSP namespace", func() {
+			request.Instance.Spec.TemplateValidator.Namespace = ""
+			request.Instance.Spec.CommonTemplates.Namespace = sspNamespace
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].Degraded).ToNot(BeNil())
+		})
+	})
+
+	It("should return single error if templates namespace does not exist", func() {
+		request.Instance.Spec.CommonTemplates.Namespace = "non-existing"
+
+		counter := &templateWriteCounter{Client: request.Client}
+		request.Client = counter
+
+		_, err := operand.Reconcile(&request)
+		Expect(err).To(MatchError(`namespace "non-existing" for common templates does not exist`))
+		Expect(counter.Writes()).To(BeZero())
+	})
+
+	It("should deploy templates to golden images namespace", func() {
+		request.Instance.Spec.CommonTemplates.Namespace = GoldenImagesNSname
+
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		for _, template := range bundleLoader.Templates() {
+			template.Namespace = GoldenImagesNSname
+			ExpectResourceExists(&template, request)
+		}
+	})
+
+	It("should create golden-images namespace", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+	})
+	It("should create common-template resources", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		templatesBundle := bundleLoader.Templates()
+		Expect(templatesBundle).ToNot(BeNil())
+		for _, template := range templatesBundle {
+			template.Namespace = namespace
+			ExpectResourceExists(&template, request)
+		}
+	})
+	It("should create view role", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+	})
+	It("should create view role binding", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+	})
+	It("should create view role binding", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+		ExpectResourceExists(newEditRole(), request)
+	})
+
+	Context("aggregated view role", func() {
+		It("should create aggregated view role", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			role := newAggregatedViewRole()
+			ExpectResourceExists(role, request)
+			Expect(role.Labels).To(HaveKeyWithValue(AggregateToViewLabel, "true"))
+			Expect(role.Labels).To(HaveKeyWithValue(AggregateToEditLabel, "true"))
+			Expect(role.Rules).To(Equal(newAggregatedViewRole().Rules))
+		})
+
+		It("should restore aggregation labels", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			role := newAggregatedViewRole()
+			ExpectResourceExists(role, request)
+			delete(role.Labels, AggregateToViewLabel)
+			Expect(request.Client.Update(request.Context, role)).To(Succeed())
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(role, request)
+			Expect(role.Labels).To(HaveKeyWithValue(AggregateToViewLabel, "true"))
+		})
+
+		It("should remove aggregated view role when disabled", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(newAggregatedViewRole(), request)
+
+			disabled := false
+			request.Instance.Spec.CommonTemplates.AggregateGoldenImagesViewRole = &disabled
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceNotExists(newAggregatedViewRole(), request)
+
+			// The namespaced view role is still reconciled
+			ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+		})
+
+		It("should remove aggregated view role on cleanup", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+			ExpectResourceNotExists(newAggregatedViewRole(), request)
+		})
+	})
+
+	Context("view role binding subjects", func() {
+		teamGroup := rbac.Subject{Kind: rbac.GroupKind, Name: "team-a", APIGroup: rbac.GroupName}
+		teamUser := rbac.Subject{Kind: rbac.UserKind, Name: "alice", APIGroup: rbac.GroupName}
+		adminGroup := rbac.Subject{Kind: rbac.GroupKind, Name: "admin-added", APIGroup: rbac.GroupName}
+
+		getSubjects := func() []rbac.Subject {
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			return binding.Subjects
+		}
+
+		reconcile := func() {
+			request.VersionCache = common.VersionCache{}
+			_, err := operand.Reconcile(&request)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		}
+
+		It("should add additional subjects from the spec", func() {
+			request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects = []rbac.Subject{teamGroup, teamUser}
+			reconcile()
+
+			expected := append(newViewRoleBinding(GoldenImagesNSname).Subjects, teamGroup, teamUser)
+			Expect(getSubjects()).To(Equal(expected))
+		})
+
+		It("should keep subjects added in the cluster", func() {
+			reconcile()
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			binding.Subjects = append(binding.Subjects, adminGroup)
+			Expect(request.Client.Update(request.Context, binding)).To(Succeed())
+
+			reconcile()
+			Expect(getSubjects()).To(ContainElement(adminGroup))
+		})
+
+		It("should restore removed operator subjects", func() {
+			reconcile()
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			binding.Subjects = []rbac.Subject{adminGroup}
+			Expect(request.Client.Update(request.Context, binding)).To(Succeed())
+
+			reconcile()
+			Expect(getSubjects()).To(Equal(append(newViewRoleBinding(GoldenImagesNSname).Subjects, adminGroup)))
+		})
+
+		It("should remove subjects removed from the spec", func() {
+			request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects = []rbac.Subject{teamGroup, teamUser}
+			reconcile()
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			binding.Subjects = append(binding.Subjects, adminGroup)
+			Expect(request.Client.Update(request.Context, binding)).To(Succeed())
+
+			request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects = []rbac.Subject{teamUser}
+			reconcile()
+			subjects := getSubjects()
+			Expect(subjects).ToNot(ContainElement(teamGroup))
+			Expect(subjects).To(ContainElement(teamUser))
+			Expect(subjects).To(ContainElement(adminGroup))
+
+			request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects = nil
+			reconcile()
+			binding = newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			Expect(binding.Subjects).To(Equal(append(newViewRoleBinding(GoldenImagesNSname).Subjects, adminGroup)))
+			Expect(binding.Annotations).ToNot(HaveKey(AdditionalSubjectsAnnotation))
+		})
+
+		It("should de-duplicate subjects", func() {
+			operatorSubject := newViewRoleBinding(GoldenImagesNSname).Subjects[0]
+			// The API group of groups is defaulted, so subjects without it are duplicates too
+			withoutAPIGroup := rbac.Subject{Kind: rbac.GroupKind, Name: teamGroup.Name}
+			request.Instance.Spec.CommonTemplates.AdditionalViewRoleSubjects = []rbac.Subject{operatorSubject, teamGroup, withoutAPIGroup}
+			reconcile()
+
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			binding.Subjects = append(binding.Subjects, teamGroup, operatorSubject)
+			Expect(request.Client.Update(request.Context, binding)).To(Succeed())
+
+			reconcile()
+			Expect(getSubjects()).To(Equal(append(newViewRoleBinding(GoldenImagesNSname).Subjects, teamGroup)))
+		})
+	})
+
+	Context("golden images RBAC updates", func() {
+		var counter *rbacUpdateCounter
+
+		BeforeEach(func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			counter = &rbacUpdateCounter{Client: request.Client}
+			request.Client = counter
+			request.VersionCache = common.VersionCache{}
+		})
+
+		It("should not update RBAC on second reconcile", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counter.updates).To(BeZero())
+		})
+
+		It("should not update RBAC with reordered rules and subjects", func() {
+			viewRole := newViewRole(GoldenImagesNSname)
+			ExpectResourceExists(viewRole, request)
+			viewRole.Rules[0], viewRole.Rules[1] = viewRole.Rules[1], viewRole.Rules[0]
+			verbs := viewRole.Rules[0].Verbs
+			verbs[0], verbs[len(verbs)-1] = verbs[len(verbs)-1], verbs[0]
+			Expect(request.Client.Update(request.Context, viewRole)).To(Succeed())
+
+			editRole := newEditRole()
+			ExpectResourceExists(editRole, request)
+			editRole.Rules[0], editRole.Rules[2] = editRole.Rules[2], editRole.Rules[0]
+			Expect(request.Client.Update(request.Context, editRole)).To(Succeed())
+
+			binding := newViewRoleBinding(GoldenImagesNSname)
+			ExpectResourceExists(binding, request)
+			binding.Subjects[0], binding.Subjects[1] = binding.Subjects[1], binding.Subjects[0]
+			Expect(request.Client.Update(request.Context, binding)).To(Succeed())
+
+			counter.updates = 0
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counter.updates).To(BeZero())
+		})
+
+		It("should grant access to DataSources and DataImportCrons", func() {
+			cdiRule := func(rules []rbac.PolicyRule, resource string) rbac.PolicyRule {
+				for _, rule := range rules {
+					if len(rule.APIGroups) == 1 && rule.APIGroups[0] == CdiApiGroup {
+						for _, ruleResource := range rule.Resources {
+							if ruleResource == resource {
+								return rule
+							}
+						}
+					}
+				}
+				Fail("no rule for " + resource)
+				return rbac.PolicyRule{}
+			}
+			for _, resource := range []string{"datasources", "dataimportcrons"} {
+				Expect(cdiRule(newViewRole(GoldenImagesNSname).Rules, resource).Verbs).
+					To(ConsistOf("get", "list", "watch"))
+				Expect(cdiRule(newEditRole().Rules, resource).Verbs).
+					To(ConsistOf("create", "delete", "get", "list", "patch", "update", "watch"))
+			}
+		})
+
+		It("should add new rules to roles created by older versions", func() {
+			viewRole := newViewRole(GoldenImagesNSname)
+			ExpectResourceExists(viewRole, request)
+			viewRole.Rules = viewRole.Rules[:3]
+			Expect(request.Client.Update(request.Context, viewRole)).To(Succeed())
+
+			editRole := newEditRole()
+			ExpectResourceExists(editRole, request)
+			editRole.Rules = editRole.Rules[:4]
+			Expect(request.Client.Update(request.Context, editRole)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(viewRole, request)
+			Expect(viewRole.Rules).To(Equal(newViewRole(GoldenImagesNSname).Rules))
+			ExpectResourceExists(editRole, request)
+			Expect(editRole.Rules).To(Equal(newEditRole().Rules))
+		})
+
+		It("should update changed rules", func() {
+			viewRole := newViewRole(GoldenImagesNSname)
+			ExpectResourceExists(viewRole, request)
+			viewRole.Rules[0].Verbs = []string{"get"}
+			Expect(request.Client.Update(request.Context, viewRole)).To(Succeed())
+
+			counter.updates = 0
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counter.updates).To(Equal(1))
+
+			ExpectResourceExists(viewRole, request)
+			Expect(viewRole.Rules).To(Equal(newViewRole(GoldenImagesNSname).Rules))
+		})
+	})
+
+	Context("template updates", func() {
+		var counter *templateWriteCounter
+
+		BeforeEach(func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			counter = &templateWriteCounter{Client: request.Client}
+			request.Client = counter
+			// Clear the cache, so templates are compared with the bundle
+			request.VersionCache = common.VersionCache{}
+		})
+
+		It("should add hash annotation", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Namespace = namespace
+			ExpectResourceExists(template, request)
+
+			hash, err := templateHash(&bundleLoader.Templates()[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(template.Annotations).To(HaveKeyWithValue(TemplateHashAnnotation, hash))
+		})
+
+		It("should not write unchanged templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counter.Writes()).To(BeZero())
+		})
+
+		It("should update modified template", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Namespace = namespace
+			ExpectResourceExists(template, request)
+
+			expectedParameters := template.Parameters
+			template.Parameters = nil
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+			Expect(counter.Writes()).To(Equal(int32(1)))
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counter.Writes()).To(Equal(int32(2)))
+
+			ExpectResourceExists(template, request)
+			Expect(template.Parameters).To(Equal(expectedParameters))
+		})
+
+		It("should restore removed selector labels and keep user labels", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Namespace = namespace
+			ExpectResourceExists(template, request)
+
+			var removedLabels []string
+			for key := range template.Labels {
+				if isSelectorLabel(key) {
+					removedLabels = append(removedLabels, key)
+					delete(template.Labels, key)
+				}
+			}
+			Expect(removedLabels).ToNot(BeEmpty())
+			template.Labels["user-label"] = "user-value"
+			template.Labels[TemplateOsLabelPrefix+"not-in-bundle"] = "true"
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			repairedBefore := counterValue(templatesLabelsRepaired)
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counterValue(templatesLabelsRepaired)).To(Equal(repairedBefore + 1))
+
+			updated := newTestTemplate(template.Name)
+			ExpectResourceExists(updated, request)
+			for _, key := range removedLabels {
+				Expect(updated.Labels).To(HaveKeyWithValue(key, bundleLoader.Templates()[0].Labels[key]))
+			}
+			Expect(updated.Labels).To(HaveKeyWithValue("user-label", "user-value"))
+			Expect(updated.Labels).ToNot(HaveKey(TemplateOsLabelPrefix + "not-in-bundle"))
+		})
+
+		It("should not count templates with all labels as repaired", func() {
+			repairedBefore := counterValue(templatesLabelsRepaired)
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(counterValue(templatesLabelsRepaired)).To(Equal(repairedBefore))
+		})
+
+		It("should not overwrite objects and parameters of unmanaged template", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Namespace = namespace
+			ExpectResourceExists(template, request)
+
+			template.Annotations[TemplateUnmanagedAnnotation] = "true"
+			template.Parameters = []templatev1.Parameter{{Name: "CUSTOM_PARAMETER"}}
+			template.Objects = nil
+			delete(template.Labels, TemplateVersionLabel)
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updated := newTestTemplate(template.Name)
+			ExpectResourceExists(updated, request)
+			Expect(updated.Parameters).To(Equal(template.Parameters))
+			Expect(updated.Objects).To(BeEmpty())
+			Expect(updated.Labels).To(HaveKeyWithValue(TemplateVersionLabel, Version))
+
+			var skipped []string
+			for _, status := range statuses {
+				if status.Skipped != nil {
+					skipped = append(skipped, status.Resource.GetName())
+				}
+			}
+			Expect(skipped).To(ConsistOf(template.Name))
+		})
+
+		It("should restore objects and parameters when template is managed again", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Namespace = namespace
+			ExpectResourceExists(template, request)
+			original := template.DeepCopy()
+
+			template.Annotations[TemplateUnmanagedAnnotation] = "true"
+			template.Parameters = []templatev1.Parameter{{Name: "CUSTOM_PARAMETER"}}
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(template, request)
+			delete(template.Annotations, TemplateUnmanagedAnnotation)
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				Expect(status.Skipped).To(BeNil())
+			}
+
+			updated := newTestTemplate(template.Name)
+			ExpectResourceExists(updated, request)
+			Expect(updated.Parameters).To(Equal(original.Parameters))
+			Expect(updated.Objects).To(Equal(original.Objects))
+		})
+	})
+
+	Context("bundle ConfigMap", func() {
+		const (
+			configMapNamespace = "custom-bundle-ns"
+			configMapName      = "custom-bundle"
+		)
+
+		var configMap *core.ConfigMap
+
+		degradedStatuses := func(statuses []common.ResourceStatus) []common.ResourceStatus {
+			var degraded []common.ResourceStatus
+			for _, status := range statuses {
+				if status.Degraded != nil {
+					degraded = append(degraded, status)
+				}
+			}
+			return degraded
+		}
+
+		BeforeEach(func() {
+			request.Instance.Spec.CommonTemplates.BundleConfigMap = &ssp.BundleConfigMapReference{
+				Namespace: configMapNamespace,
+				Name:      configMapName,
+			}
+			configMap = &core.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configMapName,
+					Namespace: configMapNamespace,
+				},
+				Data: map[string]string{
+					defaultBundleConfigMapKey: fmt.Sprintf(testTemplateYaml, "cm-template-1", Version) +
+						fmt.Sprintf(testTemplateYaml, "cm-template-2", Version),
+				},
+			}
+		})
+
+		It("should deploy templates from ConfigMap", func() {
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(degradedStatuses(statuses)).To(BeEmpty())
+
+			ExpectResourceExists(newTestTemplate("cm-template-1"), request)
+			ExpectResourceExists(newTestTemplate("cm-template-2"), request)
+			ExpectResourceNotExists(newTestTemplate(bundleLoader.Templates()[0].Name), request)
+		})
+
+		It("should use custom key", func() {
+			configMap.Data = map[string]string{"custom-key": configMap.Data[defaultBundleConfigMapKey]}
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+			request.Instance.Spec.CommonTemplates.BundleConfigMap.Key = "custom-key"
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(newTestTemplate("cm-template-1"), request)
+		})
+
+		It("should update templates when ConfigMap changes", func() {
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			configMap.Data[defaultBundleConfigMapKey] += "parameters:\n- name: NEW_PARAMETER\n"
+			Expect(request.Client.Update(request.Context, configMap)).To(Succeed())
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			template := newTestTemplate("cm-template-2")
+			ExpectResourceExists(template, request)
+			Expect(template.Parameters).To(HaveLen(1))
+			Expect(template.Parameters[0].Name).To(Equal("NEW_PARAMETER"))
+		})
+
+		It("should report degraded status if ConfigMap does not exist", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			degraded := degradedStatuses(statuses)
+			Expect(degraded).To(HaveLen(1))
+			Expect(*degraded[0].Degraded).To(ContainSubstring("does not exist"))
+			ExpectResourceNotExists(newTestTemplate(bundleLoader.Templates()[0].Name), request)
+		})
+
+		It("should report degraded status if ConfigMap does not contain key", func() {
+			configMap.Data = map[string]string{"other-key": ""}
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			degraded := degradedStatuses(statuses)
+			Expect(degraded).To(HaveLen(1))
+			Expect(*degraded[0].Degraded).To(ContainSubstring("does not contain key"))
+		})
+
+		It("should report degraded status if ConfigMap contains invalid bundle", func() {
+			configMap.Data[defaultBundleConfigMapKey] = "invalid: [yaml"
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			degraded := degradedStatuses(statuses)
+			Expect(degraded).To(HaveLen(1))
+			Expect(*degraded[0].Degraded).To(ContainSubstring("invalid common templates bundle"))
+			ExpectResourceNotExists(newTestTemplate("cm-template-1"), request)
+		})
+
+		It("should remove templates from ConfigMap in cleanup", func() {
+			Expect(request.Client.Create(request.Context, configMap)).To(Succeed())
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+			ExpectResourceNotExists(newTestTemplate("cm-template-1"), request)
+			ExpectResourceNotExists(newTestTemplate("cm-template-2"), request)
+		})
+	})
+
+	Context("templates metrics", func() {
+		It("should count reconciled templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(gaugeValue(templatesTotal)).To(BeEquivalentTo(len(bundleLoader.Templates())))
+			Expect(gaugeValue(templatesFailed)).To(BeZero())
+		})
+
+		It("should count failed templates", func() {
+			templates := bundleLoader.Templates()
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names: map[string]struct{}{
+					templates[0].Name: {},
+					templates[1].Name: {},
+				},
+			}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+
+			Expect(gaugeValue(templatesTotal)).To(BeEquivalentTo(len(templates)))
+			Expect(gaugeValue(templatesFailed)).To(BeEquivalentTo(2))
+		})
+	})
+
+	Context("templates status", func() {
+		commonTemplatesStatus := func(statuses []common.ResourceStatus) *ssp.CommonTemplatesStatus {
+			var result *ssp.CommonTemplatesStatus
+			for _, status := range statuses {
+				if status.CommonTemplates != nil {
+					Expect(result).To(BeNil(), "only one status should contain the summary")
+					result = status.CommonTemplates
+				}
+			}
+			return result
+		}
+
+		upgradeSummary := func(statuses []common.ResourceStatus) *ssp.UpgradeSummary {
+			for _, status := range statuses {
+				if status.CommonTemplates != nil {
+					return status.Upgrade
+				}
+			}
+			return nil
+		}
+
+		It("should report deployed templates", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(commonTemplatesStatus(statuses)).To(Equal(&ssp.CommonTemplatesStatus{
+				Version:           Version,
+				Namespace:         namespace,
+				DeployedTemplates: len(bundleLoader.Templates()),
+			}))
+		})
+
+		It("should report deprecated templates", func() {
+			oldTemplate := newTestTemplate("old-template")
+			oldTemplate.Labels = map[string]string{
+				TemplateVersionLabel:               "not-latest",
+				TemplateTypeLabel:                  "base",
+				common.AppKubernetesNameLabel:      operandName,
+				common.AppKubernetesManagedByLabel: "ssp-operator",
+			}
+			Expect(request.Client.Create(request.Context, oldTemplate)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			summary := commonTemplatesStatus(statuses)
+			Expect(summary.DeprecatedTemplates).To(Equal(1))
+			Expect(summary.DeployedTemplates).To(Equal(len(bundleLoader.Templates())))
+		})
+
+		It("should report templates changed by an upgrade", func() {
+			oldTemplate := newTestTemplate("old-template")
+			oldTemplate.Labels = map[string]string{
+				TemplateVersionLabel:               "not-latest",
+				TemplateTypeLabel:                  "base",
+				common.AppKubernetesNameLabel:      operandName,
+				common.AppKubernetesManagedByLabel: "ssp-operator",
+			}
+			Expect(request.Client.Create(request.Context, oldTemplate)).To(Succeed())
+
+			templates := bundleLoader.Templates()
+			previousTemplate := templates[0].DeepCopy()
+			previousTemplate.Namespace = namespace
+			previousTemplate.Labels[TemplateVersionLabel] = "not-latest"
+			Expect(request.Client.Create(request.Context, previousTemplate)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(upgradeSummary(statuses)).To(Equal(&ssp.UpgradeSummary{
+				AddedTemplates:      len(templates) - 1,
+				UpdatedTemplates:    1,
+				DeprecatedTemplates: 1,
+			}))
+
+			// Changes are only reported once
+			request.VersionCache = common.VersionCache{}
+			statuses, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(upgradeSummary(statuses)).To(Equal(&ssp.UpgradeSummary{}))
+		})
+
+		It("should report failed templates with the first error", func() {
+			templates := bundleLoader.Templates()
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names: map[string]struct{}{
+					templates[0].Name: {},
+					templates[1].Name: {},
+				},
+			}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+
+			summary := commonTemplatesStatus(statuses)
+			Expect(summary.DeployedTemplates).To(Equal(len(templates) - 2))
+			Expect(summary.FailedTemplates).To(Equal(2))
+			Expect(summary.FirstError).To(ContainSubstring(templates[0].Name))
+		})
+
+		It("should reference failed templates in their statuses", func() {
+			templates := bundleLoader.Templates()
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names:  map[string]struct{}{templates[0].Name: {}},
+			}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+
+			summary := commonTemplatesStatus(statuses)
+			Expect(summary.FailedTemplateNames).To(Equal([]string{templates[0].Name}))
+
+			status := common.FailedResourceStatus(newTestTemplate(templates[0].Name), errors.New("test error"))
+			Expect(status.ObjectRef.Name).To(Equal(templates[0].Name))
+			Expect(status.ObjectRef.Namespace).To(Equal(namespace))
+			Expect(*status.Error).To(Equal("test error"))
+		})
+
+		It("should report failed templates as degraded", func() {
+			templates := bundleLoader.Templates()
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names:  map[string]struct{}{templates[0].Name: {}},
+			}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+			summaryStatus := statuses[len(statuses)-1]
+			Expect(summaryStatus.CommonTemplates).ToNot(BeNil())
+			Expect(summaryStatus.Degraded).ToNot(BeNil())
+			Expect(*summaryStatus.Degraded).To(Equal("Failed to reconcile 1 templates: " + templates[0].Name))
+		})
+
+		It("should keep golden images statuses when templates fail", func() {
+			templates := bundleLoader.Templates()
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names:  map[string]struct{}{templates[0].Name: {}},
+			}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+
+			var resources []string
+			for _, status := range statuses {
+				if status.Resource != nil {
+					resources = append(resources, status.Resource.GetName())
+				}
+			}
+			Expect(resources).To(ContainElement(GoldenImagesNSname))
+			Expect(resources).To(ContainElement(ViewRoleName))
+		})
+
+		It("should not report degraded when all templates are reconciled", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				Expect(status.Degraded).To(BeNil())
+			}
+		})
+
+		It("should list at most the maximum number of failed templates", func() {
+			templates := bundleLoader.Templates()
+			Expect(len(templates)).To(BeNumerically(">", maxFailedTemplateNames+2))
+			names := map[string]struct{}{}
+			for _, template := range templates[:maxFailedTemplateNames+2] {
+				names[template.Name] = struct{}{}
+			}
+			request.Client = &failingTemplateClient{Client: request.Client, names: names}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+
+			summary := commonTemplatesStatus(statuses)
+			Expect(summary.FailedTemplates).To(Equal(maxFailedTemplateNames + 2))
+			Expect(summary.FailedTemplateNames).To(HaveLen(maxFailedTemplateNames + 1))
+			Expect(summary.FailedTemplateNames[maxFailedTemplateNames]).To(Equal("+2 more"))
+			for _, name := range summary.FailedTemplateNames[:maxFailedTemplateNames] {
+				Expect(names).To(HaveKey(name))
+			}
+		})
+	})
+
+	Context("validating templates", func() {
+		newValidTemplate := func(name string) templatev1.Template {
+			return templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+					Labels: map[string]string{
+						TemplateTypeLabel:    "base",
+						TemplateVersionLabel: Version,
+						testOsLabel:          "true",
+						testFlavorLabel:      "true",
+						testWorkflowLabel:    "true",
+					},
+				},
+				Objects: []runtime.RawExtension{{
+					Raw: []byte(`{"kind":"VirtualMachine","metadata":{"name":"${NAME}"}}`),
+				}},
+				Parameters: []templatev1.Parameter{{Name: "NAME"}, {Name: "PVCNAME"}},
+			}
+		}
+
+		It("should accept valid templates", func() {
+			Expect(ValidateTemplates([]templatev1.Template{
+				newValidTemplate("template-1"),
+				newValidTemplate("template-2"),
+			})).To(Succeed())
+		})
+
+		It("should list all malformed templates", func() {
+			withoutName := newValidTemplate("")
+
+			brokenObject := newValidTemplate("broken-object")
+			brokenObject.Objects = append(brokenObject.Objects, runtime.RawExtension{Raw: []byte(`"not an object"`)})
+
+			duplicateParameters := newValidTemplate("duplicate-parameters")
+			duplicateParameters.Parameters = append(duplicateParameters.Parameters, templatev1.Parameter{Name: "NAME"})
+
+			withoutVersion := newValidTemplate("without-version")
+			delete(withoutVersion.Labels, TemplateVersionLabel)
+
+			err := ValidateTemplates([]templatev1.Template{
+				newValidTemplate("valid-template"),
+				withoutName,
+				brokenObject,
+				duplicateParameters,
+				withoutVersion,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("4 malformed templates"))
+			Expect(err.Error()).To(ContainSubstring("template at index 1 has no name"))
+			Expect(err.Error()).To(ContainSubstring("template broken-object has object 1 that cannot be decoded"))
+			Expect(err.Error()).To(ContainSubstring("template duplicate-parameters has duplicate parameters: NAME"))
+			Expect(err.Error()).To(ContainSubstring("template without-version is missing labels: " + TemplateVersionLabel))
+			Expect(err.Error()).ToNot(ContainSubstring("valid-template"))
+		})
+	})
+
+	Context("template parameters", func() {
+		newTemplateWithParameters := func(names ...string) *templatev1.Template {
+			template := &templatev1.Template{ObjectMeta: metav1.ObjectMeta{Name: "test-template"}}
+			for _, name := range names {
+				template.Parameters = append(template.Parameters, templatev1.Parameter{Name: name})
+			}
+			return template
+		}
+
+		DescribeTable("should accept valid parameter names", func(names ...string) {
+			warnings, err := validateTemplateParameters(newTemplateWithParameters(names...))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		},
+			Entry("no parameters"),
+			Entry("uppercase names", "NAME", "SRC_PVC_NAME", "CLOUD_USER_PASSWORD"),
+			Entry("names with digits", "DISK_1", "V2"),
+		)
+
+		DescribeTable("should reject parameter names failing template processing", func(expectedError string, names ...string) {
+			_, err := validateTemplateParameters(newTemplateWithParameters(names...))
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+			Entry("reserved name", `parameter name "OBJECTS" is reserved`, "NAME", "OBJECTS"),
+			Entry("reserved name in lowercase", `parameter name "labels" is reserved`, "labels"),
+			Entry("invalid characters", `parameter name "SRC-PVC" contains invalid characters`, "SRC-PVC"),
+			Entry("empty name", `parameter name "" contains invalid characters`, ""),
+			Entry("duplicate name", `parameter "NAME" is defined multiple times`, "NAME", "NAME"),
+		)
+
+		It("should warn about names not following the convention", func() {
+			warnings, err := validateTemplateParameters(newTemplateWithParameters("NAME", "pvcName", "_PVC"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(HaveLen(2))
+			Expect(warnings[0]).To(ContainSubstring("pvcName"))
+			Expect(warnings[1]).To(ContainSubstring("_PVC"))
+		})
+
+		It("should report degraded status and not create template with reserved parameter", func() {
+			template := bundleLoader.Templates()[0].DeepCopy()
+			template.Parameters = append(template.Parameters, templatev1.Parameter{Name: "MESSAGE"})
+
+			funcs := operand.(*commonTemplates).reconcileTemplatesFuncs(&request, &templatesSummary{}, []templatev1.Template{*template}, &vmDefaults{})
+			Expect(funcs).To(HaveLen(1))
+			status, err := funcs[0](&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(*status.Degraded).To(ContainSubstring(`parameter name "MESSAGE" is reserved`))
+			ExpectResourceNotExists(newTestTemplate(template.Name), request)
+		})
+
+		Context("preserved parameter defaults", func() {
+			It("should keep value and generator of parameters in both versions", func() {
+				bundle := []templatev1.Parameter{
+					{Name: "NAME", Generate: "expression", From: "vm-[a-z]{8}", Required: true},
+					{Name: "NETWORK", Value: "pod", Description: "new description"},
+				}
+				found := []templatev1.Parameter{
+					{Name: "NAME", Value: "admin-name", Description: "old description"},
+					{Name: "NETWORK", Value: "bridge", Required: true},
+				}
+				Expect(mergeParameterDefaults(bundle, found)).To(Equal([]templatev1.Parameter{
+					{Name: "NAME", Value: "admin-name", Required: true},
+					{Name: "NETWORK", Value: "bridge", Description: "new description"},
+				}))
+			})
+
+			It("should add parameters new in the bundle", func() {
+				bundle := []templatev1.Parameter{{Name: "NAME"}, {Name: "DISK", Value: "10Gi"}}
+				found := []templatev1.Parameter{{Name: "NAME", Value: "admin-name"}}
+				Expect(mergeParameterDefaults(bundle, found)).To(Equal([]templatev1.Parameter{
+					{Name: "NAME", Value: "admin-name"},
+					{Name: "DISK", Value: "10Gi"},
+				}))
+			})
+
+			It("should remove parameters dropped from the bundle", func() {
+				bundle := []templatev1.Parameter{{Name: "NAME"}}
+				found := []templatev1.Parameter{{Name: "NAME", Value: "admin-name"}, {Name: "REMOVED", Value: "value"}}
+				Expect(mergeParameterDefaults(bundle, found)).To(Equal([]templatev1.Parameter{
+					{Name: "NAME", Value: "admin-name"},
+				}))
+			})
+
+			It("should use bundle parameters if the cluster template has none", func() {
+				bundle := []templatev1.Parameter{{Name: "NAME", Value: "default"}}
+				Expect(mergeParameterDefaults(bundle, nil)).To(Equal(bundle))
+				Expect(mergeParameterDefaults(nil, bundle)).To(BeEmpty())
+			})
+
+			Context("reconcile", func() {
+				var template *templatev1.Template
+
+				BeforeEach(func() {
+					_, err := operand.Reconcile(&request)
+					Expect(err).ToNot(HaveOccurred())
+
+					template = newTestTemplate(bundleLoader.Templates()[0].Name)
+					ExpectResourceExists(template, request)
+					Expect(template.Parameters).ToNot(BeEmpty())
+					template.Parameters[0].Value = "admin-value"
+					template.Parameters[0].Generate = ""
+					Expect(request.Client.Update(request.Context, template)).To(Succeed())
+				})
+
+				It("should keep parameter value changed by admin", func() {
+					request.Instance.Spec.CommonTemplates.PreserveParameterDefaults = true
+					_, err := operand.Reconcile(&request)
+					Expect(err).ToNot(HaveOccurred())
+
+					updated := newTestTemplate(template.Name)
+					ExpectResourceExists(updated, request)
+					Expect(updated.Parameters).To(Equal(template.Parameters))
+				})
+
+				It("should restore parameter value if not enabled", func() {
+					_, err := operand.Reconcile(&request)
+					Expect(err).ToNot(HaveOccurred())
+
+					updated := newTestTemplate(template.Name)
+					ExpectResourceExists(updated, request)
+					Expect(updated.Parameters[0].Value).ToNot(Equal("admin-value"))
+				})
+			})
+		})
+
+		Context("maximum parameter count", func() {
+			var underLimit, overLimit *templatev1.Template
+
+			BeforeEach(func() {
+				underLimit = newTemplateWithParameters("NAME")
+				underLimit.Name = "under-limit"
+				overLimit = newTemplateWithParameters("NAME", "SRC_PVC_NAME", "SRC_PVC_NAMESPACE")
+				overLimit.Name = "over-limit"
+
+				maxParameters := int32(2)
+				request.Instance.Spec.CommonTemplates.MaxTemplateParameters = &maxParameters
+			})
+
+			It("should not limit parameters if maximum is not set", func() {
+				request.Instance.Spec.CommonTemplates.MaxTemplateParameters = nil
+				deployed, excluded, status := checkParameterLimit(&request, []templatev1.Template{*underLimit, *overLimit})
+				Expect(deployed).To(HaveLen(2))
+				Expect(excluded).To(BeEmpty())
+				Expect(status).To(BeNil())
+			})
+
+			It("should not report templates under the limit", func() {
+				deployed, excluded, status := checkParameterLimit(&request, []templatev1.Template{*underLimit})
+				Expect(deployed).To(HaveLen(1))
+				Expect(excluded).To(BeEmpty())
+				Expect(status).To(BeNil())
+			})
+
+			It("should report templates over the limit and deploy them", func() {
+				deployed, excluded, status := checkParameterLimit(&request, []templatev1.Template{*underLimit, *overLimit})
+				Expect(deployed).To(HaveLen(2))
+				Expect(excluded).To(BeEmpty())
+				Expect(status).ToNot(BeNil())
+				Expect(status.Degraded).ToNot(BeNil())
+				Expect(*status.Degraded).To(ContainSubstring("over-limit (3)"))
+				Expect(*status.Degraded).ToNot(ContainSubstring("under-limit"))
+			})
+
+			It("should exclude templates over the limit in strict mode", func() {
+				request.Instance.Spec.CommonTemplates.StrictMaxTemplateParameters = true
+				deployed, excluded, status := checkParameterLimit(&request, []templatev1.Template{*underLimit, *overLimit})
+				Expect(deployed).To(HaveLen(1))
+				Expect(deployed[0].Name).To(Equal("under-limit"))
+				Expect(excluded).To(HaveLen(1))
+				Expect(excluded[0].Name).To(Equal("over-limit"))
+				Expect(status).ToNot(BeNil())
+				Expect(*status.Degraded).To(ContainSubstring("over-limit (3)"))
+				Expect(*status.Degraded).To(ContainSubstring("not deployed"))
+			})
+
+			It("should not deploy bundle templates over the limit in strict mode", func() {
+				maxParameters := int32(1)
+				request.Instance.Spec.CommonTemplates.MaxTemplateParameters = &maxParameters
+				request.Instance.Spec.CommonTemplates.StrictMaxTemplateParameters = true
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []string
+				for _, status := range statuses {
+					if status.Degraded != nil && status.Resource == request.Instance {
+						degraded = append(degraded, *status.Degraded)
+					}
+				}
+				Expect(degraded).To(ContainElement(ContainSubstring("not deployed")))
+
+				for _, template := range bundleLoader.Templates() {
+					if len(template.Parameters) > 1 {
+						ExpectResourceNotExists(newTestTemplate(template.Name), request)
+					} else {
+						ExpectResourceExists(newTestTemplate(template.Name), request)
+					}
+				}
+			})
+		})
+	})
+
+	Context("additional bundles", func() {
+		var bundleDir string
+
+		writeBundle := func(filename, version string, names ...string) string {
+			content := ""
+			for _, name := range names {
+				content += fmt.Sprintf(testTemplateYaml, name, version)
+			}
+			path := filepath.Join(bundleDir, filename)
+			ExpectWithOffset(1, ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			return path
+		}
+
+		BeforeEach(func() {
+			var err error
+			bundleDir, err = ioutil.TempDir("", "additional-bundles")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(bundleDir)).To(Succeed())
+		})
+
+		It("should deploy templates from additional bundle file", func() {
+			bundleFile := writeBundle("custom.yaml", Version, "custom-template-1", "custom-template-2")
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{bundleFile}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate("custom-template-1"), request)
+			ExpectResourceExists(newTestTemplate("custom-template-2"), request)
+			ExpectResourceExists(newTestTemplate(bundleLoader.Templates()[0].Name), request)
+		})
+
+		It("should deploy templates from bundle files in directory", func() {
+			writeBundle("first.yaml", Version, "custom-template-1")
+			writeBundle("second.json", Version, "custom-template-2")
+			writeBundle("ignored.txt", Version, "ignored-template")
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{bundleDir}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate("custom-template-1"), request)
+			ExpectResourceExists(newTestTemplate("custom-template-2"), request)
+			ExpectResourceNotExists(newTestTemplate("ignored-template"), request)
+		})
+
+		It("should fail if additional bundle does not exist", func() {
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{filepath.Join(bundleDir, "missing.yaml")}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).To(MatchError(ContainSubstring("missing.yaml")))
+		})
+
+		It("should fail on template names duplicated across bundles", func() {
+			mainTemplateName := bundleLoader.Templates()[0].Name
+			first := writeBundle("first.yaml", Version, "custom-template", mainTemplateName)
+			second := writeBundle("second.yaml", Version, "custom-template")
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{first, second}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("template %s is defined in main bundle and %s", mainTemplateName, first)))
+			Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("template custom-template is defined in %s and %s", first, second)))
+			ExpectResourceNotExists(newTestTemplate("custom-template"), request)
+		})
+
+		It("should not deprecate templates from additional bundles with other version", func() {
+			bundleFile := writeBundle("custom.yaml", "custom-v1", "custom-template")
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{bundleFile}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			template := newTestTemplate("custom-template")
+			ExpectResourceExists(template, request)
+			Expect(template.Annotations).ToNot(HaveKey(TemplateDeprecatedAnnotation))
+		})
+
+		It("should remove templates from additional bundles in cleanup", func() {
+			bundleFile := writeBundle("custom.yaml", Version, "custom-template")
+			request.Instance.Spec.CommonTemplates.AdditionalBundles = []string{bundleFile}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+			ExpectResourceNotExists(newTestTemplate("custom-template"), request)
+		})
+	})
+
+	Context("disabling", func() {
+		disabled := false
+
+		BeforeEach(func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			request.VersionCache = common.VersionCache{}
+		})
+
+		It("should remove templates when templates are disabled", func() {
+			request.Instance.Spec.CommonTemplates.Enabled = &disabled
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			templates := &templatev1.TemplateList{}
+			Expect(request.Client.List(request.Context, templates)).To(Succeed())
+			Expect(templates.Items).To(BeEmpty())
+
+			var summary *ssp.CommonTemplatesStatus
+			for _, status := range statuses {
+				if status.CommonTemplates != nil {
+					summary = status.CommonTemplates
+				}
+			}
+			Expect(summary).To(Equal(&ssp.CommonTemplatesStatus{}))
+
+			// Golden images RBAC is still reconciled
+			ExpectResourceExists(newViewRole(goldenImagesNamespace(&request)), request)
+			ExpectResourceExists(newEditRole(), request)
+		})
+
+		It("should deploy templates again when templates are enabled", func() {
+			request.Instance.Spec.CommonTemplates.Enabled = &disabled
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Instance.Spec.CommonTemplates.Enabled = nil
+			request.VersionCache = common.VersionCache{}
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceExists(newTestTemplate(template.Name), request)
+			}
+		})
+
+		It("should remove golden images RBAC and keep namespace when golden images are disabled", func() {
+			request.Instance.Spec.CommonTemplates.GoldenImagesEnabled = &disabled
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			goldenImagesNS := goldenImagesNamespace(&request)
+			ExpectResourceNotExists(newViewRole(goldenImagesNS), request)
+			ExpectResourceNotExists(newViewRoleBinding(goldenImagesNS), request)
+			ExpectResourceNotExists(newEditRole(), request)
+			ExpectResourceExists(newGoldenImagesNS(goldenImagesNS), request)
+
+			// Templates are still deployed
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceExists(newTestTemplate(template.Name), request)
+			}
+		})
+	})
+
+	Context("cleanup", func() {
+		newOwnedTemplate := func(name, version string) *templatev1.Template {
+			return &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel:               version,
+						TemplateTypeLabel:                  "base",
+						common.AppKubernetesNameLabel:      operandName,
+						common.AppKubernetesManagedByLabel: "ssp-operator",
+					},
+				},
+			}
+		}
+
+		It("should remove current and older templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			oldTemplate := newOwnedTemplate("old-template", "not-latest")
+			Expect(request.Client.Create(request.Context, oldTemplate)).To(Succeed())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+
+			templates := &templatev1.TemplateList{}
+			Expect(request.Client.List(request.Context, templates)).To(Succeed())
+			Expect(templates.Items).To(BeEmpty())
+		})
+
+		It("should not remove user templates", func() {
+			userTemplate := newOwnedTemplate("user-template", Version)
+			userTemplate.Labels = map[string]string{
+				TemplateVersionLabel: Version,
+				TemplateTypeLabel:    "base",
+			}
+			Expect(request.Client.Create(request.Context, userTemplate)).To(Succeed())
+			otherNamespaceTemplate := newOwnedTemplate("other-namespace-template", Version)
+			otherNamespaceTemplate.Namespace = "other-namespace"
+			Expect(request.Client.Create(request.Context, otherNamespaceTemplate)).To(Succeed())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+
+			ExpectResourceExists(userTemplate, request)
+			ExpectResourceExists(otherNamespaceTemplate, request)
+		})
+
+		It("should succeed if Template API does not exist", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Client = &noTemplatesAPIClient{Client: request.Client}
+			Expect(operand.Cleanup(&request)).To(Succeed())
+
+			ExpectResourceNotExists(newViewRole(goldenImagesNamespace(&request)), request)
+			ExpectResourceNotExists(newEditRole(), request)
+		})
+	})
+
+	Context("templates namespace migration", func() {
+		const newNamespace = "new-templates-namespace"
+
+		// updateSummary copies the summary to the SSP status, like the controller does
+		updateSummary := func(statuses []common.ResourceStatus) {
+			for _, status := range statuses {
+				if status.CommonTemplates != nil {
+					request.Instance.Status.CommonTemplates = status.CommonTemplates
+				}
+			}
+		}
+
+		reconcile := func() error {
+			// The controller clears the version cache when the spec changes
+			request.VersionCache = common.VersionCache{}
+			statuses, err := operand.Reconcile(&request)
+			updateSummary(statuses)
+			return err
+		}
+
+		newTemplateInNamespace := func(name, ns string, labels map[string]string) *templatev1.Template {
+			return &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns,
+					Labels:    labels,
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			Expect(reconcile()).To(Succeed())
+			Expect(request.Instance.Status.CommonTemplates.Namespace).To(Equal(namespace))
+
+			newTemplatesNamespace := &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: newNamespace}}
+			Expect(request.Client.Create(request.Context, newTemplatesNamespace)).To(Succeed())
+			request.Instance.Spec.CommonTemplates.Namespace = newNamespace
+		})
+
+		It("should move templates to the new namespace", func() {
+			Expect(reconcile()).To(Succeed())
+
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceExists(newTemplateInNamespace(template.Name, newNamespace, nil), request)
+				ExpectResourceNotExists(newTemplateInNamespace(template.Name, namespace, nil), request)
+			}
+			Expect(request.Instance.Status.CommonTemplates.Namespace).To(Equal(newNamespace))
+		})
+
+		It("should not delete user templates from the previous namespace", func() {
+			userTemplate := newTemplateInNamespace("user-template", namespace, map[string]string{
+				TemplateTypeLabel: "base",
+			})
+			Expect(request.Client.Create(request.Context, userTemplate)).To(Succeed())
+
+			otherOwnerTemplate := newTemplateInNamespace("other-owner-template", namespace, map[string]string{
+				common.AppKubernetesNameLabel:      operandName,
+				common.AppKubernetesManagedByLabel: "ssp-operator",
+			})
+			otherOwnerTemplate.Annotations = map[string]string{
+				libhandler.NamespacedNameAnnotation: "other-namespace/other-ssp",
+			}
+			Expect(request.Client.Create(request.Context, otherOwnerTemplate)).To(Succeed())
+
+			Expect(reconcile()).To(Succeed())
+
+			ExpectResourceExists(userTemplate, request)
+			ExpectResourceExists(otherOwnerTemplate, request)
+		})
+
+		It("should resume migration after failed deletion", func() {
+			fakeClient := request.Client
+			request.Client = &failingTemplateDeleteClient{Client: fakeClient, namespace: namespace}
+			Expect(reconcile()).ToNot(Succeed())
+			Expect(request.Instance.Status.CommonTemplates.Namespace).To(Equal(namespace))
+
+			request.Client = fakeClient
+			Expect(reconcile()).To(Succeed())
+
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceNotExists(newTemplateInNamespace(template.Name, namespace, nil), request)
+			}
+			Expect(request.Instance.Status.CommonTemplates.Namespace).To(Equal(newNamespace))
+		})
+
+		It("should not delete templates from the previous namespace if templates failed to deploy", func() {
+			failingName := bundleLoader.Templates()[0].Name
+			request.Client = &failingTemplateClient{
+				Client: request.Client,
+				names:  map[string]struct{}{failingName: {}},
+			}
+			Expect(reconcile()).ToNot(Succeed())
+
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceExists(newTemplateInNamespace(template.Name, namespace, nil), request)
+			}
+			Expect(request.Instance.Status.CommonTemplates.Namespace).To(Equal(namespace))
+		})
+
+		It("should remove templates from the previous namespace on cleanup", func() {
+			Expect(operand.Cleanup(&request)).To(Succeed())
+
+			templates := &templatev1.TemplateList{}
+			Expect(request.Client.List(request.Context, templates)).To(Succeed())
+			Expect(templates.Items).To(BeEmpty())
+		})
+	})
+
+	Context("concurrent reconciliation", func() {
+		const otherNamespace = "other-templates-namespace"
+
+		var otherRequest common.Request
+
+		BeforeEach(func() {
+			otherRequest = request
+			otherRequest.Client = fake.NewFakeClientWithScheme(request.Client.Scheme())
+			otherRequest.Instance = request.Instance.DeepCopy()
+			otherRequest.Instance.Spec.CommonTemplates.Namespace = otherNamespace
+			otherRequest.VersionCache = common.VersionCache{}
+
+			templatesNamespace := &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherNamespace}}
+			Expect(otherRequest.Client.Create(otherRequest.Context, templatesNamespace)).To(Succeed())
+		})
+
+		expectTemplatesInNamespace := func(request common.Request, templatesNamespace string) {
+			for _, bundleTemplate := range bundleLoader.Templates() {
+				template := &templatev1.Template{}
+				key := client.ObjectKey{Name: bundleTemplate.Name, Namespace: templatesNamespace}
+				ExpectWithOffset(1, request.Client.Get(request.Context, key, template)).To(Succeed())
+			}
+			templates := &templatev1.TemplateList{}
+			ExpectWithOffset(1, request.Client.List(request.Context, templates)).To(Succeed())
+			for _, template := range templates.Items {
+				ExpectWithOffset(1, template.Namespace).To(Equal(templatesNamespace))
+			}
+		}
+
+		It("should not mix desired objects of reconciles with different namespaces", func() {
+			wg := sync.WaitGroup{}
+			errs := make([]error, 2)
+			for i, req := range []*common.Request{&request, &otherRequest} {
+				wg.Add(1)
+				go func(i int, req *common.Request) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, errs[i] = operand.Reconcile(req)
+				}(i, req)
+			}
+			wg.Wait()
+			Expect(errs).To(Equal([]error{nil, nil}))
+
+			expectTemplatesInNamespace(request, namespace)
+			expectTemplatesInNamespace(otherRequest, otherNamespace)
+		})
+
+		It("should not modify loaded bundle in cleanup", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = operand.Reconcile(&otherRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&otherRequest)).To(Succeed())
+			for _, template := range bundleLoader.Templates() {
+				Expect(template.Namespace).To(BeEmpty())
+			}
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			expectTemplatesInNamespace(request, namespace)
+		})
+	})
+
+	Context("template recreation", func() {
+		var recorder *record.FakeRecorder
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(100)
+			request.Recorder = recorder
+			request.Client = &uidSettingClient{Client: request.Client}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not emit event when templates are not recreated", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		It("should emit event when deleted template is recreated", func() {
+			template := newTestTemplate(bundleLoader.Templates()[0].Name)
+			ExpectResourceExists(template, request)
+			oldUID := template.UID
+			Expect(request.Client.Delete(request.Context, template)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			recreated := newTestTemplate(template.Name)
+			ExpectResourceExists(recreated, request)
+			Expect(recreated.UID).ToNot(Equal(oldUID))
+
+			var event string
+			Expect(recorder.Events).To(Receive(&event))
+			Expect(event).To(ContainSubstring(TemplateRecreatedReason))
+			Expect(event).To(ContainSubstring(template.Name))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+
+	Context("out-of-band template modification", func() {
+		var (
+			recorder *record.FakeRecorder
+			template *templatev1.Template
+		)
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(100)
+			request.Recorder = recorder
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = newTestTemplate(bundleLoader.Templates()[0].Name)
+			ExpectResourceExists(template, request)
+		})
+
+		restoredCount := func() float64 {
+			return counterValue(templatesRestored.WithLabelValues(templateWorkload(template)))
+		}
+
+		It("should label restorations by template workload", func() {
+			Expect(templateWorkload(&templatev1.Template{})).To(Equal(noWorkload))
+			Expect(templateWorkload(&templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					TemplateWorkloadLabelPrefix + "server":  "true",
+					TemplateWorkloadLabelPrefix + "desktop": "true",
+					TemplateWorkloadLabelPrefix + "other":   "false",
+					TemplateOsLabelPrefix + "fedora":        "true",
+				}},
+			})).To(Equal("desktop"))
+		})
+
+		It("should not report restoration when nothing changed", func() {
+			before := restoredCount()
+
+			// The cache is cleared, so the template content is compared
+			request.VersionCache = common.VersionCache{}
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(restoredCount()).To(Equal(before))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		It("should restore modified parameters and report it", func() {
+			Expect(template.Parameters).ToNot(BeEmpty())
+			expectedParameters := template.Parameters
+			template.Parameters = append([]templatev1.Parameter{{Name: "INJECTED"}}, template.Parameters...)
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			before := restoredCount()
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			restoredTemplate := newTestTemplate(template.Name)
+			ExpectResourceExists(restoredTemplate, request)
+			Expect(restoredTemplate.Parameters).To(Equal(expectedParameters))
+
+			Expect(restoredCount()).To(Equal(before + 1))
+			var event string
+			Expect(recorder.Events).To(Receive(&event))
+			Expect(event).To(ContainSubstring(TemplateRestoredReason))
+			Expect(event).To(ContainSubstring(template.Name + " was modified and restored"))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		It("should restore modified objects and report it", func() {
+			template.Objects = []runtime.RawExtension{{Raw: []byte(`{"kind":"ConfigMap"}`)}}
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			before := restoredCount()
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(restoredCount()).To(Equal(before + 1))
+			Expect(recorder.Events).To(Receive())
+		})
+
+		It("should not report restoration when only labels were modified", func() {
+			template.Labels["user-label"] = "user-value"
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			before := restoredCount()
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(restoredCount()).To(Equal(before))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		It("should not report restoration when the bundle content changed", func() {
+			template.Annotations[TemplateHashAnnotation] = "previous-bundle-hash"
+			template.Parameters = nil
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			before := restoredCount()
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(restoredCount()).To(Equal(before))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+
+	Context("golden images namespace metadata", func() {
+		const (
+			psaLabel         = "pod-security.kubernetes.io/enforce"
+			costAnnotation   = "example.com/cost-center"
+			userLabel        = "example.com/team"
+			userAnnotation   = "example.com/contact"
+			reconciledPolicy = "restricted"
+		)
+
+		BeforeEach(func() {
+			request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceLabels = map[string]string{
+				psaLabel: reconciledPolicy,
+			}
+			request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceAnnotations = map[string]string{
+				costAnnotation: "1234",
+			}
+		})
+
+		It("should set labels and annotations to the namespace", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			namespace := newGoldenImagesNS(GoldenImagesNSname)
+			ExpectResourceExists(namespace, request)
+			Expect(namespace.Labels).To(HaveKeyWithValue(psaLabel, reconciledPolicy))
+			Expect(namespace.Labels).To(HaveKeyWithValue(common.AppKubernetesNameLabel, operandName))
+			Expect(namespace.Annotations).To(HaveKeyWithValue(costAnnotation, "1234"))
+		})
+
+		It("should not modify the SSP spec", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceLabels).To(Equal(map[string]string{
+				psaLabel: reconciledPolicy,
+			}))
+		})
+
+		It("should not overwrite operator labels", func() {
+			request.Instance.Spec.CommonTemplates.GoldenImagesNamespaceLabels[common.AppKubernetesNameLabel] = "other"
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			namespace := newGoldenImagesNS(GoldenImagesNSname)
+			ExpectResourceExists(namespace, request)
+			Expect(namespace.Labels).To(HaveKeyWithValue(common.AppKubernetesNameLabel, operandName))
+		})
+
+		It("should restore configured keys changed by user and keep user keys", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			namespace := newGoldenImagesNS(GoldenImagesNSname)
+			ExpectResourceExists(namespace, request)
+			namespace.Labels[psaLabel] = "privileged"
+			namespace.Labels[userLabel] = "storage"
+			namespace.Annotations[costAnnotation] = "changed"
+			namespace.Annotations[userAnnotation] = "admin@example.com"
+			Expect(request.Client.Update(request.Context, namespace)).To(Succeed())
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updated := newGoldenImagesNS(GoldenImagesNSname)
+			ExpectResourceExists(updated, request)
+			Expect(updated.Labels).To(HaveKeyWithValue(psaLabel, reconciledPolicy))
+			Expect(updated.Labels).To(HaveKeyWithValue(userLabel, "storage"))
+			Expect(updated.Annotations).To(HaveKeyWithValue(costAnnotation, "1234"))
+			Expect(updated.Annotations).To(HaveKeyWithValue(userAnnotation, "admin@example.com"))
+		})
+	})
+
+	Context("custom golden images namespace", func() {
+		const customNamespace = "custom-os-images"
+
+		BeforeEach(func() {
+			request.Instance.Spec.CommonTemplates.GoldenImagesNamespace = customNamespace
+		})
+
+		It("should create golden images namespace and RBAC", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newGoldenImagesNS(customNamespace), request)
+			ExpectResourceExists(newViewRole(customNamespace), request)
+			ExpectResourceExists(newViewRoleBinding(customNamespace), request)
+			ExpectResourceNotExists(newGoldenImagesNS(GoldenImagesNSname), request)
+		})
+
+		It("should set source PVC namespace in templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, bundleTemplate := range bundleLoader.Templates() {
+				template := newTestTemplate(bundleTemplate.Name)
+				ExpectResourceExists(template, request)
+				for _, param := range template.Parameters {
+					if param.Name == sourcePVCNamespaceParameter {
+						Expect(param.Value).To(Equal(customNamespace))
+					}
+				}
+			}
+		})
+
+		It("should remove resources in cleanup", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&request)).To(Succeed())
+			ExpectResourceNotExists(newGoldenImagesNS(customNamespace), request)
+			ExpectResourceNotExists(newViewRole(customNamespace), request)
+			ExpectResourceNotExists(newViewRoleBinding(customNamespace), request)
+		})
+
+		Context("when namespace changes", func() {
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.GoldenImagesNamespace = ""
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+
+				request.Instance.Spec.CommonTemplates.GoldenImagesNamespace = customNamespace
+			})
+
+			It("should keep old namespace by default", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newGoldenImagesNS(customNamespace), request)
+				ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+			})
+
+			It("should remove view RBAC from kept old namespace", func() {
+				ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+				ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceNotExists(newViewRole(GoldenImagesNSname), request)
+				ExpectResourceNotExists(newViewRoleBinding(GoldenImagesNSname), request)
+				ExpectResourceExists(newViewRole(customNamespace), request)
+				ExpectResourceExists(newViewRoleBinding(customNamespace), request)
+			})
+
+			It("should keep view RBAC if old namespace is an additional namespace", func() {
+				request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{GoldenImagesNSname}
+
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+				ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+			})
+
+			It("should delete old namespace if enabled", func() {
+				request.Instance.Spec.CommonTemplates.DeleteOldGoldenImagesNamespaces = true
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newGoldenImagesNS(customNamespace), request)
+				ExpectResourceNotExists(newGoldenImagesNS(GoldenImagesNSname), request)
+			})
+
+			It("should not delete old namespace containing PVCs", func() {
+				pvc := &core.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-pvc",
+						Namespace: GoldenImagesNSname,
+					},
+				}
+				Expect(request.Client.Create(request.Context, pvc)).To(Succeed())
+
+				request.Instance.Spec.CommonTemplates.DeleteOldGoldenImagesNamespaces = true
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(degraded[0].Resource.GetName()).To(Equal(GoldenImagesNSname))
+			})
+		})
+	})
+
+	Context("unmanaged golden images namespace", func() {
+		BeforeEach(func() {
+			manage := false
+			request.Instance.Spec.CommonTemplates.ManageGoldenImagesNamespace = &manage
+		})
+
+		It("should report degraded status if namespace does not exist", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceNotExists(newGoldenImagesNS(GoldenImagesNSname), request)
+
+			var degraded []common.ResourceStatus
+			for _, status := range statuses {
+				if status.Degraded != nil {
+					degraded = append(degraded, status)
+				}
+			}
+			Expect(degraded).To(HaveLen(1))
+			Expect(degraded[0].Resource.GetName()).To(Equal(GoldenImagesNSname))
+		})
+
+		Context("when namespace exists", func() {
+			var goldenImagesNS *core.Namespace
+
+			BeforeEach(func() {
+				goldenImagesNS = &core.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   GoldenImagesNSname,
+						Labels: map[string]string{"admin-label": "value"},
+					},
+				}
+				Expect(request.Client.Create(request.Context, goldenImagesNS)).To(Succeed())
+			})
+
+			It("should not modify the namespace", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				found := &core.Namespace{}
+				Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(goldenImagesNS), found)).To(Succeed())
+				Expect(found.Labels).To(Equal(goldenImagesNS.Labels))
+				Expect(found.Annotations).To(BeEmpty())
+			})
+
+			It("should reconcile RBAC in the namespace", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+				ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+			})
+
+			It("should not delete the namespace in cleanup", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(operand.Cleanup(&request)).To(Succeed())
+				ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+				ExpectResourceNotExists(newViewRole(GoldenImagesNSname), request)
+				ExpectResourceNotExists(newViewRoleBinding(GoldenImagesNSname), request)
+			})
+		})
+	})
+
+	Context("additional golden images namespaces", func() {
+		const (
+			teamNamespace  = "team-os-images"
+			otherNamespace = "other-os-images"
+		)
+
+		BeforeEach(func() {
+			for _, namespace := range []string{teamNamespace, otherNamespace} {
+				Expect(request.Client.Create(request.Context, newGoldenImagesNS(namespace))).To(Succeed())
+			}
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{teamNamespace, otherNamespace}
+		})
+
+		It("should reconcile RBAC in additional namespaces", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, namespace := range []string{teamNamespace, otherNamespace} {
+				role := newViewRole(namespace)
+				ExpectResourceExists(role, request)
+				Expect(role.Labels).To(HaveKeyWithValue(AdditionalGoldenImagesNamespaceLabel, "true"))
+				Expect(role.Rules).To(Equal(newViewRole(namespace).Rules))
+
+				binding := newViewRoleBinding(namespace)
+				ExpectResourceExists(binding, request)
+				Expect(binding.Labels).To(HaveKeyWithValue(AdditionalGoldenImagesNamespaceLabel, "true"))
+			}
+			ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+		})
+
+		It("should report degraded status if additional namespace does not exist", func() {
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{teamNamespace, "missing-os-images"}
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			var degraded []common.ResourceStatus
+			for _, status := range statuses {
+				if status.Degraded != nil {
+					degraded = append(degraded, status)
+				}
+			}
+			Expect(degraded).To(HaveLen(1))
+			Expect(*degraded[0].Degraded).To(ContainSubstring("missing-os-images"))
+			ExpectResourceExists(newViewRole(teamNamespace), request)
+		})
+
+		It("should remove RBAC from namespaces removed from the list", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{teamNamespace}
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newViewRole(teamNamespace), request)
+			ExpectResourceExists(newViewRoleBinding(teamNamespace), request)
+			ExpectResourceNotExists(newViewRole(otherNamespace), request)
+			ExpectResourceNotExists(newViewRoleBinding(otherNamespace), request)
+			ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+		})
+
+		It("should not remove RBAC from the golden images namespace", func() {
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{GoldenImagesNSname}
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = nil
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			role := newViewRole(GoldenImagesNSname)
+			ExpectResourceExists(role, request)
+			Expect(role.Labels).ToNot(HaveKey(AdditionalGoldenImagesNamespaceLabel))
+		})
+
+		It("should remove RBAC from all additional namespaces in cleanup", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Cleanup removes also namespaces that were dropped from the list
+			request.Instance.Spec.CommonTemplates.AdditionalGoldenImageNamespaces = []string{teamNamespace}
+			Expect(operand.Cleanup(&request)).To(Succeed())
+
+			for _, namespace := range []string{teamNamespace, otherNamespace} {
+				ExpectResourceNotExists(newViewRole(namespace), request)
+				ExpectResourceNotExists(newViewRoleBinding(namespace), request)
+				ExpectResourceExists(newGoldenImagesNS(namespace), request)
+			}
+		})
+	})
+
+	Context("terminating golden images namespace", func() {
+		BeforeEach(func() {
+			namespace := newGoldenImagesNS(GoldenImagesNSname)
+			namespace.Status.Phase = core.NamespaceTerminating
+			Expect(request.Client.Create(request.Context, namespace)).To(Succeed())
+		})
+
+		It("should wait for namespace termination without error", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			var waiting []common.ResourceStatus
+			for _, status := range statuses {
+				if status.RequeueAfter > 0 {
+					waiting = append(waiting, status)
+				}
+			}
+			Expect(waiting).To(HaveLen(1))
+			Expect(waiting[0].RequeueAfter).To(Equal(namespaceTerminationRequeueDelay))
+			Expect(*waiting[0].Progressing).To(ContainSubstring("Waiting for termination of golden images namespace"))
+			Expect(*waiting[0].Degraded).To(ContainSubstring(GoldenImagesNSname))
+
+			ExpectResourceNotExists(newViewRole(GoldenImagesNSname), request)
+			ExpectResourceNotExists(newViewRoleBinding(GoldenImagesNSname), request)
+
+			// Templates do not depend on the golden images namespace
+			for _, template := range bundleLoader.Templates() {
+				ExpectResourceExists(newTestTemplate(template.Name), request)
+			}
+		})
+
+		It("should recreate namespace and RBAC after the namespace is removed", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(request.Client.Delete(request.Context, newGoldenImagesNS(GoldenImagesNSname))).To(Succeed())
+
+			request.VersionCache = common.VersionCache{}
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				Expect(status.RequeueAfter).To(BeZero())
+			}
+
+			ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
+			ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
+			ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+		})
+	})
+
+	Context("owner references", func() {
+		expectOwnedBySSP := func(obj client.Object) {
+			ExpectResourceExists(obj, request)
+			ExpectWithOffset(1, obj.GetOwnerReferences()).To(HaveLen(1))
+			ExpectWithOffset(1, obj.GetOwnerReferences()[0].Kind).To(Equal("SSP"))
+			ExpectWithOffset(1, obj.GetOwnerReferences()[0].Name).To(Equal(name))
+		}
+
+		It("should set owner references to golden images RBAC in the SSP namespace", func() {
+			request.Instance.Spec.CommonTemplates.GoldenImagesNamespace = sspNamespace
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			expectOwnedBySSP(newViewRole(sspNamespace))
+			expectOwnedBySSP(newViewRoleBinding(sspNamespace))
+		})
+
+		It("should not set owner references to resources in other namespaces", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, obj := range []client.Object{
+				newViewRole(GoldenImagesNSname),
+				newViewRoleBinding(GoldenImagesNSname),
+				newGoldenImagesNS(GoldenImagesNSname),
+				newEditRole(),
+				newAggregatedViewRole(),
+			} {
+				ExpectResourceExists(obj, request)
+				Expect(obj.GetOwnerReferences()).To(BeEmpty())
+				Expect(obj.GetAnnotations()).To(HaveKey(libhandler.NamespacedNameAnnotation))
+			}
+		})
+	})
+
+	Context("golden images default ServiceAccount", func() {
+		newClusterRole := func(name string, rules ...rbac.PolicyRule) *rbac.ClusterRole {
+			return &rbac.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Rules:      rules,
+			}
+		}
+
+		defaultServiceAccount := rbac.Subject{
+			Kind:      rbac.ServiceAccountKind,
+			Name:      "default",
+			Namespace: GoldenImagesNSname,
+		}
+
+		serviceAccountStatuses := func(statuses []common.ResourceStatus) []string {
+			var messages []string
+			for _, status := range statuses {
+				if status.Degraded != nil && strings.Contains(*status.Degraded, "Default ServiceAccount") {
+					messages = append(messages, *status.Degraded)
+				}
+			}
+			return messages
+		}
+
+		BeforeEach(func() {
+			Expect(request.Client.Create(request.Context, newClusterRole("cluster-admin", rbac.PolicyRule{
+				APIGroups: []string{rbac.APIGroupAll},
+				Resources: []string{rbac.ResourceAll},
+				Verbs:     []string{rbac.VerbAll},
+			}))).To(Succeed())
+			Expect(request.Client.Create(request.Context, newClusterRole("pvc-reader", rbac.PolicyRule{
+				APIGroups: []string{core.GroupName},
+				Resources: []string{"persistentvolumeclaims"},
+				Verbs:     []string{"get", "list", "watch"},
+			}))).To(Succeed())
+		})
+
+		It("should not report minimal bindings", func() {
+			binding := &rbac.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-reader", Namespace: GoldenImagesNSname},
+				Subjects:   []rbac.Subject{defaultServiceAccount},
+				RoleRef:    rbac.RoleRef{APIGroup: rbac.GroupName, Kind: "ClusterRole", Name: "pvc-reader"},
+			}
+			Expect(request.Client.Create(request.Context, binding)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serviceAccountStatuses(statuses)).To(BeEmpty())
+		})
+
+		It("should not report overprivileged bindings of other subjects", func() {
+			binding := &rbac.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				Subjects: []rbac.Subject{{
+					Kind:      rbac.ServiceAccountKind,
+					Name:      "default",
+					Namespace: "other-namespace",
+				}},
+				RoleRef: rbac.RoleRef{APIGroup: rbac.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			}
+			Expect(request.Client.Create(request.Context, binding)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serviceAccountStatuses(statuses)).To(BeEmpty())
+		})
+
+		It("should report cluster-admin ClusterRoleBinding", func() {
+			binding := &rbac.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "os-images-admin"},
+				Subjects:   []rbac.Subject{defaultServiceAccount},
+				RoleRef:    rbac.RoleRef{APIGroup: rbac.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			}
+			Expect(request.Client.Create(request.Context, binding)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			messages := serviceAccountStatuses(statuses)
+			Expect(messages).To(HaveLen(1))
+			Expect(messages[0]).To(ContainSubstring(`ClusterRoleBinding "os-images-admin" (ClusterRole "cluster-admin")`))
+		})
+
+		It("should report escalating Role bound to ServiceAccounts group", func() {
+			role := &rbac.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: "rbac-binder", Namespace: GoldenImagesNSname},
+				Rules: []rbac.PolicyRule{{
+					APIGroups: []string{rbac.GroupName},
+					Resources: []string{"roles"},
+					Verbs:     []string{"get", "bind"},
+				}},
+			}
+			Expect(request.Client.Create(request.Context, role)).To(Succeed())
+			binding := &rbac.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "rbac-binder", Namespace: GoldenImagesNSname},
+				Subjects: []rbac.Subject{{
+					Kind:     rbac.GroupKind,
+					Name:     "system:serviceaccounts:" + GoldenImagesNSname,
+					APIGroup: rbac.GroupName,
+				}},
+				RoleRef: rbac.RoleRef{APIGroup: rbac.GroupName, Kind: "Role", Name: "rbac-binder"},
+			}
+			Expect(request.Client.Create(request.Context, binding)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			messages := serviceAccountStatuses(statuses)
+			Expect(messages).To(HaveLen(1))
+			Expect(messages[0]).To(ContainSubstring(`RoleBinding "rbac-binder" (Role "rbac-binder")`))
+		})
+
+		DescribeTable("should report cluster-admin ClusterRoleBinding of groups including all ServiceAccounts", func(group string) {
+			binding := &rbac.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "group-admin"},
+				Subjects: []rbac.Subject{{
+					Kind:     rbac.GroupKind,
+					Name:     group,
+					APIGroup: rbac.GroupName,
+				}},
+				RoleRef: rbac.RoleRef{APIGroup: rbac.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			}
+			Expect(request.Client.Create(request.Context, binding)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			messages := serviceAccountStatuses(statuses)
+			Expect(messages).To(HaveLen(1))
+			Expect(messages[0]).To(ContainSubstring(`ClusterRoleBinding "group-admin" (ClusterRole "cluster-admin")`))
+		},
+			Entry("all ServiceAccounts", "system:serviceaccounts"),
+			Entry("all authenticated users", "system:authenticated"),
+		)
+
+		It("should index subjects including the default ServiceAccount", func() {
+			subjects := []rbac.Subject{
+				defaultServiceAccount,
+				{Kind: rbac.GroupKind, Name: "system:serviceaccounts:" + GoldenImagesNSname},
+				{Kind: rbac.GroupKind, Name: "system:serviceaccounts"},
+				{Kind: rbac.GroupKind, Name: "system:authenticated"},
+			}
+			for _, subject := range subjects {
+				Expect(isDefaultServiceAccountSubject(subject, GoldenImagesNSname)).To(BeTrue(), subject.Name)
+			}
+			Expect(subjectIndexKeys(subjects)).To(ConsistOf(defaultServiceAccountSubjectKeys(GoldenImagesNSname)))
+			Expect(isDefaultServiceAccountSubject(rbac.Subject{Kind: rbac.GroupKind, Name: "system:serviceaccounts:other"},
+				GoldenImagesNSname)).To(BeFalse())
+		})
+	})
+
+	Context("VM defaults", func() {
+		const (
+			testLabel      = "fleet.example.com/group"
+			testAnnotation = "fleet.example.com/owner"
+			templateLabel  = "vm.kubevirt.io/template"
+		)
+
+		It("should add default labels and annotations to template VMs", func() {
+			request.Instance.Spec.CommonTemplates.DefaultVMLabels = map[string]string{
+				testLabel: "test-group",
+			}
+			request.Instance.Spec.CommonTemplates.DefaultVMAnnotations = map[string]string{
+				testAnnotation: "test-owner",
+			}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, template := range bundleLoader.Templates() {
+				vm := getTemplateVM(template.Name, request)
+				Expect(vm.GetLabels()).To(HaveKeyWithValue(testLabel, "test-group"))
+				Expect(vm.GetAnnotations()).To(HaveKeyWithValue(testAnnotation, "test-owner"))
+			}
+		})
+
+		It("should not overwrite labels defined in templates", func() {
+			request.Instance.Spec.CommonTemplates.DefaultVMLabels = map[string]string{
+				templateLabel: "overwritten",
+			}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, template := range bundleLoader.Templates() {
+				vm := getTemplateVM(template.Name, request)
+				Expect(vm.GetLabels()).To(HaveKeyWithValue(templateLabel, template.Name))
+			}
+		})
+
+		It("should not modify the loaded bundle", func() {
+			request.Instance.Spec.CommonTemplates.DefaultVMLabels = map[string]string{
+				testLabel: "test-group",
+			}
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, template := range bundleLoader.Templates() {
+				for _, object := range template.Objects {
+					Expect(string(object.Raw)).ToNot(ContainSubstring(testLabel))
+				}
+			}
+		})
+
+		Context("default memory ballooning", func() {
+			It("should not set memory ballooning by default", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					_, found, err := unstructured.NestedBool(vm.Object, autoattachMemBalloonPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeFalse())
+				}
+			})
+
+			DescribeTable("should set memory ballooning to template VMs", func(enabled bool) {
+				request.Instance.Spec.CommonTemplates.DefaultMemoryBallooning = &enabled
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					value, found, err := unstructured.NestedBool(vm.Object, autoattachMemBalloonPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(value).To(Equal(enabled))
+				}
+			},
+				Entry("enabled", true),
+				Entry("disabled", false),
+			)
+
+			It("should not overwrite memory ballooning defined in template", func() {
+				template := &templatev1.Template{
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"devices":{"autoattachMemBalloon":true}}}}}}`),
+					}, {
+						Raw: []byte(`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"devices":{}}}}}}`),
+					}},
+				}
+				disabled := false
+				request.Instance.Spec.CommonTemplates.DefaultMemoryBallooning = &disabled
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"autoattachMemBalloon":true`))
+				Expect(string(template.Objects[1].Raw)).To(ContainSubstring(`"autoattachMemBalloon":false`))
+			})
+		})
+
+		Context("default secure boot", func() {
+			newTemplateWithVM := func(osName, vmJson string) *templatev1.Template {
+				return &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "test-template",
+						Labels: map[string]string{TemplateOsLabelPrefix + osName: "true"},
+					},
+					Objects: []runtime.RawExtension{{Raw: []byte(vmJson)}},
+				}
+			}
+
+			getVM := func(template *templatev1.Template) *unstructured.Unstructured {
+				vm := &unstructured.Unstructured{}
+				ExpectWithOffset(1, json.Unmarshal(template.Objects[0].Raw, &vm.Object)).To(Succeed())
+				return vm
+			}
+
+			It("should enable secure boot, EFI and SMM for matching OS", func() {
+				request.Instance.Spec.CommonTemplates.SecureBootByOS = map[string]bool{"win10": true}
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var windowsTemplates int
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					secureBoot, found, err := unstructured.NestedBool(vm.Object, efiSecureBootPath...)
+					Expect(err).ToNot(HaveOccurred())
+					if template.Labels[TemplateOsLabelPrefix+"win10"] != "true" {
+						Expect(found).To(BeFalse(), "template: "+template.Name)
+						continue
+					}
+					windowsTemplates++
+					Expect(found).To(BeTrue(), "template: "+template.Name)
+					Expect(secureBoot).To(BeTrue())
+					smm, _, err := unstructured.NestedBool(vm.Object, smmEnabledPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(smm).To(BeTrue())
+				}
+				Expect(windowsTemplates).ToNot(BeZero())
+			})
+
+			It("should not overwrite secure boot defined in template", func() {
+				template := newTemplateWithVM("win10",
+					`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"firmware":{"bootloader":{"efi":{"secureBoot":false}}}}}}}}`)
+				request.Instance.Spec.CommonTemplates.SecureBootByOS = map[string]bool{"win10": true}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+
+				vm := getVM(template)
+				secureBoot, found, err := unstructured.NestedBool(vm.Object, efiSecureBootPath...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secureBoot).To(BeFalse())
+				_, found, err = unstructured.NestedFieldNoCopy(vm.Object, smmEnabledPath...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+
+			It("should only disable secure boot in VMs using EFI", func() {
+				template := newTemplateWithVM("win10", `{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{}}}}}`)
+				request.Instance.Spec.CommonTemplates.SecureBootByOS = map[string]bool{"win10": false}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				_, found, err := unstructured.NestedFieldNoCopy(getVM(template).Object, bootloaderPath...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+
+				template = newTemplateWithVM("win10",
+					`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"firmware":{"bootloader":{"efi":{}}}}}}}}`)
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				secureBoot, found, err := unstructured.NestedBool(getVM(template).Object, efiSecureBootPath...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secureBoot).To(BeFalse())
+			})
+
+			It("should fail to enable secure boot in VM using BIOS", func() {
+				template := newTemplateWithVM("win10",
+					`{"kind":"VirtualMachine","metadata":{"name":"test-vm"},"spec":{"template":{"spec":{"domain":{"firmware":{"bootloader":{"bios":{}}}}}}}}`)
+				request.Instance.Spec.CommonTemplates.SecureBootByOS = map[string]bool{"win10": true}
+
+				err := newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)
+				Expect(err).To(HaveOccurred())
+				Expect(isSecureBootConflict(err)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring("secure boot requires EFI"))
+			})
+
+			It("should not change templates of other OS", func() {
+				vmJson := `{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{}}}}}`
+				template := newTemplateWithVM("fedora33", vmJson)
+				request.Instance.Spec.CommonTemplates.SecureBootByOS = map[string]bool{"win10": true}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(Equal(vmJson))
+			})
+		})
+
+		Context("removed VirtualMachine fields", func() {
+			// vmSpecSchema returns a VirtualMachine CRD schema, where spec contains only the given fields
+			vmSpecSchema := func(specFields ...string) map[string]interface{} {
+				specProperties := map[string]interface{}{}
+				for _, field := range specFields {
+					specProperties[field] = map[string]interface{}{
+						"type":                                 "object",
+						"x-kubernetes-preserve-unknown-fields": true,
+					}
+				}
+				return map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"apiVersion": map[string]interface{}{"type": "string"},
+						"kind":       map[string]interface{}{"type": "string"},
+						"metadata":   map[string]interface{}{"type": "object"},
+						"spec": map[string]interface{}{
+							"type":       "object",
+							"properties": specProperties,
+						},
+					},
+				}
+			}
+
+			createVMCRD := func(openAPISchema map[string]interface{}) {
+				crd := newCustomResourceDefinition()
+				crd.SetName(VirtualMachineCRDName)
+				Expect(unstructured.SetNestedSlice(crd.Object, []interface{}{
+					map[string]interface{}{
+						"name":    "v1",
+						"served":  true,
+						"storage": true,
+						"schema": map[string]interface{}{
+							"openAPIV3Schema": openAPISchema,
+						},
+					},
+				}, "spec", "versions")).To(Succeed())
+				Expect(request.Client.Create(request.Context, crd)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				request.Client.Scheme().AddKnownTypeWithName(customResourceDefinitionGVK, &unstructured.Unstructured{})
+				request.Client.Scheme().AddKnownTypeWithName(
+					customResourceDefinitionGVK.GroupVersion().WithKind("CustomResourceDefinitionList"),
+					&unstructured.UnstructuredList{})
+			})
+
+			It("should not report templates using only fields from the schema", func() {
+				createVMCRD(vmSpecSchema("template", "running", "dataVolumeTemplates"))
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+			})
+
+			It("should report templates using a field removed from the schema", func() {
+				createVMCRD(vmSpecSchema("template", "dataVolumeTemplates"))
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				for _, template := range bundleLoader.Templates() {
+					Expect(*degraded[0].Degraded).To(ContainSubstring(template.Name + " (spec.running)"))
+				}
+
+				// Templates are still deployed
+				for _, template := range bundleLoader.Templates() {
+					ExpectResourceExists(newTestTemplate(template.Name), request)
+				}
+			})
+
+			It("should not check templates if the VirtualMachine CRD does not exist", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+			})
+
+			It("should return paths of unknown nested fields", func() {
+				template := &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{Name: "test-template"},
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"apiVersion":"kubevirt.io/v1","kind":"VirtualMachine",` +
+							`"spec":{"disks":[{"name":"disk","removed":true}],"labels":{"a":{"removed":1}},"free":{"any":1}}}`),
+					}},
+				}
+				vmSchema := map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"apiVersion": map[string]interface{}{"type": "string"},
+						"kind":       map[string]interface{}{"type": "string"},
+						"spec": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"disks": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"name": map[string]interface{}{"type": "string"},
+										},
+									},
+								},
+								"labels": map[string]interface{}{
+									"type": "object",
+									"additionalProperties": map[string]interface{}{
+										"type":       "object",
+										"properties": map[string]interface{}{},
+									},
+								},
+								"free": map[string]interface{}{
+									"type":                                 "object",
+									"x-kubernetes-preserve-unknown-fields": true,
+								},
+							},
+						},
+					},
+				}
+
+				fields, err := unknownVMFields(template, map[string]map[string]interface{}{"v1": vmSchema})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fields).To(Equal([]string{"spec.disks[0].removed", "spec.labels.a.removed"}))
+
+				fields, err = unknownVMFields(template, map[string]map[string]interface{}{"v1alpha3": vmSchema})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fields).To(BeEmpty())
+			})
+		})
+
+		Context("default TPM", func() {
+			BeforeEach(func() {
+				kubeVirtGVK := kubeVirtListGVK.GroupVersion().WithKind("KubeVirt")
+				request.Client.Scheme().AddKnownTypeWithName(kubeVirtGVK, &unstructured.Unstructured{})
+				request.Client.Scheme().AddKnownTypeWithName(kubeVirtListGVK, &unstructured.UnstructuredList{})
+			})
+
+			createKubeVirt := func(vmStateStorageClass string) {
+				kubeVirt := &unstructured.Unstructured{}
+				kubeVirt.SetGroupVersionKind(kubeVirtListGVK.GroupVersion().WithKind("KubeVirt"))
+				kubeVirt.SetName("kubevirt")
+				kubeVirt.SetNamespace(sspNamespace)
+				if vmStateStorageClass != "" {
+					Expect(unstructured.SetNestedField(kubeVirt.Object, vmStateStorageClass,
+						"spec", "configuration", "vmStateStorageClass")).To(Succeed())
+				}
+				Expect(request.Client.Create(request.Context, kubeVirt)).To(Succeed())
+			}
+
+			newTemplateWithVM := func(osName, vmJson string) *templatev1.Template {
+				return &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "test-template",
+						Labels: map[string]string{TemplateOsLabelPrefix + osName: "true"},
+					},
+					Objects: []runtime.RawExtension{{Raw: []byte(vmJson)}},
+				}
+			}
+
+			It("should enable persistent TPM for matching OS", func() {
+				createKubeVirt("test-storage-class")
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": true}
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				var windowsTemplates int
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					tpm, found, err := unstructured.NestedMap(vm.Object, tpmPath...)
+					Expect(err).ToNot(HaveOccurred())
+					if template.Labels[TemplateOsLabelPrefix+"win10"] != "true" {
+						Expect(found).To(BeFalse(), "template: "+template.Name)
+						continue
+					}
+					windowsTemplates++
+					Expect(tpm).To(Equal(map[string]interface{}{"persistent": true}), "template: "+template.Name)
+				}
+				Expect(windowsTemplates).ToNot(BeZero())
+			})
+
+			It("should disable TPM for matching OS", func() {
+				template := newTemplateWithVM("win10", `{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{}}}}}`)
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": false}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"tpm":{"enabled":false}`))
+			})
+
+			It("should not overwrite TPM defined in template", func() {
+				vmJson := `{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"devices":{"tpm":{}}}}}}}`
+				template := newTemplateWithVM("win10", vmJson)
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": true}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(Equal(vmJson))
+			})
+
+			It("should not change templates of other OS", func() {
+				vmJson := `{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{}}}}}`
+				template := newTemplateWithVM("fedora33", vmJson)
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": true}
+				Expect(newVMDefaults(&request.Instance.Spec.CommonTemplates).apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(Equal(vmJson))
+			})
+
+			It("should report degraded status if VM state storage class is not configured", func() {
+				createKubeVirt("")
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": true}
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring("VM state storage class"))
+			})
+
+			It("should not check storage if TPM is only disabled", func() {
+				request.Instance.Spec.CommonTemplates.TPMByOS = map[string]bool{"win10": false}
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+			})
+		})
+
+		Context("expected OS families", func() {
+			degradedStatuses := func(statuses []common.ResourceStatus) []common.ResourceStatus {
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				return degraded
+			}
+
+			It("should not report degraded status if all families are covered", func() {
+				request.Instance.Spec.CommonTemplates.ExpectedOSFamilies = []string{"fedora", "win"}
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(degradedStatuses(statuses)).To(BeEmpty())
+			})
+
+			It("should report degraded status with missing families", func() {
+				request.Instance.Spec.CommonTemplates.ExpectedOSFamilies = []string{"fedora", "solaris", "haiku"}
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				degraded := degradedStatuses(statuses)
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(HaveSuffix(": solaris, haiku"))
+
+				// Templates are deployed anyway
+				for _, template := range bundleLoader.Templates() {
+					key := client.ObjectKey{Namespace: namespace, Name: template.Name}
+					Expect(request.Client.Get(request.Context, key, &templatev1.Template{})).To(Succeed())
+				}
+			})
+
+			It("should not count deprecated templates", func() {
+				request.Instance.Spec.CommonTemplates.ExpectedOSFamilies = []string{"fedora"}
+				active := newTestTemplate("active")
+				active.Labels = map[string]string{TemplateOsLabelPrefix + "fedora33": "true"}
+				deprecated := newTestTemplate("deprecated")
+				deprecated.Labels = map[string]string{TemplateOsLabelPrefix + "fedora32": "true"}
+				deprecated.Annotations = map[string]string{TemplateDeprecatedAnnotation: "true"}
+
+				Expect(checkOSFamilyCoverage(&request, []templatev1.Template{*active, *deprecated})).To(BeNil())
+
+				status := checkOSFamilyCoverage(&request, []templatev1.Template{*deprecated})
+				Expect(status).ToNot(BeNil())
+				Expect(*status.Degraded).To(HaveSuffix(": fedora"))
+			})
+
+			It("should not count OS labels set to false", func() {
+				request.Instance.Spec.CommonTemplates.ExpectedOSFamilies = []string{"fedora"}
+				template := newTestTemplate("test-template")
+				template.Labels = map[string]string{TemplateOsLabelPrefix + "fedora33": "false"}
+
+				Expect(checkOSFamilyCoverage(&request, []templatev1.Template{*template})).ToNot(BeNil())
+			})
+		})
+
+		Context("default snapshot class", func() {
+			const snapshotClassName = "test-snapshot-class"
+
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.DefaultSnapshotClass = snapshotClassName
+			})
+
+			It("should set snapshot class to template VMs", func() {
+				snapshotClass := newVolumeSnapshotClass()
+				snapshotClass.SetName(snapshotClassName)
+				Expect(request.Client.Create(request.Context, snapshotClass)).To(Succeed())
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					Expect(vm.GetAnnotations()).To(HaveKeyWithValue(VMSnapshotClassAnnotation, snapshotClassName))
+				}
+			})
+
+			It("should report degraded status if snapshot class does not exist", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring(snapshotClassName))
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					Expect(vm.GetAnnotations()).ToNot(HaveKey(VMSnapshotClassAnnotation))
+				}
+			})
+
+			It("should not overwrite snapshot class defined in template", func() {
+				template := &templatev1.Template{
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"kind":"VirtualMachine","metadata":{"annotations":{"` +
+							VMSnapshotClassAnnotation + `":"explicit"}}}`),
+					}},
+				}
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"explicit"`))
+				Expect(string(template.Objects[0].Raw)).ToNot(ContainSubstring(snapshotClassName))
+			})
+		})
+
+		Context("default migration policy", func() {
+			const policyName = "test-migration-policy"
+
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.DefaultMigrationPolicy = policyName
+			})
+
+			createMigrationPolicy := func(selector map[string]string) {
+				policy := newMigrationPolicy()
+				policy.SetName(policyName)
+				Expect(unstructured.SetNestedStringMap(policy.Object, selector,
+					"spec", "selectors", "virtualMachineInstanceSelector")).To(Succeed())
+				Expect(request.Client.Create(request.Context, policy)).To(Succeed())
+			}
+
+			It("should add labels selected by the policy to template VMs", func() {
+				createMigrationPolicy(map[string]string{"migration-policy": "fast"})
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					labels, _, err := unstructured.NestedStringMap(vm.Object, vmiLabelsPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(labels).To(HaveKeyWithValue("migration-policy", "fast"), "template: "+template.Name)
+				}
+			})
+
+			It("should report degraded status if the policy does not exist", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring(policyName))
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					labels, _, err := unstructured.NestedStringMap(vm.Object, vmiLabelsPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(labels).ToNot(HaveKey("migration-policy"))
+				}
+			})
+
+			It("should not overwrite labels defined in template", func() {
+				template := &templatev1.Template{
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"kind":"VirtualMachine","spec":{"template":{"metadata":{"labels":{"migration-policy":"explicit"}}}}}`),
+					}},
+				}
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				defaults.migrationPolicyLabels = map[string]string{"migration-policy": "fast", "other": "value"}
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"migration-policy":"explicit"`))
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"other":"value"`))
+			})
+		})
+
+		Context("default scheduler by workload", func() {
+			const schedulerName = "test-scheduler"
+
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.SchedulerByWorkload = map[string]string{
+					"server": schedulerName,
+				}
+			})
+
+			newSchedulerLease := func(name string, renewTime time.Time) *coordination.Lease {
+				return &coordination.Lease{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: "kube-system",
+					},
+					Spec: coordination.LeaseSpec{
+						HolderIdentity:       pointer.StringPtr(name + "-holder"),
+						LeaseDurationSeconds: pointer.Int32Ptr(15),
+						RenewTime:            &metav1.MicroTime{Time: renewTime},
+					},
+				}
+			}
+
+			createSchedulerLease := func(name string) {
+				Expect(request.Client.Create(request.Context, newSchedulerLease(name, time.Now()))).To(Succeed())
+			}
+
+			newTemplate := func(workloadLabel string, vm string) *templatev1.Template {
+				return &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{workloadLabel: "true"},
+					},
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(vm),
+					}},
+				}
+			}
+
+			It("should set scheduler name in templates of matching workloads", func() {
+				createSchedulerLease(schedulerName)
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				serverTemplates := 0
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					scheduler, _, err := unstructured.NestedString(vm.Object, schedulerNamePath...)
+					Expect(err).ToNot(HaveOccurred())
+					if template.Labels[testWorkflowLabel] == "true" {
+						Expect(scheduler).To(Equal(schedulerName), template.Name)
+						serverTemplates++
+					} else {
+						Expect(scheduler).To(BeEmpty(), template.Name)
+					}
+				}
+				Expect(serverTemplates).ToNot(BeZero())
+			})
+
+			It("should use the first workload in alphabetical order", func() {
+				template := &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							TemplateWorkloadLabelPrefix + "server":  "true",
+							TemplateWorkloadLabelPrefix + "desktop": "true",
+						},
+					},
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"kind":"VirtualMachine"}`),
+					}},
+				}
+				request.Instance.Spec.CommonTemplates.SchedulerByWorkload["desktop"] = "desktop-scheduler"
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"schedulerName":"desktop-scheduler"`))
+			})
+
+			It("should not set scheduler name in templates of other workloads", func() {
+				template := newTemplate(TemplateWorkloadLabelPrefix+"desktop", `{"kind":"VirtualMachine"}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).ToNot(ContainSubstring("schedulerName"))
+			})
+
+			It("should not overwrite scheduler name defined in template", func() {
+				template := newTemplate(testWorkflowLabel,
+					`{"kind":"VirtualMachine","spec":{"template":{"spec":{"schedulerName":"explicit"}}}}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"schedulerName":"explicit"`))
+			})
+
+			It("should report degraded status if the scheduler is not running", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring(schedulerName))
+
+				// Scheduler name is set anyway, because the scheduler can be deployed later
+				for _, template := range bundleLoader.Templates() {
+					if template.Labels[testWorkflowLabel] != "true" {
+						continue
+					}
+					vm := getTemplateVM(template.Name, request)
+					scheduler, _, err := unstructured.NestedString(vm.Object, schedulerNamePath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(scheduler).To(Equal(schedulerName), template.Name)
+				}
+			})
+
+			It("should report scheduler with an expired lease as not running", func() {
+				lease := newSchedulerLease(schedulerName, time.Now().Add(-time.Minute))
+				Expect(request.Client.Create(request.Context, lease)).To(Succeed())
+
+				status, err := checkWorkloadSchedulers(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).ToNot(BeNil())
+				Expect(*status.Degraded).To(ContainSubstring(schedulerName))
+			})
+
+			It("should report scheduler with a lease without holder as not running", func() {
+				lease := newSchedulerLease(schedulerName, time.Now())
+				lease.Spec.HolderIdentity = nil
+				Expect(request.Client.Create(request.Context, lease)).To(Succeed())
+
+				status, err := checkWorkloadSchedulers(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).ToNot(BeNil())
+			})
+
+			It("should ignore scheduler leases in other namespaces", func() {
+				lease := newSchedulerLease(schedulerName, time.Now())
+				lease.Namespace = "other-namespace"
+				Expect(request.Client.Create(request.Context, lease)).To(Succeed())
+
+				status, err := checkWorkloadSchedulers(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).ToNot(BeNil())
+			})
+
+			It("should read the lease directly from the API server", func() {
+				request.APIReader = fake.NewFakeClientWithScheme(request.Client.Scheme(), newSchedulerLease(schedulerName, time.Now()))
+
+				status, err := checkWorkloadSchedulers(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).To(BeNil())
+			})
+
+			It("should not report degraded status for the default scheduler", func() {
+				request.Instance.Spec.CommonTemplates.SchedulerByWorkload["server"] = core.DefaultSchedulerName
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+			})
+		})
+
+		Context("default access credentials", func() {
+			const secretName = "test-ssh-keys"
+
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.DefaultAccessCredentials = &ssp.DefaultAccessCredentials{
+					SecretName: secretName,
+					Users:      []string{"fedora", "cloud-user"},
+				}
+			})
+
+			createSecret := func() {
+				secret := &core.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      secretName,
+						Namespace: goldenImagesNamespace(&request),
+					},
+				}
+				Expect(request.Client.Create(request.Context, secret)).To(Succeed())
+			}
+
+			It("should set access credentials to template VMs", func() {
+				createSecret()
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					credentials, found, err := unstructured.NestedSlice(vm.Object, accessCredentialsPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(credentials).To(Equal(newAccessCredentials(request.Instance.Spec.CommonTemplates.DefaultAccessCredentials)))
+
+					secretRef, _, err := unstructured.NestedString(credentials[0].(map[string]interface{}),
+						"sshPublicKey", "source", "secret", "secretName")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(secretRef).To(Equal(secretName))
+				}
+			})
+
+			It("should report degraded status if secret does not exist", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring(secretName))
+
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					_, found, err := unstructured.NestedFieldNoCopy(vm.Object, accessCredentialsPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeFalse())
+				}
+			})
+
+			It("should read the secret directly from the API server", func() {
+				secret := &core.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      secretName,
+						Namespace: goldenImagesNamespace(&request),
+					},
+				}
+				request.APIReader = fake.NewFakeClientWithScheme(request.Client.Scheme(), secret)
+
+				status, err := checkAccessCredentialsSecret(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).To(BeNil())
+			})
+
+			It("should not overwrite access credentials defined in template", func() {
+				template := &templatev1.Template{
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(`{"kind":"VirtualMachine","spec":{"template":{"spec":{"accessCredentials":[` +
+							`{"sshPublicKey":{"source":{"secret":{"secretName":"explicit"}}}}]}}}}`),
+					}},
+				}
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"explicit"`))
+				Expect(string(template.Objects[0].Raw)).ToNot(ContainSubstring(secretName))
+			})
+
+			It("should only set access credentials for selected operating systems", func() {
+				request.Instance.Spec.CommonTemplates.DefaultAccessCredentials.OperatingSystems = []string{"other-os"}
+
+				newTemplate := func(osLabel string) *templatev1.Template {
+					return &templatev1.Template{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{osLabel: "true"},
+						},
+						Objects: []runtime.RawExtension{{
+							Raw: []byte(`{"kind":"VirtualMachine"}`),
+						}},
+					}
+				}
+
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+
+				matching := newTemplate(TemplateOsLabelPrefix + "other-os")
+				Expect(defaults.apply(matching)).To(Succeed())
+				Expect(string(matching.Objects[0].Raw)).To(ContainSubstring(secretName))
+
+				other := newTemplate(testOsLabel)
+				Expect(defaults.apply(other)).To(Succeed())
+				Expect(string(other.Objects[0].Raw)).ToNot(ContainSubstring("accessCredentials"))
+			})
+		})
+
+		Context("dedicated CPU for workloads", func() {
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.DedicatedCPUForWorkloads = []string{"server"}
+			})
+
+			createCPUManagerNode := func() {
+				node := &core.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cpu-manager-node",
+						Labels: map[string]string{CPUManagerNodeLabel: "true"},
+					},
+				}
+				Expect(request.Client.Create(request.Context, node)).To(Succeed())
+			}
+
+			newTemplate := func(workloadLabel string, vm string) *templatev1.Template {
+				return &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{workloadLabel: "true"},
+					},
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(vm),
+					}},
+				}
+			}
+
+			It("should set dedicated CPU placement in templates of matching workloads", func() {
+				createCPUManagerNode()
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				serverTemplates := 0
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					dedicatedCPU, _, err := unstructured.NestedBool(vm.Object, dedicatedCPUPath...)
+					Expect(err).ToNot(HaveOccurred())
+					if template.Labels[testWorkflowLabel] == "true" {
+						Expect(dedicatedCPU).To(BeTrue(), template.Name)
+						serverTemplates++
+						continue
+					}
+					// Other templates keep the value from the bundle
+					bundleDedicatedCPU := false
+					for _, object := range template.Objects {
+						bundleDedicatedCPU = bundleDedicatedCPU || strings.Contains(string(object.Raw), `"dedicatedCpuPlacement":true`)
+					}
+					Expect(dedicatedCPU).To(Equal(bundleDedicatedCPU), template.Name)
+				}
+				Expect(serverTemplates).ToNot(BeZero())
+			})
+
+			It("should not set dedicated CPU placement in templates of other workloads", func() {
+				template := newTemplate(TemplateWorkloadLabelPrefix+"desktop", `{"kind":"VirtualMachine"}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).ToNot(ContainSubstring("dedicatedCpuPlacement"))
+			})
+
+			It("should not overwrite dedicated CPU placement defined in template", func() {
+				template := newTemplate(testWorkflowLabel,
+					`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"cpu":{"dedicatedCpuPlacement":false}}}}}}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"dedicatedCpuPlacement":false`))
+			})
+
+			It("should read nodes directly from the API server", func() {
+				node := &core.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cpu-manager-node",
+						Labels: map[string]string{CPUManagerNodeLabel: "true"},
+					},
+				}
+				// Nodes are not in the cached client
+				request.APIReader = fake.NewFakeClientWithScheme(request.Client.Scheme(), node)
+
+				status, err := checkCPUManager(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).To(BeNil())
+			})
+
+			It("should report degraded status if no node has CPU manager enabled", func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring("CPU manager"))
+
+				// Dedicated CPU placement is still set, so it takes effect once the CPU manager is enabled
+				for _, template := range bundleLoader.Templates() {
+					if template.Labels[testWorkflowLabel] != "true" {
+						continue
+					}
+					vm := getTemplateVM(template.Name, request)
+					dedicatedCPU, _, err := unstructured.NestedBool(vm.Object, dedicatedCPUPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(dedicatedCPU).To(BeTrue())
+				}
+			})
+		})
+
+		Context("hot-plug for workloads", func() {
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.EnableHotplugForWorkloads = []string{"server"}
+
+				kubeVirtGVK := kubeVirtListGVK.GroupVersion().WithKind("KubeVirt")
+				request.Client.Scheme().AddKnownTypeWithName(kubeVirtGVK, &unstructured.Unstructured{})
+				request.Client.Scheme().AddKnownTypeWithName(kubeVirtListGVK, &unstructured.UnstructuredList{})
+			})
+
+			createKubeVirt := func(featureGates ...string) {
+				kubeVirt := &unstructured.Unstructured{}
+				kubeVirt.SetGroupVersionKind(kubeVirtListGVK.GroupVersion().WithKind("KubeVirt"))
+				kubeVirt.SetName("kubevirt")
+				kubeVirt.SetNamespace(sspNamespace)
+				Expect(unstructured.SetNestedStringSlice(kubeVirt.Object, featureGates,
+					"spec", "configuration", "developerConfiguration", "featureGates")).To(Succeed())
+				Expect(request.Client.Create(request.Context, kubeVirt)).To(Succeed())
+			}
+
+			newTemplate := func(workloadLabel string, vm string) *templatev1.Template {
+				return &templatev1.Template{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{workloadLabel: "true"},
+					},
+					Objects: []runtime.RawExtension{{
+						Raw: []byte(vm),
+					}},
+				}
+			}
+
+			It("should enable hot-plug in templates of matching workloads", func() {
+				createKubeVirt("DataVolumes", HotplugVolumesFeatureGate)
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					Expect(status.Degraded).To(BeNil())
+				}
+
+				serverTemplates := 0
+				for _, template := range bundleLoader.Templates() {
+					vm := getTemplateVM(template.Name, request)
+					_, found, err := unstructured.NestedBool(vm.Object, disableHotplugPath...)
+					Expect(err).ToNot(HaveOccurred())
+					if template.Labels[testWorkflowLabel] == "true" {
+						Expect(found).To(BeTrue(), template.Name)
+						serverTemplates++
+					} else {
+						Expect(found).To(BeFalse(), template.Name)
+					}
+				}
+				Expect(serverTemplates).ToNot(BeZero())
+			})
+
+			It("should not overwrite hot-plug setting defined in template", func() {
+				template := newTemplate(testWorkflowLabel,
+					`{"kind":"VirtualMachine","spec":{"template":{"spec":{"domain":{"devices":{"disableHotplug":true}}}}}}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).To(ContainSubstring(`"disableHotplug":true`))
+			})
+
+			It("should not change templates of other workloads", func() {
+				template := newTemplate(TemplateWorkloadLabelPrefix+"desktop", `{"kind":"VirtualMachine"}`)
+				defaults := newVMDefaults(&request.Instance.Spec.CommonTemplates)
+				Expect(defaults.apply(template)).To(Succeed())
+				Expect(string(template.Objects[0].Raw)).ToNot(ContainSubstring("disableHotplug"))
+			})
+
+			It("should report degraded status if the feature gate is not enabled", func() {
+				createKubeVirt("DataVolumes")
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				var degraded []common.ResourceStatus
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, status)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(*degraded[0].Degraded).To(ContainSubstring(HotplugVolumesFeatureGate))
+
+				// Hot-plug is still enabled, so it takes effect once the feature gate is enabled
+				for _, template := range bundleLoader.Templates() {
+					if template.Labels[testWorkflowLabel] != "true" {
+						continue
+					}
+					vm := getTemplateVM(template.Name, request)
+					_, found, err := unstructured.NestedBool(vm.Object, disableHotplugPath...)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+				}
+			})
+
+			It("should report degraded status if KubeVirt does not exist", func() {
+				status, err := checkHotplugFeatureGate(&request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(status).ToNot(BeNil())
+				Expect(*status.Degraded).To(ContainSubstring(HotplugVolumesFeatureGate))
+			})
+		})
+	})
+
+	Context("dump templates", func() {
+		bundleFile := filepath.Join(BundleDir, "common-templates-"+Version+".yaml")
+
+		It("should print templates with the namespace applied", func() {
+			out := &bytes.Buffer{}
+			Expect(DumpTemplates(out, bundleFile, "test-namespace", "")).To(Succeed())
+
+			dumped, err := decodeTemplates(out, decodeOptions{validate: true})
+			Expect(err).ToNot(HaveOccurred())
+			expected, err := ReadTemplates(bundleFile, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dumped).To(HaveLen(len(expected)))
+			for i := range dumped {
+				Expect(dumped[i].Name).To(Equal(expected[i].Name))
+				Expect(dumped[i].Namespace).To(Equal("test-namespace"))
+				Expect(dumped[i].Parameters).To(Equal(expected[i].Parameters))
+			}
+		})
+
+		It("should set the golden images namespace", func() {
+			out := &bytes.Buffer{}
+			Expect(DumpTemplates(out, bundleFile, "test-namespace", "test-golden-images")).To(Succeed())
+
+			dumped, err := decodeTemplates(out, decodeOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			found := false
+			for _, template := range dumped {
+				for _, parameter := range template.Parameters {
+					if parameter.Name == sourcePVCNamespaceParameter {
+						Expect(parameter.Value).To(Equal("test-golden-images"))
+						found = true
+					}
+				}
+			}
+			Expect(found).To(BeTrue())
+		})
+
+		It("should fail for empty or malformed bundle", func() {
+			dir, err := ioutil.TempDir("", "common-templates-bundle")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			emptyBundle := filepath.Join(dir, "empty.yaml")
+			Expect(ioutil.WriteFile(emptyBundle, []byte(""), 0644)).To(Succeed())
+			Expect(DumpTemplates(&bytes.Buffer{}, emptyBundle, "test-namespace", "")).
+				To(MatchError(ContainSubstring("no templates could be found")))
+
+			malformedBundle := filepath.Join(dir, "malformed.yaml")
+			Expect(ioutil.WriteFile(malformedBundle, []byte("invalid: [yaml"), 0644)).To(Succeed())
+			Expect(DumpTemplates(&bytes.Buffer{}, malformedBundle, "test-namespace", "")).To(HaveOccurred())
+		})
+	})
+
+	Context("bundle reload", func() {
+		var bundleFile string
+
+		writeBundle := func(names ...string) {
+			content := ""
+			for _, name := range names {
+				content += fmt.Sprintf(testTemplateYaml, name, Version)
+			}
+			Expect(ioutil.WriteFile(bundleFile, []byte(content), 0644)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "common-templates-bundle")
+			Expect(err).ToNot(HaveOccurred())
+			bundleFile = filepath.Join(dir, "bundle.yaml")
+
+			bundleLoader = newTemplatesLoader(bundleFile)
+			operand.(*commonTemplates).bundleLoader = bundleLoader
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(filepath.Dir(bundleFile))).To(Succeed())
+		})
+
+		It("should deploy templates added to the bundle file", func() {
+			writeBundle("test-template-1")
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate("test-template-1"), request)
+			ExpectResourceNotExists(newTestTemplate("test-template-2"), request)
+
+			writeBundle("test-template-1", "test-template-2")
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate("test-template-1"), request)
+			ExpectResourceExists(newTestTemplate("test-template-2"), request)
+		})
+
+		It("should keep previous templates if the bundle file becomes invalid", func() {
+			writeBundle("test-template-1")
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ioutil.WriteFile(bundleFile, []byte("invalid: [yaml"), 0644)).To(Succeed())
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(bundleLoader.Templates()).To(HaveLen(1))
+			Expect(bundleLoader.Templates()[0].Name).To(Equal("test-template-1"))
+		})
+
+		Context("bundle status", func() {
+			It("should report match after the bundle is loaded", func() {
+				writeBundle("test-template-1")
+				_, err := bundleLoader.Load(false, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				status := bundleLoader.Status()
+				Expect(status.Filename).To(Equal(bundleFile))
+				Expect(status.Matches).To(BeTrue())
+				Expect(status.FileChecksum).To(Equal(status.LoadedChecksum))
+				Expect(status.LastReload).ToNot(BeNil())
+				Expect(status.Error).To(BeEmpty())
+			})
+
+			It("should report mismatch until the changed bundle is reloaded", func() {
+				writeBundle("test-template-1")
+				_, err := bundleLoader.Load(false, false)
+				Expect(err).ToNot(HaveOccurred())
+				firstReload := *bundleLoader.Status().LastReload
+
+				writeBundle("test-template-1", "test-template-2")
+				status := bundleLoader.Status()
+				Expect(status.Matches).To(BeFalse())
+				Expect(status.FileChecksum).ToNot(Equal(status.LoadedChecksum))
+				Expect(*status.LastReload).To(Equal(firstReload))
+
+				_, err = bundleLoader.Load(false, false)
+				Expect(err).ToNot(HaveOccurred())
+				status = bundleLoader.Status()
+				Expect(status.Matches).To(BeTrue())
+				Expect(status.LastReload.Before(firstReload)).To(BeFalse())
+			})
+
+			It("should report mismatch if the bundle file is invalid", func() {
+				writeBundle("test-template-1")
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ioutil.WriteFile(bundleFile, []byte("invalid: [yaml"), 0644)).To(Succeed())
+				_, err = operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(bundleLoader.Status().Matches).To(BeFalse())
+			})
+
+			It("should report mismatch if nothing was loaded", func() {
+				writeBundle("test-template-1")
+
+				status := bundleLoader.Status()
+				Expect(status.Matches).To(BeFalse())
+				Expect(status.LoadedChecksum).To(BeEmpty())
+				Expect(status.LastReload).To(BeNil())
+			})
+
+			It("should report error if the bundle file does not exist", func() {
+				status := bundleLoader.Status()
+				Expect(status.Matches).To(BeFalse())
+				Expect(status.Error).ToNot(BeEmpty())
+			})
+
+			It("should serve status on the debug endpoint", func() {
+				writeBundle("test-template-1")
+				_, err := bundleLoader.Load(false, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				handler := operand.(operands.DebugHandlersProvider).DebugHandlers()[BundleStatusPath]
+				Expect(handler).ToNot(BeNil())
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, BundleStatusPath, nil))
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				served := bundleStatus{}
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &served)).To(Succeed())
+				Expect(served.Filename).To(Equal(bundleFile))
+				Expect(served.Matches).To(BeTrue())
+			})
+		})
+
+		Context("bundle validation", func() {
+			const templateWithoutOsYaml = `---
+apiVersion: template.openshift.io/v1
+kind: Template
+metadata:
+  name: %s
+  labels:
+    template.kubevirt.io/version: %s
+    flavor.template.kubevirt.io/test: "true"
+    workload.template.kubevirt.io/server: "true"
+objects: []
+`
+
+			It("should read valid bundle", func() {
+				writeBundle("test-template-1", "test-template-2")
+
+				templates, err := ReadTemplates(bundleFile, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(templates).To(HaveLen(2))
+			})
+
+			It("should fail to read empty bundle", func() {
+				writeBundle()
+
+				_, err := ReadTemplates(bundleFile, false)
+				Expect(err).To(MatchError(ContainSubstring("no templates could be found")))
+			})
+
+			It("should list templates with missing labels", func() {
+				content := fmt.Sprintf(testTemplateYaml, "test-template-1", Version) +
+					fmt.Sprintf(templateWithoutOsYaml, "test-broken-1", Version) +
+					fmt.Sprintf(templateWithoutOsYaml, "test-broken-2", Version)
+				Expect(ioutil.WriteFile(bundleFile, []byte(content), 0644)).To(Succeed())
+
+				_, err := ReadTemplates(bundleFile, false)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("2 malformed templates"))
+				Expect(err.Error()).To(ContainSubstring("template test-broken-1 is missing labels: " + TemplateTypeLabel + ", " + TemplateOsLabelPrefix + "*"))
+				Expect(err.Error()).To(ContainSubstring("template test-broken-2"))
+				Expect(err.Error()).ToNot(ContainSubstring("test-template-1"))
+			})
+
+			It("should not reconcile any template from invalid bundle", func() {
+				content := fmt.Sprintf(testTemplateYaml, "test-template-1", Version) +
+					fmt.Sprintf(templateWithoutOsYaml, "test-broken-1", Version)
+				Expect(ioutil.WriteFile(bundleFile, []byte(content), 0644)).To(Succeed())
+
+				_, err := operand.Reconcile(&request)
+				Expect(err).To(MatchError(ContainSubstring("template test-broken-1 is missing labels")))
+				ExpectResourceNotExists(newTestTemplate("test-template-1"), request)
+			})
+
+			It("should return error instead of panic if no bundle was loaded", func() {
+				writeBundle()
+
+				Expect(func() {
+					_, err := operand.Reconcile(&request)
+					Expect(err).To(MatchError(ContainSubstring("failed to load common templates bundle")))
+				}).ToNot(Panic())
+			})
+		})
+
+		Context("permissive bundle loading", func() {
+			const (
+				brokenTemplateYaml = "---\napiVersion: template.openshift.io/v1\nkind: Template\nmetadata:\n  name: broken\nobjects: 42\n"
+				invalidYaml        = "---\nkind: [Template\n"
+			)
+
+			var content string
+
+			BeforeEach(func() {
+				content = fmt.Sprintf(testTemplateYaml, "test-template-1", Version) +
+					brokenTemplateYaml +
+					fmt.Sprintf(testTemplateWithOsYaml, "test-without-os", Version, "test.kubevirt.io/label") +
+					invalidYaml +
+					fmt.Sprintf(testTemplateYaml, "test-template-2", Version)
+				Expect(ioutil.WriteFile(bundleFile, []byte(content), 0644)).To(Succeed())
+			})
+
+			It("should fail on first invalid document in strict mode", func() {
+				templates, _, err := readTemplatesFile(bundleFile, false, false)
+				Expect(templates).To(BeNil())
+				decodeErr := &TemplateDecodeError{}
+				Expect(errors.As(err, &decodeErr)).To(BeTrue())
+				Expect(decodeErr.Document).To(Equal(1))
+			})
+
+			It("should return templates from valid documents and list failed documents", func() {
+				templates, checksum, err := readTemplatesFile(bundleFile, false, true)
+				Expect(templates).To(HaveLen(2))
+				Expect(templates[0].Name).To(Equal("test-template-1"))
+				Expect(templates[1].Name).To(Equal("test-template-2"))
+				Expect(checksum).To(Equal(sha256.Sum256([]byte(content))))
+
+				partialErr := &PartialBundleError{}
+				Expect(errors.As(err, &partialErr)).To(BeTrue())
+				Expect(partialErr.Documents).To(Equal([]int{1, 2, 3}))
+				Expect(err.Error()).To(ContainSubstring("template test-without-os is missing labels"))
+			})
+
+			It("should fail if no document is valid", func() {
+				Expect(ioutil.WriteFile(bundleFile, []byte(brokenTemplateYaml), 0644)).To(Succeed())
+
+				templates, _, err := readTemplatesFile(bundleFile, false, true)
+				Expect(templates).To(BeNil())
+				Expect(err).To(MatchError(ContainSubstring("no templates could be found")))
+			})
+
+			It("should stop at invalid JSON stream and keep decoded templates", func() {
+				stream := `{"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": "template-1"}}
+{"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": `
+
+				templates, err := decodeTemplates(strings.NewReader(stream), decodeOptions{permissive: true})
+				Expect(templates).To(HaveLen(1))
+				Expect(templates[0].Name).To(Equal("template-1"))
+				partialErr := &PartialBundleError{}
+				Expect(errors.As(err, &partialErr)).To(BeTrue())
+				Expect(partialErr.Documents).To(Equal([]int{1}))
+			})
+
+			It("should deploy templates from valid documents with degraded status", func() {
+				request.Instance.Spec.CommonTemplates.PermissiveBundleLoading = true
+
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				ExpectResourceExists(newTestTemplate("test-template-1"), request)
+				ExpectResourceExists(newTestTemplate("test-template-2"), request)
+
+				var degraded []string
+				for _, status := range statuses {
+					if status.Degraded != nil {
+						degraded = append(degraded, *status.Degraded)
+					}
+				}
+				Expect(degraded).To(HaveLen(1))
+				Expect(degraded[0]).To(ContainSubstring("documents [1 2 3]"))
+			})
+
+			It("should not deploy any template without permissive mode", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).To(HaveOccurred())
+				ExpectResourceNotExists(newTestTemplate("test-template-1"), request)
+			})
+		})
+
+		Context("bundle integrity", func() {
+			writeChecksum := func() {
+				data, err := ioutil.ReadFile(bundleFile)
+				Expect(err).ToNot(HaveOccurred())
+				checksum := sha256.Sum256(data)
+				content := hex.EncodeToString(checksum[:]) + "  bundle.yaml\n"
+				Expect(ioutil.WriteFile(checksumFilename(bundleFile), []byte(content), 0644)).To(Succeed())
+			}
+
+			It("should verify shipped bundle", func() {
+				templates, err := ReadTemplates(filepath.Join(BundleDir, "common-templates-"+Version+".yaml"), true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(templates).ToNot(BeEmpty())
+			})
+
+			It("should read bundle with matching checksum", func() {
+				writeBundle("test-template-1")
+				writeChecksum()
+
+				templates, err := ReadTemplates(bundleFile, true)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(templates).To(HaveLen(1))
+			})
+
+			It("should fail to read bundle with mismatched checksum", func() {
+				writeBundle("test-template-1")
+				writeChecksum()
+				writeBundle("test-template-1", "test-template-2")
+
+				_, err := ReadTemplates(bundleFile, true)
+				Expect(err).To(MatchError(ContainSubstring("is corrupted")))
+			})
+
+			It("should fail to read bundle without checksum file", func() {
+				writeBundle("test-template-1")
+
+				_, err := ReadTemplates(bundleFile, true)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should not verify checksum if disabled", func() {
+				writeBundle("test-template-1")
+
+				templates, err := ReadTemplates(bundleFile, false)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(templates).To(HaveLen(1))
+			})
+
+			It("should not deploy templates from mismatched bundle", func() {
+				request.Instance.Spec.CommonTemplates.VerifyBundleIntegrity = true
+				writeBundle("test-template-1")
+				writeChecksum()
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				ExpectResourceExists(newTestTemplate("test-template-1"), request)
+
+				writeBundle("test-template-1", "test-template-2")
+				_, err = operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				ExpectResourceNotExists(newTestTemplate("test-template-2"), request)
+			})
+		})
+
+		Context("template filters", func() {
+			writeBundleWithOs := func(templateOs map[string]string) {
+				content := ""
+				for name, os := range templateOs {
+					content += fmt.Sprintf(testTemplateWithOsYaml, name, Version, TemplateOsLabelPrefix+os)
+				}
+				Expect(ioutil.WriteFile(bundleFile, []byte(content), 0644)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				writeBundleWithOs(map[string]string{
+					"test-fedora":  "fedora33",
+					"test-centos":  "centos8",
+					"test-windows": "win10",
+				})
+			})
+
+			AfterEach(func() {
+				request.Instance.Spec.CommonTemplates.Filters = nil
+			})
+
+			It("should deploy all templates without filters", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-fedora"), request)
+				ExpectResourceExists(newTestTemplate("test-centos"), request)
+				ExpectResourceExists(newTestTemplate("test-windows"), request)
+			})
+
+			It("should deploy only matching templates", func() {
+				request.Instance.Spec.CommonTemplates.Filters = &ssp.TemplateFilters{
+					OperatingSystems: []string{"fedora33", "centos8"},
+				}
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-fedora"), request)
+				ExpectResourceExists(newTestTemplate("test-centos"), request)
+				ExpectResourceNotExists(newTestTemplate("test-windows"), request)
+			})
+
+			It("should delete deployed templates excluded by filters", func() {
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				ExpectResourceExists(newTestTemplate("test-windows"), request)
+
+				request.Instance.Spec.CommonTemplates.Filters = &ssp.TemplateFilters{
+					OperatingSystems: []string{"fedora33"},
+				}
+				_, err = operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-fedora"), request)
+				ExpectResourceNotExists(newTestTemplate("test-centos"), request)
+				ExpectResourceNotExists(newTestTemplate("test-windows"), request)
+			})
+
+			It("should not delete excluded templates not owned by the operator", func() {
+				userTemplate := newTestTemplate("test-windows")
+				userTemplate.Labels = map[string]string{
+					TemplateTypeLabel:    "base",
+					TemplateVersionLabel: Version,
+				}
+				Expect(request.Client.Create(request.Context, userTemplate)).To(Succeed())
+
+				request.Instance.Spec.CommonTemplates.Filters = &ssp.TemplateFilters{
+					OperatingSystems: []string{"fedora33"},
+				}
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-windows"), request)
+			})
+		})
+
+		Context("pruning removed templates", func() {
+			BeforeEach(func() {
+				request.Instance.Spec.CommonTemplates.PruneRemovedTemplates = true
+			})
+
+			AfterEach(func() {
+				request.Instance.Spec.CommonTemplates.PruneRemovedTemplates = false
+			})
+
+			It("should delete templates removed from the bundle", func() {
+				writeBundle("test-template-1", "test-template-2")
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				writeBundle("test-template-1")
+				_, err = operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-template-1"), request)
+				ExpectResourceNotExists(newTestTemplate("test-template-2"), request)
+			})
+
+			It("should not delete templates when pruning is disabled", func() {
+				request.Instance.Spec.CommonTemplates.PruneRemovedTemplates = false
+
+				writeBundle("test-template-1", "test-template-2")
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				writeBundle("test-template-1")
+				_, err = operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("test-template-2"), request)
+			})
+
+			It("should not delete templates not owned by the operator", func() {
+				writeBundle("test-template-1")
+
+				userTemplate := newTestTemplate("user-template")
+				userTemplate.Labels = map[string]string{
+					TemplateTypeLabel:    "base",
+					TemplateVersionLabel: Version,
+				}
+				Expect(request.Client.Create(request.Context, userTemplate)).To(Succeed())
+
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newTestTemplate("user-template"), request)
+			})
+		})
+	})
+
+	Context("listing old templates", func() {
+		newOldTemplate := func(name string, objectSize int) *templatev1.Template {
+			return &templatev1.Template{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      name,
 					Namespace: namespace,
-				},
-				Spec: ssp.SSPSpec{
-					CommonTemplates: ssp.CommonTemplates{
-						Namespace: namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel:               "not-latest",
+						TemplateTypeLabel:                  "base",
+						common.AppKubernetesNameLabel:      operandName,
+						common.AppKubernetesManagedByLabel: "ssp-operator",
 					},
 				},
-			},
-			Logger:       log,
-			VersionCache: common.VersionCache{},
+				Objects: []runtime.RawExtension{{
+					Raw: []byte(fmt.Sprintf(`{"kind":"VirtualMachine","metadata":{"annotations":{"data":"%s"}}}`, strings.Repeat("x", objectSize))),
+				}},
+			}
 		}
-	})
 
-	It("should create golden-images namespace", func() {
-		_, err := operand.Reconcile(&request)
-		Expect(err).ToNot(HaveOccurred())
-		ExpectResourceExists(newGoldenImagesNS(GoldenImagesNSname), request)
-	})
-	It("should create common-template resources", func() {
-		_, err := operand.Reconcile(&request)
-		Expect(err).ToNot(HaveOccurred())
-		Expect(templatesBundle).ToNot(BeNil())
-		for _, template := range templatesBundle {
-			template.Namespace = namespace
-			ExpectResourceExists(&template, request)
-		}
-	})
-	It("should create view role", func() {
-		_, err := operand.Reconcile(&request)
-		Expect(err).ToNot(HaveOccurred())
-		ExpectResourceExists(newViewRole(GoldenImagesNSname), request)
-	})
-	It("should create view role binding", func() {
-		_, err := operand.Reconcile(&request)
-		Expect(err).ToNot(HaveOccurred())
-		ExpectResourceExists(newViewRoleBinding(GoldenImagesNSname), request)
+		It("should deprecate old templates from all pages", func() {
+			const count = 2*templatesListPageSize + 10
+			for i := 0; i < count; i++ {
+				Expect(request.Client.Create(request.Context, newOldTemplate(fmt.Sprintf("old-template-%d", i), 10))).To(Succeed())
+			}
+			paginating := &paginatingClient{Client: request.Client}
+			request.Client = paginating
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(paginating.limits).To(Equal([]int64{templatesListPageSize, templatesListPageSize, templatesListPageSize}))
+			for i := 0; i < count; i++ {
+				template := newTestTemplate(fmt.Sprintf("old-template-%d", i))
+				ExpectResourceExists(template, request)
+				Expect(template.Annotations).To(HaveKeyWithValue(TemplateDeprecatedAnnotation, "true"))
+				Expect(template.Objects).To(HaveLen(1))
+			}
+		})
+
+		Measure("should keep memory bounded with many templates", func(b Benchmarker) {
+			const (
+				count      = 5000
+				objectSize = 4096
+			)
+			for i := 0; i < count; i++ {
+				Expect(request.Client.Create(request.Context, newOldTemplate(fmt.Sprintf("old-template-%d", i), objectSize))).To(Succeed())
+			}
+			request.Client = &paginatingClient{Client: request.Client}
+
+			memStats := runtime_.MemStats{}
+			runtime_.GC()
+			runtime_.GC()
+			runtime_.ReadMemStats(&memStats)
+			heapBefore := memStats.HeapAlloc
+
+			selector := labels.SelectorFromSet(labels.Set{TemplateTypeLabel: "base"})
+			templates, err := listTemplatesMetadata(&request, selector)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(templates).To(HaveLen(count))
+
+			// The second collection frees buffers cached in pools
+			runtime_.GC()
+			runtime_.GC()
+			runtime_.ReadMemStats(&memStats)
+			retained := int64(memStats.HeapAlloc) - int64(heapBefore)
+			runtime_.KeepAlive(templates)
+
+			b.RecordValue("retained heap (MiB)", float64(retained)/(1<<20))
+			// The template objects alone would take more than count * objectSize bytes
+			Expect(retained).To(BeNumerically("<", count*objectSize/2))
+		}, 1)
 	})
-	It("should create view role binding", func() {
-		_, err := operand.Reconcile(&request)
-		Expect(err).ToNot(HaveOccurred())
-		ExpectResourceExists(newEditRole(), request)
+
+	Context("bundle directory", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "bundle-dir")
+			Expect(err).ToNot(HaveOccurred())
+			content := fmt.Sprintf(testTemplateYaml, "alternate-template", Version)
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common-templates-"+Version+".yaml"), []byte(content), 0644)).To(Succeed())
+			Expect(os.Setenv(common.TemplatesBundleDirKey, dir)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			SetBundleDir("")
+			Expect(os.Unsetenv(common.TemplatesBundleDirKey)).To(Succeed())
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should load templates from directory set by environment variable", func() {
+			Expect(CheckBundleDir()).To(Succeed())
+
+			templates, err := GetOperand().(*commonTemplates).bundleLoader.Load(false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(templates).To(HaveLen(1))
+			Expect(templates[0].Name).To(Equal("alternate-template"))
+		})
+
+		It("should fail if the directory does not exist", func() {
+			Expect(os.Setenv(common.TemplatesBundleDirKey, filepath.Join(dir, "missing"))).To(Succeed())
+			Expect(CheckBundleDir()).To(MatchError(ContainSubstring(common.TemplatesBundleDirKey)))
+		})
+
+		It("should load templates from directory set after the operand was created", func() {
+			Expect(os.Unsetenv(common.TemplatesBundleDirKey)).To(Succeed())
+			operand := GetOperand().(*commonTemplates)
+
+			SetBundleDir(dir)
+			Expect(CheckBundleDir()).To(Succeed())
+
+			templates, err := operand.bundleLoader.Load(false, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(templates).To(HaveLen(1))
+			Expect(templates[0].Name).To(Equal("alternate-template"))
+		})
+
+		It("should prefer directory set by SetBundleDir over environment variable", func() {
+			missing := filepath.Join(dir, "missing")
+			SetBundleDir(missing)
+			Expect(bundleDir()).To(Equal(missing))
+			Expect(CheckBundleDir()).To(MatchError(ContainSubstring(missing)))
+		})
+
+		It("should use the default directory without environment variable", func() {
+			Expect(os.Unsetenv(common.TemplatesBundleDirKey)).To(Succeed())
+			Expect(bundleDir()).To(Equal(BundleDir))
+			Expect(CheckBundleDir()).To(Succeed())
+		})
 	})
 
 	Context("old templates", func() {
@@ -135,11 +4438,13 @@ var _ = Describe("Common-Templates operand", func() {
 					Name:      "test-tpl",
 					Namespace: request.Instance.Spec.CommonTemplates.Namespace,
 					Labels: map[string]string{
-						TemplateVersionLabel: "not-latest",
-						TemplateTypeLabel:    "base",
-						testOsLabel:          "true",
-						testFlavorLabel:      "true",
-						testWorkflowLabel:    "true",
+						TemplateVersionLabel:               "not-latest",
+						TemplateTypeLabel:                  "base",
+						common.AppKubernetesNameLabel:      operandName,
+						common.AppKubernetesManagedByLabel: "ssp-operator",
+						testOsLabel:                        "true",
+						testFlavorLabel:                    "true",
+						testWorkflowLabel:                  "true",
 					},
 					Annotations: map[string]string{},
 					OwnerReferences: []metav1.OwnerReference{{
@@ -192,6 +4497,66 @@ var _ = Describe("Common-Templates operand", func() {
 			Expect(updatedTpl.Labels[TemplateVersionLabel]).To(Equal("not-latest"), TemplateVersionLabel+" should equal not-latest")
 			Expect(updatedTpl.Annotations[TemplateDeprecatedAnnotation]).To(Equal("true"), TemplateDeprecatedAnnotation+" should not be empty")
 		})
+		It("should set deprecation time to old templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(oldTpl), updatedTpl)).To(Succeed())
+
+			deprecatedTime, err := time.Parse(time.RFC3339, updatedTpl.Annotations[TemplateDeprecatedTimeAnnotation])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deprecatedTime).To(BeTemporally("~", time.Now(), time.Minute))
+		})
+		It("should not change existing deprecation time", func() {
+			const deprecatedTime = "2020-01-01T00:00:00Z"
+			oldTpl.Annotations[TemplateDeprecatedTimeAnnotation] = deprecatedTime
+			Expect(request.Client.Update(request.Context, oldTpl)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(oldTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Annotations[TemplateDeprecatedTimeAnnotation]).To(Equal(deprecatedTime))
+		})
+		It("should mark display name and description of old templates once", func() {
+			oldTpl.Annotations[TemplateDisplayNameAnnotation] = "Fedora VM"
+			oldTpl.Annotations[TemplateDescriptionAnnotation] = "Template for Fedora"
+			Expect(request.Client.Update(request.Context, oldTpl)).To(Succeed())
+
+			for i := 0; i < 2; i++ {
+				request.VersionCache = common.VersionCache{}
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(oldTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Annotations).To(HaveKeyWithValue(TemplateDisplayNameAnnotation, DeprecatedTemplateMarker+"Fedora VM"))
+			Expect(updatedTpl.Annotations).To(HaveKeyWithValue(TemplateDescriptionAnnotation, DeprecatedTemplateMarker+"Template for Fedora"))
+		})
+		It("should not add display name to old templates without it", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(oldTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Annotations).ToNot(HaveKey(TemplateDisplayNameAnnotation))
+			Expect(updatedTpl.Annotations).ToNot(HaveKey(TemplateDescriptionAnnotation))
+		})
+		It("should not mark latest templates", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			var latestTemplates templatev1.TemplateList
+			Expect(request.Client.List(request.Context, &latestTemplates, client.MatchingLabels{TemplateVersionLabel: Version})).To(Succeed())
+			Expect(latestTemplates.Items).ToNot(BeEmpty())
+			for _, template := range latestTemplates.Items {
+				Expect(template.Annotations[TemplateDisplayNameAnnotation]).ToNot(HavePrefix(DeprecatedTemplateMarker), template.Name)
+				Expect(template.Annotations[TemplateDescriptionAnnotation]).ToNot(HavePrefix(DeprecatedTemplateMarker), template.Name)
+			}
+		})
 		It("should not remove labels from latest templates", func() {
 			_, err := operand.Reconcile(&request)
 			Expect(err).ToNot(HaveOccurred(), "reconciliation in order to update old template failed")
@@ -215,5 +4580,365 @@ var _ = Describe("Common-Templates operand", func() {
 				}
 			}
 		})
+		It("should not deprecate user templates labeled as base", func() {
+			userTpl := &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "user-base-template",
+					Namespace: request.Instance.Spec.CommonTemplates.Namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel: "not-latest",
+						TemplateTypeLabel:    "base",
+						testOsLabel:          "true",
+						testFlavorLabel:      "true",
+						testWorkflowLabel:    "true",
+					},
+				},
+			}
+			Expect(request.Client.Create(request.Context, userTpl)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(userTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Labels).To(Equal(userTpl.Labels))
+			Expect(updatedTpl.Annotations).ToNot(HaveKey(TemplateDeprecatedAnnotation))
+			Expect(updatedTpl.Annotations).ToNot(HaveKey(TemplateDeprecatedTimeAnnotation))
+
+			// The owned old template is still deprecated
+			updatedOldTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(oldTpl), updatedOldTpl)).To(Succeed())
+			Expect(updatedOldTpl.Annotations).To(HaveKeyWithValue(TemplateDeprecatedAnnotation, "true"))
+		})
+		It("should report skipped foreign template in an event", func() {
+			recorder := record.NewFakeRecorder(100)
+			request.Recorder = recorder
+
+			foreignTpl := &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foreign-base-template",
+					Namespace: request.Instance.Spec.CommonTemplates.Namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel: "v0.0.1-custom",
+						TemplateTypeLabel:    "base",
+						testOsLabel:          "true",
+					},
+				},
+			}
+			Expect(request.Client.Create(request.Context, foreignTpl)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(foreignTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Labels).To(Equal(foreignTpl.Labels))
+			Expect(updatedTpl.Annotations).ToNot(HaveKey(TemplateDeprecatedAnnotation))
+
+			Expect(recorder.Events).To(Receive(And(
+				ContainSubstring(ForeignTemplateSkippedReason),
+				ContainSubstring("Skipped foreign template "+request.Instance.Spec.CommonTemplates.Namespace+"/foreign-base-template"),
+			)))
+		})
+		It("should emit event once for each newly deprecated template", func() {
+			recorder := record.NewFakeRecorder(100)
+			request.Recorder = recorder
+
+			secondTpl := oldTpl.DeepCopy()
+			secondTpl.ObjectMeta = metav1.ObjectMeta{
+				Name:      "second-test-tpl",
+				Namespace: oldTpl.Namespace,
+				Labels:    oldTpl.Labels,
+			}
+			Expect(request.Client.Create(request.Context, secondTpl)).To(Succeed())
+			defer func() {
+				Expect(request.Client.Delete(request.Context, secondTpl)).To(Succeed())
+			}()
+
+			for i := 0; i < 3; i++ {
+				request.VersionCache = common.VersionCache{}
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			Expect(events).To(ConsistOf(
+				And(ContainSubstring(TemplateDeprecatedReason), ContainSubstring(oldTpl.Namespace+"/test-tpl from version not-latest")),
+				And(ContainSubstring(TemplateDeprecatedReason), ContainSubstring(oldTpl.Namespace+"/second-test-tpl from version not-latest")),
+			))
+		})
+		It("should not emit event for already deprecated template", func() {
+			recorder := record.NewFakeRecorder(100)
+			request.Recorder = recorder
+
+			oldTpl.Annotations[TemplateDeprecatedAnnotation] = "true"
+			Expect(request.Client.Update(request.Context, oldTpl)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.Events).ToNot(Receive())
+		})
+		It("should deprecate template with version label of an older bundle", func() {
+			olderTpl := &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "older-bundle-template",
+					Namespace: request.Instance.Spec.CommonTemplates.Namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel: "v0.13.1",
+						TemplateTypeLabel:    "base",
+						testOsLabel:          "true",
+					},
+				},
+			}
+			Expect(request.Client.Create(request.Context, olderTpl)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedTpl := &templatev1.Template{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(olderTpl), updatedTpl)).To(Succeed())
+			Expect(updatedTpl.Annotations).To(HaveKeyWithValue(TemplateDeprecatedAnnotation, "true"))
+			Expect(updatedTpl.Labels).ToNot(HaveKey(testOsLabel))
+		})
+		It("should list versions of older bundles", func() {
+			versions := olderBundleVersions(filepath.Join(BundleDir, "common-templates-"+Version+".yaml"))
+			Expect(versions).To(HaveKey("v0.13.1"))
+			Expect(versions).To(HaveKey("v0.12.2"))
+			Expect(versions).ToNot(HaveKey(Version))
+		})
+	})
+
+	Context("deprecated templates retention", func() {
+		var (
+			fakeClock        *clock.FakeClock
+			retentionOperand *commonTemplates
+			recorder         *record.FakeRecorder
+			expiredTpl       *templatev1.Template
+			recentTpl        *templatev1.Template
+		)
+
+		newOldTemplate := func(name, version string, deprecatedFor time.Duration) *templatev1.Template {
+			return &templatev1.Template{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels: map[string]string{
+						TemplateVersionLabel:               version,
+						TemplateTypeLabel:                  "base",
+						common.AppKubernetesNameLabel:      operandName,
+						common.AppKubernetesManagedByLabel: "ssp-operator",
+					},
+					Annotations: map[string]string{
+						TemplateDeprecatedAnnotation:     "true",
+						TemplateDeprecatedTimeAnnotation: fakeClock.Now().Add(-deprecatedFor).UTC().Format(time.RFC3339),
+					},
+				},
+			}
+		}
+
+		newVirtualMachine := func(name, templateName string) *unstructured.Unstructured {
+			vm := &unstructured.Unstructured{}
+			vm.SetGroupVersionKind(virtualMachineListGVK.GroupVersion().WithKind(virtualMachineKind))
+			vm.SetName(name)
+			vm.SetNamespace("vm-namespace")
+			vm.SetLabels(map[string]string{
+				VMTemplateNameLabel:      templateName,
+				VMTemplateNamespaceLabel: namespace,
+			})
+			return vm
+		}
+
+		BeforeEach(func() {
+			// The fake client needs VirtualMachine types registered in the scheme
+			vmGVK := virtualMachineListGVK.GroupVersion().WithKind(virtualMachineKind)
+			request.Client.Scheme().AddKnownTypeWithName(vmGVK, &unstructured.Unstructured{})
+			request.Client.Scheme().AddKnownTypeWithName(virtualMachineListGVK, &unstructured.UnstructuredList{})
+
+			fakeClock = clock.NewFakeClock(time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC))
+			retentionOperand = &commonTemplates{parallelism: 1, clock: fakeClock, bundleLoader: bundleLoader}
+			recorder = record.NewFakeRecorder(100)
+			request.Recorder = recorder
+
+			expiredTpl = newOldTemplate("expired-tpl", "v0.1.0", 48*time.Hour)
+			recentTpl = newOldTemplate("recent-tpl", "v0.2.0", time.Hour)
+			Expect(request.Client.Create(request.Context, expiredTpl)).To(Succeed())
+			Expect(request.Client.Create(request.Context, recentTpl)).To(Succeed())
+		})
+
+		It("should keep deprecated templates without retention", func() {
+			_, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate(expiredTpl.Name), request)
+			ExpectResourceExists(newTestTemplate(recentTpl.Name), request)
+		})
+
+		It("should delete only expired templates", func() {
+			request.Instance.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: 24 * time.Hour}
+
+			statuses, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceNotExists(newTestTemplate(expiredTpl.Name), request)
+			ExpectResourceExists(newTestTemplate(recentTpl.Name), request)
+
+			var deletedStatus *common.ResourceStatus
+			for i := range statuses {
+				if statuses[i].Resource.GetName() == expiredTpl.Name {
+					deletedStatus = &statuses[i]
+				}
+			}
+			Expect(deletedStatus).ToNot(BeNil())
+			Expect(deletedStatus.Degraded).To(BeNil())
+		})
+
+		It("should delete template when retention passes", func() {
+			request.Instance.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: 24 * time.Hour}
+
+			_, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(newTestTemplate(recentTpl.Name), request)
+
+			fakeClock.Step(23 * time.Hour)
+			_, err = retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(newTestTemplate(recentTpl.Name), request)
+
+			fakeClock.Step(time.Hour + time.Second)
+			_, err = retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceNotExists(newTestTemplate(recentTpl.Name), request)
+		})
+
+		It("should record deprecation time from the clock", func() {
+			template := newOldTemplate("new-old-tpl", "v0.3.0", 0)
+			delete(template.Annotations, TemplateDeprecatedTimeAnnotation)
+			Expect(request.Client.Create(request.Context, template)).To(Succeed())
+
+			_, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(template, request)
+			Expect(template.Annotations).To(HaveKeyWithValue(TemplateDeprecatedTimeAnnotation, fakeClock.Now().UTC().Format(time.RFC3339)))
+		})
+
+		It("should not delete expired templates used by VMs", func() {
+			request.Instance.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: 24 * time.Hour}
+			Expect(request.Client.Create(request.Context, newVirtualMachine("test-vm", expiredTpl.Name))).To(Succeed())
+
+			_, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newTestTemplate(expiredTpl.Name), request)
+
+			var event string
+			Expect(recorder.Events).To(Receive(&event))
+			Expect(event).To(ContainSubstring(DeprecatedTemplateInUseReason))
+			Expect(event).To(ContainSubstring(expiredTpl.Name))
+		})
+
+		It("should not delete templates of the current version", func() {
+			request.Instance.Spec.CommonTemplates.DeprecatedTemplatesRetention = &metav1.Duration{Duration: 24 * time.Hour}
+
+			_, err := retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			template := newTestTemplate(bundleLoader.Templates()[0].Name)
+			ExpectResourceExists(template, request)
+			template.Annotations[TemplateDeprecatedTimeAnnotation] = fakeClock.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+			Expect(request.Client.Update(request.Context, template)).To(Succeed())
+
+			_, err = retentionOperand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(template, request)
+		})
+	})
+})
+
+var _ = Describe("Templates bundle decoding", func() {
+	templateNames := func(templates []templatev1.Template) []string {
+		var names []string
+		for _, template := range templates {
+			names = append(names, template.Name)
+		}
+		return names
+	}
+
+	It("should decode multi-document YAML with comments and empty documents", func() {
+		bundle := "# Generated bundle\n---\n" +
+			fmt.Sprintf(testTemplateYaml, "template-1", Version) +
+			"---\n# empty document\n---\n" +
+			fmt.Sprintf(testTemplateYaml, "template-2", Version)
+
+		templates, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templateNames(templates)).To(Equal([]string{"template-1", "template-2"}))
+	})
+
+	It("should decode YAML with CRLF line endings", func() {
+		bundle := fmt.Sprintf(testTemplateYaml, "template-1", Version) +
+			fmt.Sprintf(testTemplateYaml, "template-2", Version)
+		bundle = strings.ReplaceAll(bundle, "\n", "\r\n")
+
+		templates, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templateNames(templates)).To(Equal([]string{"template-1", "template-2"}))
+	})
+
+	It("should decode JSON list of templates", func() {
+		bundle := `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": "template-1"}},
+    {"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": "template-2"}}
+  ]
+}`
+		templates, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templateNames(templates)).To(Equal([]string{"template-1", "template-2"}))
+	})
+
+	It("should decode stream of JSON templates", func() {
+		bundle := `{"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": "template-1"}}
+{"apiVersion": "template.openshift.io/v1", "kind": "Template", "metadata": {"name": "template-2"}}`
+
+		templates, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templateNames(templates)).To(Equal([]string{"template-1", "template-2"}))
+	})
+
+	It("should report document and template name that failed to decode", func() {
+		bundle := fmt.Sprintf(testTemplateYaml, "template-1", Version) +
+			"---\napiVersion: template.openshift.io/v1\nkind: Template\nmetadata:\n  name: broken\nobjects: 42\n"
+
+		_, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		decodeErr := &TemplateDecodeError{}
+		Expect(errors.As(err, &decodeErr)).To(BeTrue())
+		Expect(decodeErr.Document).To(Equal(1))
+		Expect(decodeErr.Name).To(Equal("broken"))
+	})
+
+	It("should reject documents that are not templates", func() {
+		bundle := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+
+		_, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		Expect(err).To(MatchError(ContainSubstring("unexpected kind")))
+	})
+
+	It("should reject duplicate template names", func() {
+		bundle := fmt.Sprintf(testTemplateYaml, "template-1", Version) +
+			fmt.Sprintf(testTemplateYaml, "template-2", Version) +
+			fmt.Sprintf(testTemplateYaml, "template-1", Version)
+
+		_, err := decodeTemplates(strings.NewReader(bundle), decodeOptions{})
+		decodeErr := &TemplateDecodeError{}
+		Expect(errors.As(err, &decodeErr)).To(BeTrue())
+		Expect(decodeErr.Document).To(Equal(2))
+		Expect(decodeErr.Name).To(Equal("template-1"))
+		Expect(err).To(MatchError(ContainSubstring("duplicate template name")))
 	})
 })
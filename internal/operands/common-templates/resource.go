@@ -2,44 +2,334 @@ package common_templates
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"os"
+	"strings"
 
 	templatev1 "github.com/openshift/api/template/v1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
 const (
+	templateKind = "Template"
+
 	ViewRoleName        = "os-images.kubevirt.io:view"
 	EditClusterRoleName = "os-images.kubevirt.io:edit"
+
+	// AggregatedViewClusterRoleName is the ClusterRole aggregated into the default view and edit ClusterRoles
+	AggregatedViewClusterRoleName = "os-images.kubevirt.io:view-aggregated"
+	AggregateToViewLabel          = "rbac.authorization.k8s.io/aggregate-to-view"
+	AggregateToEditLabel          = "rbac.authorization.k8s.io/aggregate-to-edit"
 )
 
-// ReadTemplates from the combined yaml file and return the list of its templates
-func ReadTemplates(filename string) ([]templatev1.Template, error) {
-	var bundle []templatev1.Template
-	file, err := ioutil.ReadFile(filename)
+var volumeSnapshotClassGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshotClass",
+}
+
+var migrationPolicyGVK = schema.GroupVersionKind{
+	Group:   "migrations.kubevirt.io",
+	Version: "v1alpha1",
+	Kind:    "MigrationPolicy",
+}
+
+var virtualMachineListGVK = schema.GroupVersionKind{
+	Group:   "kubevirt.io",
+	Version: "v1",
+	Kind:    "VirtualMachineList",
+}
+
+func newVirtualMachineList() *unstructured.UnstructuredList {
+	vms := &unstructured.UnstructuredList{}
+	vms.SetGroupVersionKind(virtualMachineListGVK)
+	return vms
+}
+
+var kubeVirtListGVK = schema.GroupVersionKind{
+	Group:   "kubevirt.io",
+	Version: "v1",
+	Kind:    "KubeVirtList",
+}
+
+func newKubeVirtList() *unstructured.UnstructuredList {
+	kubeVirts := &unstructured.UnstructuredList{}
+	kubeVirts.SetGroupVersionKind(kubeVirtListGVK)
+	return kubeVirts
+}
+
+var customResourceDefinitionGVK = schema.GroupVersionKind{
+	Group:   "apiextensions.k8s.io",
+	Version: "v1",
+	Kind:    "CustomResourceDefinition",
+}
+
+func newCustomResourceDefinition() *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(customResourceDefinitionGVK)
+	return crd
+}
+
+func newVolumeSnapshotClass() *unstructured.Unstructured {
+	snapshotClass := &unstructured.Unstructured{}
+	snapshotClass.SetGroupVersionKind(volumeSnapshotClassGVK)
+	return snapshotClass
+}
+
+func newMigrationPolicy() *unstructured.Unstructured {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(migrationPolicyGVK)
+	return policy
+}
+
+// ReadTemplates from the combined yaml file and return the list of its templates.
+// Templates without the required labels are reported as an error.
+// If verifyIntegrity is true, the file is verified against its checksum file.
+func ReadTemplates(filename string, verifyIntegrity bool) ([]templatev1.Template, error) {
+	templates, _, err := readTemplatesFile(filename, verifyIntegrity, false)
+	return templates, err
+}
+
+// readTemplatesFile decodes the bundle file one document at a time, and returns its templates
+// together with the SHA-256 checksum of the file. In permissive mode, templates from valid
+// documents are returned with an error wrapping PartialBundleError.
+func readTemplatesFile(filename string, verifyIntegrity, permissive bool) ([]templatev1.Template, [sha256.Size]byte, error) {
+	var checksum [sha256.Size]byte
+	file, err := os.Open(filename)
 	if err != nil {
+		return nil, checksum, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	templates, parseErr := parseTemplates(io.TeeReader(file, hash), permissive)
+	// Decoding may stop early, the rest of the file is read so the checksum covers all of it
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, checksum, err
+	}
+	copy(checksum[:], hash.Sum(nil))
+
+	if verifyIntegrity {
+		if err := verifyBundleChecksum(filename, checksum); err != nil {
+			return nil, checksum, err
+		}
+	}
+	if parseErr != nil {
+		return templates, checksum, fmt.Errorf("invalid templates bundle %s: %w", filename, parseErr)
+	}
+	return templates, checksum, nil
+}
+
+// parseTemplates decodes and validates the templates bundle.
+// An empty bundle is not valid.
+func parseTemplates(reader io.Reader, permissive bool) ([]templatev1.Template, error) {
+	templates, err := decodeTemplates(reader, decodeOptions{validate: true, permissive: permissive})
+	if len(templates) == 0 && (err == nil || permissive) {
+		if err != nil {
+			return nil, fmt.Errorf("no templates could be found in the bundle: %w", err)
+		}
+		return nil, fmt.Errorf("no templates could be found in the bundle")
+	}
+	if err != nil && !permissive {
 		return nil, err
 	}
-	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(file), 1024)
-	for {
-		template := templatev1.Template{}
-		err = decoder.Decode(&template)
+	return templates, err
+}
+
+// TemplateDecodeError is returned when a document in the bundle cannot be decoded
+type TemplateDecodeError struct {
+	// Document is the index of the document in the bundle, starting from 0
+	Document int
+	// Name is the name of the template, if it is known
+	Name string
+	Err  error
+}
+
+func (e *TemplateDecodeError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("failed to decode document %d in templates bundle: %v", e.Document, e.Err)
+	}
+	return fmt.Sprintf("failed to decode template %s in document %d in templates bundle: %v", e.Name, e.Document, e.Err)
+}
+
+func (e *TemplateDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// PartialBundleError is returned in permissive mode, if some documents of the bundle
+// cannot be decoded or contain malformed templates. Templates from the other documents
+// are returned with it.
+type PartialBundleError struct {
+	// Documents are the indices of the failed documents, starting from 0
+	Documents []int
+	Errs      []error
+}
+
+func (e *PartialBundleError) Error() string {
+	messages := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d documents in templates bundle failed: %s", len(e.Documents), strings.Join(messages, "; "))
+}
+
+func (e *PartialBundleError) add(document int, err error) {
+	if len(e.Documents) == 0 || e.Documents[len(e.Documents)-1] != document {
+		e.Documents = append(e.Documents, document)
+	}
+	e.Errs = append(e.Errs, err)
+}
+
+// bundleDocument contains the fields needed to identify a document in the bundle
+type bundleDocument struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Items []json.RawMessage `json:"items"`
+}
+
+type decodeOptions struct {
+	// validate checks each decoded template, see ValidateTemplates
+	validate bool
+	// permissive skips failed documents, instead of failing the whole bundle
+	permissive bool
+}
+
+// decodeTemplates decodes a stream of YAML or JSON documents. A document can be
+// a template, or a list of templates. Empty documents are skipped.
+// Documents are decoded one at a time, so only the current document
+// is kept in memory besides the decoded templates.
+func decodeTemplates(reader io.Reader, opts decodeOptions) ([]templatev1.Template, error) {
+	var bundle []templatev1.Template
+	var problems []string
+	malformed := 0
+	partial := &PartialBundleError{}
+	documentNames := map[string]int{}
+
+	result := func() ([]templatev1.Template, error) {
+		if len(partial.Errs) > 0 {
+			return bundle, partial
+		}
+		if len(problems) > 0 {
+			return nil, fmt.Errorf("%d malformed templates: %s", malformed, strings.Join(problems, "; "))
+		}
+		return bundle, nil
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 1024)
+	for index := 0; ; index++ {
+		raw := json.RawMessage{}
+		err := decoder.Decode(&raw)
 		if err == io.EOF {
-			return bundle, nil
+			return result()
 		}
 		if err != nil {
-			return nil, err
+			decodeErr := &TemplateDecodeError{Document: index, Err: err}
+			if !opts.permissive {
+				return nil, decodeErr
+			}
+			partial.add(index, decodeErr)
+			if !canContinueDecoding(err) {
+				return result()
+			}
+			continue
+		}
+
+		templates, decodeErr := decodeDocument(raw)
+		if decodeErr != nil {
+			decodeErr.Document = index
+			if !opts.permissive {
+				return nil, decodeErr
+			}
+			partial.add(index, decodeErr)
+			continue
 		}
-		if template.Name != "" {
+		for _, template := range templates {
+			if previous, exists := documentNames[template.Name]; exists {
+				duplicateErr := &TemplateDecodeError{
+					Document: index,
+					Name:     template.Name,
+					Err:      fmt.Errorf("duplicate template name, first defined in document %d", previous),
+				}
+				if !opts.permissive {
+					return nil, duplicateErr
+				}
+				partial.add(index, duplicateErr)
+				continue
+			}
+
+			if opts.validate {
+				if templateProblems := validateTemplate(&template, len(bundle)+malformed); len(templateProblems) > 0 {
+					malformed++
+					if opts.permissive {
+						partial.add(index, fmt.Errorf("document %d: %s", index, strings.Join(templateProblems, ", ")))
+					} else {
+						problems = append(problems, templateProblems...)
+					}
+					continue
+				}
+			}
+			documentNames[template.Name] = index
 			bundle = append(bundle, template)
 		}
 	}
 }
 
+// canContinueDecoding returns false, if the decoding error is caused by the stream itself.
+// Errors in a single YAML document do not prevent decoding of the following documents.
+func canContinueDecoding(err error) bool {
+	syntaxErr := &json.SyntaxError{}
+	return !errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func decodeDocument(raw json.RawMessage) ([]templatev1.Template, *TemplateDecodeError) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+
+	document := bundleDocument{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, &TemplateDecodeError{Err: err}
+	}
+
+	if document.Kind == "List" || document.Kind == "TemplateList" {
+		var templates []templatev1.Template
+		for _, item := range document.Items {
+			itemTemplates, err := decodeDocument(item)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, itemTemplates...)
+		}
+		return templates, nil
+	}
+
+	name := document.Metadata.Name
+	if document.Kind != templateKind {
+		return nil, &TemplateDecodeError{Name: name, Err: fmt.Errorf("unexpected kind \"%s\"", document.Kind)}
+	}
+	if name == "" {
+		return nil, &TemplateDecodeError{Err: fmt.Errorf("template has no name")}
+	}
+
+	template := templatev1.Template{}
+	if err := json.Unmarshal(raw, &template); err != nil {
+		return nil, &TemplateDecodeError{Name: name, Err: err}
+	}
+	return []templatev1.Template{template}, nil
+}
+
 func newGoldenImagesNS(namespace string) *core.Namespace {
 	return &core.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -70,6 +360,11 @@ func newViewRole(namespace string) *rbac.Role {
 				Resources: []string{"datavolumes/source"},
 				Verbs:     []string{"create"},
 			},
+			{
+				APIGroups: []string{CdiApiGroup},
+				Resources: []string{"datasources", "dataimportcrons"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
 			{
 				APIGroups: []string{core.GroupName},
 				Resources: []string{"namespaces"},
@@ -105,6 +400,33 @@ func newViewRoleBinding(namespace string) *rbac.RoleBinding {
 	}
 }
 
+// newAggregatedViewRole returns the ClusterRole with read access to golden images.
+// ClusterRoles cannot be limited to a namespace, so the rules apply in all namespaces
+// where the aggregating view or edit ClusterRole is bound.
+func newAggregatedViewRole() *rbac.ClusterRole {
+	return &rbac.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: AggregatedViewClusterRoleName,
+			Labels: map[string]string{
+				AggregateToViewLabel: "true",
+				AggregateToEditLabel: "true",
+			},
+		},
+		Rules: []rbac.PolicyRule{
+			{
+				APIGroups: []string{core.GroupName},
+				Resources: []string{"persistentvolumeclaims", "persistentvolumeclaims/status"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{CdiApiGroup},
+				Resources: []string{"datavolumes"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	}
+}
+
 func newEditRole() *rbac.ClusterRole {
 	return &rbac.ClusterRole{
 		ObjectMeta: metav1.ObjectMeta{
@@ -131,6 +453,11 @@ func newEditRole() *rbac.ClusterRole {
 				Resources: []string{"datavolumes/source"},
 				Verbs:     []string{"create"},
 			},
+			{
+				APIGroups: []string{CdiApiGroup},
+				Resources: []string{"datasources", "dataimportcrons"},
+				Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+			},
 		},
 	}
 }
@@ -0,0 +1,123 @@
+package common_templates
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+// maxFailedTemplateNames is the maximum number of failed templates listed in the SSP status
+const maxFailedTemplateNames = 10
+
+// templatesSummary counts the results of template reconciliation for the SSP status
+type templatesSummary struct {
+	deployed   int32
+	deprecated int
+
+	// Changes counted for the upgrade summary
+	added           int32
+	updated         int32
+	newlyDeprecated int32
+}
+
+// countAdded counts a template created by the reconciliation
+func (s *templatesSummary) countAdded() {
+	atomic.AddInt32(&s.added, 1)
+}
+
+// countUpdated counts a template updated from an older bundle version
+func (s *templatesSummary) countUpdated() {
+	atomic.AddInt32(&s.updated, 1)
+}
+
+// countDeprecated counts a template from an older bundle, that was deprecated by the reconciliation
+func (s *templatesSummary) countDeprecated() {
+	atomic.AddInt32(&s.newlyDeprecated, 1)
+}
+
+// countDeployed wraps the functions, so each template reconciled without error
+// and without degraded status is counted as deployed.
+func (s *templatesSummary) countDeployed(funcs []common.ReconcileFunc) []common.ReconcileFunc {
+	wrapped := make([]common.ReconcileFunc, 0, len(funcs))
+	for _, f := range funcs {
+		f := f
+		wrapped = append(wrapped, func(request *common.Request) (common.ResourceStatus, error) {
+			status, err := f(request)
+			if err == nil && status.Degraded == nil {
+				atomic.AddInt32(&s.deployed, 1)
+			}
+			return status, err
+		})
+	}
+	return wrapped
+}
+
+// withFailedStatus wraps the function, so a failed reconciliation
+// returns a status referencing the template and its error.
+func withFailedStatus(template client.Object, f common.ReconcileFunc) common.ReconcileFunc {
+	return func(request *common.Request) (common.ResourceStatus, error) {
+		status, err := f(request)
+		if err != nil {
+			return common.FailedResourceStatus(template, err), err
+		}
+		return status, nil
+	}
+}
+
+// resourceStatus returns the status passing the summary to the SSP status.
+// The namespace is where the templates are deployed and templateStatuses
+// are the statuses of reconciled templates, including the failed ones.
+func (s *templatesSummary) resourceStatus(request *common.Request, namespace string, templateStatuses []common.ResourceStatus, err error) common.ResourceStatus {
+	summary := &ssp.CommonTemplatesStatus{
+		Version:             Version,
+		Namespace:           namespace,
+		DeployedTemplates:   int(atomic.LoadInt32(&s.deployed)),
+		DeprecatedTemplates: s.deprecated,
+		FailedTemplateNames: failedTemplateNames(templateStatuses),
+	}
+	if errs := templateErrors(err); len(errs) > 0 {
+		summary.FailedTemplates = len(errs)
+		summary.FirstError = errs[0].Error()
+	}
+	var degraded common.StatusMessage
+	if summary.FailedTemplates > 0 {
+		msg := fmt.Sprintf("Failed to reconcile %d templates: %s",
+			summary.FailedTemplates, strings.Join(summary.FailedTemplateNames, ", "))
+		degraded = &msg
+	}
+	return common.ResourceStatus{
+		Resource:        request.Instance,
+		Degraded:        degraded,
+		CommonTemplates: summary,
+		Upgrade: &ssp.UpgradeSummary{
+			AddedTemplates:      int(atomic.LoadInt32(&s.added)),
+			UpdatedTemplates:    int(atomic.LoadInt32(&s.updated)),
+			DeprecatedTemplates: int(atomic.LoadInt32(&s.newlyDeprecated)),
+		},
+	}
+}
+
+// failedTemplateNames returns names of the failed templates, the names
+// over maxFailedTemplateNames are replaced by their count.
+func failedTemplateNames(templateStatuses []common.ResourceStatus) []string {
+	var names []string
+	failed := 0
+	for _, status := range templateStatuses {
+		if status.Error == nil || status.ObjectRef == nil {
+			continue
+		}
+		failed++
+		if len(names) < maxFailedTemplateNames {
+			names = append(names, status.ObjectRef.Name)
+		}
+	}
+	if failed > len(names) {
+		names = append(names, fmt.Sprintf("+%d more", failed-len(names)))
+	}
+	return names
+}
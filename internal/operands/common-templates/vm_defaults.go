@@ -0,0 +1,340 @@
+package common_templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
+)
+
+const (
+	virtualMachineKind = "VirtualMachine"
+
+	// VMSnapshotClassAnnotation selects the VolumeSnapshotClass used for snapshots of the VM
+	VMSnapshotClassAnnotation = "snapshot.kubevirt.io/volume-snapshot-class"
+)
+
+var (
+	// autoattachMemBalloonPath is the path of the field enabling memory ballooning in a VirtualMachine
+	autoattachMemBalloonPath = []string{"spec", "template", "spec", "domain", "devices", "autoattachMemBalloon"}
+
+	bootloaderPath    = []string{"spec", "template", "spec", "domain", "firmware", "bootloader"}
+	efiSecureBootPath = append(append([]string{}, bootloaderPath...), "efi", "secureBoot")
+	smmEnabledPath    = []string{"spec", "template", "spec", "domain", "features", "smm", "enabled"}
+
+	accessCredentialsPath = []string{"spec", "template", "spec", "accessCredentials"}
+	dedicatedCPUPath      = []string{"spec", "template", "spec", "domain", "cpu", "dedicatedCpuPlacement"}
+	disableHotplugPath    = []string{"spec", "template", "spec", "domain", "devices", "disableHotplug"}
+	tpmPath               = []string{"spec", "template", "spec", "domain", "devices", "tpm"}
+	vmiLabelsPath         = []string{"spec", "template", "metadata", "labels"}
+	schedulerNamePath     = []string{"spec", "template", "spec", "schedulerName"}
+)
+
+// secureBootConflictError is returned if secure boot should be enabled in a VM that uses BIOS
+type secureBootConflictError struct {
+	vmName string
+}
+
+func (e *secureBootConflictError) Error() string {
+	return fmt.Sprintf("secure boot requires EFI, but VirtualMachine %s uses BIOS", e.vmName)
+}
+
+func isSecureBootConflict(err error) bool {
+	conflict := &secureBootConflictError{}
+	return errors.As(err, &conflict)
+}
+
+type vmUpdateFunc = func(vm *unstructured.Unstructured) error
+
+// vmDefaults are applied to VirtualMachine objects in common templates.
+// Values already defined by a template are not overwritten.
+type vmDefaults struct {
+	labels           map[string]string
+	annotations      map[string]string
+	snapshotClass    string
+	memoryBallooning *bool
+	secureBootByOS   map[string]bool
+	tpmByOS          map[string]bool
+
+	// migrationPolicyLabels are selected by the default migration policy
+	migrationPolicyLabels map[string]string
+	accessCredentials     *ssp.DefaultAccessCredentials
+	dedicatedCPUWorkloads []string
+	hotplugWorkloads      []string
+	schedulerByWorkload   map[string]string
+}
+
+func newVMDefaults(spec *ssp.CommonTemplates) *vmDefaults {
+	return &vmDefaults{
+		labels:           spec.DefaultVMLabels,
+		annotations:      spec.DefaultVMAnnotations,
+		snapshotClass:    spec.DefaultSnapshotClass,
+		memoryBallooning: spec.DefaultMemoryBallooning,
+		secureBootByOS:   spec.SecureBootByOS,
+		tpmByOS:          spec.TPMByOS,
+
+		accessCredentials:     spec.DefaultAccessCredentials,
+		dedicatedCPUWorkloads: spec.DedicatedCPUForWorkloads,
+		hotplugWorkloads:      spec.EnableHotplugForWorkloads,
+		schedulerByWorkload:   spec.SchedulerByWorkload,
+	}
+}
+
+// apply sets the defaults to VirtualMachine objects in the template.
+func (d *vmDefaults) apply(template *templatev1.Template) error {
+	secureBoot := osDefault(template, d.secureBootByOS)
+	tpm := osDefault(template, d.tpmByOS)
+	setAccessCredentials := d.accessCredentialsMatch(template)
+	setDedicatedCPU := workloadMatch(template, d.dedicatedCPUWorkloads)
+	enableHotplug := workloadMatch(template, d.hotplugWorkloads)
+	schedulerName := workloadScheduler(template, d.schedulerByWorkload)
+	return updateTemplateVMs(template, func(vm *unstructured.Unstructured) error {
+		vm.SetLabels(mergeDefaults(vm.GetLabels(), d.labels))
+		vm.SetAnnotations(mergeDefaults(vm.GetAnnotations(), d.annotations))
+		if d.snapshotClass != "" {
+			vm.SetAnnotations(mergeDefaults(vm.GetAnnotations(), map[string]string{
+				VMSnapshotClassAnnotation: d.snapshotClass,
+			}))
+		}
+		if len(d.migrationPolicyLabels) > 0 {
+			if err := setDefaultVMILabels(vm, d.migrationPolicyLabels); err != nil {
+				return err
+			}
+		}
+		if d.memoryBallooning != nil {
+			if err := setDefaultField(vm, *d.memoryBallooning, autoattachMemBalloonPath...); err != nil {
+				return err
+			}
+		}
+		if secureBoot != nil {
+			if err := setDefaultSecureBoot(vm, *secureBoot); err != nil {
+				return err
+			}
+		}
+		if tpm != nil {
+			if err := setDefaultField(vm, newTPMDevice(*tpm), tpmPath...); err != nil {
+				return err
+			}
+		}
+		if setAccessCredentials {
+			if err := setDefaultField(vm, newAccessCredentials(d.accessCredentials), accessCredentialsPath...); err != nil {
+				return err
+			}
+		}
+		if setDedicatedCPU {
+			if err := setDefaultField(vm, true, dedicatedCPUPath...); err != nil {
+				return err
+			}
+		}
+		if enableHotplug {
+			if err := setDefaultField(vm, false, disableHotplugPath...); err != nil {
+				return err
+			}
+		}
+		if schedulerName != "" {
+			if err := setDefaultField(vm, schedulerName, schedulerNamePath...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// accessCredentialsMatch returns true, if default access credentials should be set in the template
+func (d *vmDefaults) accessCredentialsMatch(template *templatev1.Template) bool {
+	if d.accessCredentials == nil {
+		return false
+	}
+	if len(d.accessCredentials.OperatingSystems) == 0 {
+		return true
+	}
+	for _, osName := range d.accessCredentials.OperatingSystems {
+		if template.Labels[TemplateOsLabelPrefix+osName] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// workloadMatch returns true, if the template is labeled with one of the workloads
+func workloadMatch(template *templatev1.Template, workloads []string) bool {
+	for _, workload := range workloads {
+		if template.Labels[TemplateWorkloadLabelPrefix+workload] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// workloadScheduler returns the scheduler name for the first workload of the template, in alphabetical order
+func workloadScheduler(template *templatev1.Template, schedulerByWorkload map[string]string) string {
+	workloads := make([]string, 0, len(schedulerByWorkload))
+	for workload := range schedulerByWorkload {
+		workloads = append(workloads, workload)
+	}
+	sort.Strings(workloads)
+	for _, workload := range workloads {
+		if template.Labels[TemplateWorkloadLabelPrefix+workload] == "true" {
+			return schedulerByWorkload[workload]
+		}
+	}
+	return ""
+}
+
+// newAccessCredentials returns the VM access credentials propagating keys from the secret
+func newAccessCredentials(defaults *ssp.DefaultAccessCredentials) []interface{} {
+	users := make([]interface{}, 0, len(defaults.Users))
+	for _, user := range defaults.Users {
+		users = append(users, user)
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"sshPublicKey": map[string]interface{}{
+				"source": map[string]interface{}{
+					"secret": map[string]interface{}{
+						"secretName": defaults.SecretName,
+					},
+				},
+				"propagationMethod": map[string]interface{}{
+					"qemuGuestAgent": map[string]interface{}{
+						"users": users,
+					},
+				},
+			},
+		},
+	}
+}
+
+// osDefault returns the default for operating systems of the template, or nil if none is configured.
+// If any of them has the feature enabled, it is enabled.
+func osDefault(template *templatev1.Template, byOS map[string]bool) *bool {
+	var result *bool
+	for label, value := range template.Labels {
+		if value != "true" || !strings.HasPrefix(label, TemplateOsLabelPrefix) {
+			continue
+		}
+		enabled, ok := byOS[strings.TrimPrefix(label, TemplateOsLabelPrefix)]
+		if !ok {
+			continue
+		}
+		if enabled || result == nil {
+			result = &enabled
+		}
+	}
+	return result
+}
+
+// newTPMDevice returns the TPM device of a VM. An enabled TPM keeps its state in a persistent volume.
+func newTPMDevice(enabled bool) map[string]interface{} {
+	if enabled {
+		return map[string]interface{}{"persistent": true}
+	}
+	return map[string]interface{}{"enabled": false}
+}
+
+// setDefaultSecureBoot sets secure boot, if it is not set in the VM.
+// Secure boot requires EFI and SMM, so they are enabled too.
+func setDefaultSecureBoot(vm *unstructured.Unstructured, enabled bool) error {
+	bootloader, _, err := unstructured.NestedMap(vm.Object, bootloaderPath...)
+	if err != nil {
+		return err
+	}
+	if _, usesBios := bootloader["bios"]; usesBios {
+		if enabled {
+			return &secureBootConflictError{vmName: vm.GetName()}
+		}
+		return nil
+	}
+	if _, usesEfi := bootloader["efi"]; !usesEfi && !enabled {
+		return nil
+	}
+
+	if err := setDefaultField(vm, enabled, efiSecureBootPath...); err != nil {
+		return err
+	}
+	secureBoot, _, err := unstructured.NestedBool(vm.Object, efiSecureBootPath...)
+	if err != nil || !secureBoot {
+		return err
+	}
+	return setDefaultField(vm, true, smmEnabledPath...)
+}
+
+// updateTemplateVMs calls updateFunc for each VirtualMachine object in the template.
+// The raw object is only re-encoded if it was changed.
+func updateTemplateVMs(template *templatev1.Template, updateFunc vmUpdateFunc) error {
+	for i := range template.Objects {
+		object := &template.Objects[i]
+		if object.Raw == nil {
+			continue
+		}
+
+		vm := &unstructured.Unstructured{}
+		if err := json.Unmarshal(object.Raw, &vm.Object); err != nil {
+			return fmt.Errorf("failed to decode object %d in template %s: %w", i, template.Name, err)
+		}
+		if vm.GetKind() != virtualMachineKind {
+			continue
+		}
+
+		original := runtime.DeepCopyJSON(vm.Object)
+		if err := updateFunc(vm); err != nil {
+			return fmt.Errorf("failed to update object %d in template %s: %w", i, template.Name, err)
+		}
+		if equality.Semantic.DeepEqual(original, vm.Object) {
+			continue
+		}
+
+		raw, err := json.Marshal(vm.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode object %d in template %s: %w", i, template.Name, err)
+		}
+		object.Raw = raw
+		object.Object = nil
+	}
+	return nil
+}
+
+// setDefaultField sets the value of the field, if it is not set in the VM
+func setDefaultField(vm *unstructured.Unstructured, value interface{}, fields ...string) error {
+	_, found, err := unstructured.NestedFieldNoCopy(vm.Object, fields...)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+	return unstructured.SetNestedField(vm.Object, value, fields...)
+}
+
+// setDefaultVMILabels adds labels to the VirtualMachine instance template, without overwriting existing keys
+func setDefaultVMILabels(vm *unstructured.Unstructured, labels map[string]string) error {
+	vmiLabels, _, err := unstructured.NestedStringMap(vm.Object, vmiLabelsPath...)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedStringMap(vm.Object, mergeDefaults(vmiLabels, labels), vmiLabelsPath...)
+}
+
+// mergeDefaults adds defaults to values, without overwriting existing keys
+func mergeDefaults(values, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return values
+	}
+	if values == nil {
+		values = make(map[string]string, len(defaults))
+	}
+	for key, val := range defaults {
+		if _, exists := values[key]; !exists {
+			values[key] = val
+		}
+	}
+	return values
+}
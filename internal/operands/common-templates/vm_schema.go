@@ -0,0 +1,162 @@
+package common_templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+// VirtualMachineCRDName is the name of the CRD defining the VirtualMachine API in the cluster
+const VirtualMachineCRDName = "virtualmachines.kubevirt.io"
+
+// checkRemovedVMFields returns a degraded status listing templates with VirtualMachine objects,
+// that use fields not defined in the VirtualMachine API schema installed in the cluster.
+// Such templates are still deployed, but VMs created from them may fail or lose the fields.
+func checkRemovedVMFields(request *common.Request, templates []templatev1.Template) (*common.ResourceStatus, error) {
+	crd := newCustomResourceDefinition()
+	err := request.Client.Get(request.Context, client.ObjectKey{Name: VirtualMachineCRDName}, crd)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		// KubeVirt is not installed, so there is no schema to validate against
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schemas, err := vmSchemasByVersion(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for i := range templates {
+		fields, err := unknownVMFields(&templates[i], schemas)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			problems = append(problems, fmt.Sprintf("%s (%s)", templates[i].Name, strings.Join(fields, ", ")))
+		}
+	}
+	if len(problems) == 0 {
+		return nil, nil
+	}
+
+	msg := fmt.Sprintf("Templates use fields not defined in the installed VirtualMachine API: %s", strings.Join(problems, "; "))
+	request.Logger.Info(msg)
+	return &common.ResourceStatus{
+		Resource: request.Instance,
+		Degraded: &msg,
+	}, nil
+}
+
+// vmSchemasByVersion returns the OpenAPI schema of each served version of the VirtualMachine CRD
+func vmSchemasByVersion(crd *unstructured.Unstructured) (map[string]map[string]interface{}, error) {
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[string]map[string]interface{}, len(versions))
+	for _, item := range versions {
+		version, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if served, found, _ := unstructured.NestedBool(version, "served"); found && !served {
+			continue
+		}
+		name, _, err := unstructured.NestedString(version, "name")
+		if err != nil {
+			return nil, err
+		}
+		openAPISchema, found, err := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			schemas[name] = openAPISchema
+		}
+	}
+	return schemas, nil
+}
+
+// unknownVMFields returns paths of fields in VirtualMachine objects of the template,
+// that are not defined in the schema of their API version. VMs using a version
+// without schema are not checked.
+func unknownVMFields(template *templatev1.Template, schemas map[string]map[string]interface{}) ([]string, error) {
+	var fields []string
+	for i := range template.Objects {
+		raw := template.Objects[i].Raw
+		if raw == nil {
+			continue
+		}
+		vm := &unstructured.Unstructured{}
+		if err := json.Unmarshal(raw, &vm.Object); err != nil {
+			return nil, fmt.Errorf("failed to decode object %d in template %s: %w", i, template.Name, err)
+		}
+		if vm.GetKind() != virtualMachineKind {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(vm.GetAPIVersion())
+		if err != nil || gv.Group != virtualMachineListGVK.Group {
+			continue
+		}
+		vmSchema, ok := schemas[gv.Version]
+		if !ok {
+			continue
+		}
+		fields = append(fields, unknownFields(vm.Object, vmSchema, "")...)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// unknownFields returns paths of fields in the value, that are not defined in the schema
+func unknownFields(value interface{}, fieldSchema map[string]interface{}, path string) []string {
+	if preserve, _ := fieldSchema["x-kubernetes-preserve-unknown-fields"].(bool); preserve {
+		return nil
+	}
+
+	var fields []string
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		properties, hasProperties := fieldSchema["properties"].(map[string]interface{})
+		additionalProperties, hasAdditionalProperties := fieldSchema["additionalProperties"].(map[string]interface{})
+		for key, fieldValue := range typed {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				fields = append(fields, unknownFields(fieldValue, propertySchema, fieldPath)...)
+				continue
+			}
+			if hasAdditionalProperties {
+				fields = append(fields, unknownFields(fieldValue, additionalProperties, fieldPath)...)
+				continue
+			}
+			if hasProperties {
+				fields = append(fields, fieldPath)
+			}
+		}
+	case []interface{}:
+		items, ok := fieldSchema["items"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range typed {
+			fields = append(fields, unknownFields(item, items, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return fields
+}
@@ -12,6 +12,8 @@ import (
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 )
 
 // Resources from node-labeller
@@ -86,6 +88,9 @@ minCPU: "Penryn"`
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      ConfigMapName,
 			Namespace: namespace,
+			Labels: map[string]string{
+				ssp.WatchedConfigMapLabel: "true",
+			},
 		},
 		Data: map[string]string{
 			"cpu-plugin-configmap.yaml": cpuPluginConfigmap,
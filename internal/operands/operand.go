@@ -1,6 +1,9 @@
 package operands
 
 import (
+	"context"
+	"net/http"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -28,3 +31,22 @@ type Operand interface {
 	// Name returns the name of the operand
 	Name() string
 }
+
+// DebugHandlersProvider is implemented by operands that expose debug information over HTTP
+type DebugHandlersProvider interface {
+	// DebugHandlers returns HTTP handlers by the path where they are served
+	DebugHandlers() map[string]http.Handler
+}
+
+// CacheSelectorsProvider is implemented by operands that need only some objects of a watched type
+type CacheSelectorsProvider interface {
+	// CacheSelectors returns selectors of objects kept in the cache.
+	// Objects not matching the selectors are read from the API server.
+	CacheSelectors() []common.CacheSelector
+}
+
+// FieldIndexesProvider is implemented by operands that list cached objects by an indexed field
+type FieldIndexesProvider interface {
+	// IndexFields registers field indexes in the cache
+	IndexFields(ctx context.Context, indexer client.FieldIndexer) error
+}
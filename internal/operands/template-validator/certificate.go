@@ -0,0 +1,262 @@
+package template_validator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
+	"kubevirt.io/ssp-operator/internal/common"
+)
+
+const (
+	// defaultCertExpiryWarningDays has to match the default value in the SSP CRD
+	defaultCertExpiryWarningDays = 30
+
+	// selfSignedCertValidity is the validity of the certificate created for the SelfSigned TLS provider
+	selfSignedCertValidity = 365 * 24 * time.Hour
+
+	// caBundleKey is the key of the CA bundle in the serving certificate secret
+	caBundleKey = "ca.crt"
+)
+
+// selfSignedTLS returns true, if the operator provides the serving certificate of the validator
+func selfSignedTLS(request *common.Request) bool {
+	return request.Instance.Spec.TemplateValidator.TLSProvider == ssp.TLSProviderSelfSigned
+}
+
+// certExpiryWarningDays returns the configured warning window, or the default one if it is not set
+func certExpiryWarningDays(request *common.Request) int32 {
+	warningDays := request.Instance.Spec.TemplateValidator.CertExpiryWarningDays
+	if warningDays <= 0 {
+		return defaultCertExpiryWarningDays
+	}
+	return warningDays
+}
+
+func newCertificateSecret(namespace string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName,
+			Namespace: namespace,
+		},
+	}
+}
+
+// reconcileServingCertificate creates the self-signed certificate, if it is enabled,
+// and reports the status of the serving certificate.
+// The secret is always read from the API server, so secrets are not cached by the operator.
+func reconcileServingCertificate(request *common.Request) (common.ResourceStatus, error) {
+	if err := reconcileSelfSignedCertificate(request); err != nil {
+		return common.ResourceStatus{}, err
+	}
+	return reconcileCertificateExpiry(request)
+}
+
+// reconcileSelfSignedCertificate creates the serving certificate secret of the validator,
+// if the SelfSigned TLS provider is used. The certificate is created again, if it is
+// not valid for the validator service or if it expires within the warning window.
+// With the ServiceCA provider, a secret created by the operator is removed,
+// so the service CA operator creates a new one.
+func reconcileSelfSignedCertificate(request *common.Request) error {
+	namespace := common.TemplateValidatorNamespace(request)
+	secret := newCertificateSecret(namespace)
+
+	found := &v1.Secret{}
+	err := request.UncachedReader().Get(request.Context, client.ObjectKeyFromObject(secret), found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !selfSignedTLS(request) {
+		if exists && found.Annotations[SelfSignedCertAnnotation] == "true" && !request.DryRun {
+			request.Logger.Info(fmt.Sprintf("Removing self-signed certificate secret %s/%s", namespace, SecretName))
+			err := request.Client.Delete(request.Context, found)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	renewBefore := time.Duration(certExpiryWarningDays(request)) * 24 * time.Hour
+	if exists && validSelfSignedCertificate(found, namespace, time.Now().Add(renewBefore)) {
+		secret.Data = found.Data
+	} else {
+		certPEM, keyPEM, err := newSelfSignedCertificate(namespace, time.Now())
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			v1.TLSCertKey:       certPEM,
+			v1.TLSPrivateKeyKey: keyPEM,
+			caBundleKey:         certPEM,
+		}
+	}
+	secret.Type = v1.SecretTypeTLS
+	secret.Annotations = map[string]string{SelfSignedCertAnnotation: "true"}
+
+	_, err = createOrUpdateNamespaced(request, secret).
+		Uncached().
+		WithAppLabels(operandName, operandComponent).
+		UpdateFunc(func(newRes, foundRes client.Object) {
+			newSecret := newRes.(*v1.Secret)
+			foundSecret := foundRes.(*v1.Secret)
+			if foundSecret.Type == "" {
+				// The type can only be set when the secret is created
+				foundSecret.Type = newSecret.Type
+			}
+			foundSecret.Data = newSecret.Data
+		}).
+		Reconcile()
+	return err
+}
+
+// selfSignedCABundle returns the CA bundle from the self-signed certificate secret.
+// It is empty, if the secret does not exist, which happens in dry-run mode.
+func selfSignedCABundle(request *common.Request) ([]byte, error) {
+	secret := newCertificateSecret(common.TemplateValidatorNamespace(request))
+	err := request.UncachedReader().Get(request.Context, client.ObjectKeyFromObject(secret), secret)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data[caBundleKey], nil
+}
+
+// validSelfSignedCertificate returns true, if the secret contains a certificate
+// for the validator service, that is still valid at the renewal time
+func validSelfSignedCertificate(secret *v1.Secret, namespace string, renewalTime time.Time) bool {
+	if len(secret.Data[v1.TLSPrivateKeyKey]) == 0 || len(secret.Data[caBundleKey]) == 0 {
+		return false
+	}
+	cert, err := parseCertificate(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+	if cert.VerifyHostname(serviceDNSNames(namespace)[0]) != nil {
+		return false
+	}
+	return renewalTime.Before(cert.NotAfter)
+}
+
+// serviceDNSNames returns the DNS names of the validator service
+func serviceDNSNames(namespace string) []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", ServiceName, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", ServiceName, namespace),
+	}
+}
+
+// newSelfSignedCertificate returns a PEM encoded certificate and private key for the validator service.
+// The certificate is its own CA, so it is also used as the CA bundle of the webhook.
+func newSelfSignedCertificate(namespace string, now time.Time) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dnsNames := serviceDNSNames(namespace)
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		// Allow for clock skew between the operator and the API server
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// reconcileCertificateExpiry reports the serving certificate of the validator
+// as degraded, if it expires within the configured warning window.
+// The secret is created by the service CA operator, or by the operator with the SelfSigned
+// TLS provider. If it does not exist, the certificate injection failed or did not finish yet,
+// and the webhook cannot serve requests.
+func reconcileCertificateExpiry(request *common.Request) (common.ResourceStatus, error) {
+	secret := newCertificateSecret(common.TemplateValidatorNamespace(request))
+	status := common.ResourceStatus{Resource: secret}
+
+	err := request.UncachedReader().Get(request.Context, client.ObjectKeyFromObject(secret), secret)
+	if errors.IsNotFound(err) {
+		creator := "the service CA operator"
+		if selfSignedTLS(request) {
+			creator = "the operator with the SelfSigned TLS provider"
+		}
+		msg := fmt.Sprintf("Serving certificate secret %s/%s does not exist, it is created by %s",
+			secret.Namespace, secret.Name, creator)
+		status.Progressing = &msg
+		status.Degraded = &msg
+		return status, nil
+	}
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
+
+	cert, err := parseCertificate(secret.Data[v1.TLSCertKey])
+	if err != nil {
+		msg := fmt.Sprintf("Failed to parse serving certificate: %v", err)
+		status.Degraded = &msg
+		return status, nil
+	}
+
+	notAfter := cert.NotAfter
+	warningDays := certExpiryWarningDays(request)
+
+	untilExpiry := time.Until(notAfter)
+	if untilExpiry <= 0 {
+		msg := fmt.Sprintf("Serving certificate expired at %s", notAfter.Format(time.RFC3339))
+		status.NotAvailable = &msg
+		status.Degraded = &msg
+		return status, nil
+	}
+
+	daysUntilExpiry := int32(untilExpiry.Hours() / 24)
+	if daysUntilExpiry < warningDays {
+		msg := fmt.Sprintf("Serving certificate expires in %d days, at %s", daysUntilExpiry, notAfter.Format(time.RFC3339))
+		status.Degraded = &msg
+	}
+	return status, nil
+}
+
+// parseCertificate returns the first certificate in the PEM data
+func parseCertificate(pemData []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
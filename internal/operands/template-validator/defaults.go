@@ -2,4 +2,29 @@ package template_validator
 
 const (
 	defaultTemplateValidatorImage = "quay.io/kubevirt/kubevirt-template-validator:v0.10.0"
+
+	// minTLSConfigVersion is the oldest template validator supporting the TLS configuration flags
+	minTLSConfigVersion = "v0.11.0"
+
+	// minAdmissionReviewV1Version is the oldest template validator that handles v1 admission reviews
+	minAdmissionReviewV1Version = "v0.11.0"
+
+	// defaultReplicas has to match the default value in the SSP CRD
+	defaultReplicas int32 = 2
+
+	// pdbMinAvailable is the number of validator pods kept running during disruptions
+	pdbMinAvailable int32 = 1
+
+	// defaultLogVerbosity has to match the default value in the SSP CRD
+	defaultLogVerbosity int32 = 2
+
+	// Default timing of the liveness and readiness probes, in seconds
+	defaultProbeInitialDelay     int32 = 10
+	defaultProbePeriod           int32 = 10
+	defaultProbeTimeout          int32 = 5
+	defaultProbeFailureThreshold int32 = 3
+
+	// Default resource requests of the template validator container
+	defaultCPURequest    = "50m"
+	defaultMemoryRequest = "150Mi"
 )
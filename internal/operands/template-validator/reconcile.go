@@ -2,24 +2,36 @@ package template_validator
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver/v4"
 	admission "k8s.io/api/admissionregistration/v1"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 	rbac "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	lifecycleapi "kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 	"kubevirt.io/ssp-operator/internal/common"
 	"kubevirt.io/ssp-operator/internal/operands"
+	common_templates "kubevirt.io/ssp-operator/internal/operands/common-templates"
 )
 
 // Define RBAC rules needed by this operand:
 // +kubebuilder:rbac:groups=core,resources=services;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 // RBAC for created roles
 // +kubebuilder:rbac:groups=template.openshift.io,resources=templates,verbs=get;list;watch
@@ -39,11 +51,18 @@ func (t *templateValidator) WatchTypes() []client.Object {
 		&v1.ServiceAccount{},
 		&v1.Service{},
 		&apps.Deployment{},
+		&policy.PodDisruptionBudget{},
 	}
 }
 
 func (t *templateValidator) WatchClusterTypes() []client.Object {
 	return []client.Object{
+		// Namespaced resources deployed outside of the SSP namespace
+		// are watched using owner annotations.
+		&v1.ServiceAccount{},
+		&v1.Service{},
+		&apps.Deployment{},
+		&policy.PodDisruptionBudget{},
 		&rbac.ClusterRole{},
 		&rbac.ClusterRoleBinding{},
 		&admission.ValidatingWebhookConfiguration{},
@@ -56,18 +75,45 @@ func (t *templateValidator) Reconcile(request *common.Request) ([]common.Resourc
 		reconcileServiceAccount,
 		reconcileClusterRoleBinding,
 		reconcileService,
+		reconcileServingCertificate,
 		reconcileDeployment,
+		reconcilePodDisruptionBudget,
 		reconcileValidatingWebhook,
+		removePreviousNamespaceResources,
 	)
 }
 
 func (t *templateValidator) Cleanup(request *common.Request) error {
-	for _, obj := range []client.Object{
+	namespace := common.TemplateValidatorNamespace(request)
+	objects := []client.Object{
 		newClusterRole(),
-		newClusterRoleBinding(request.Namespace),
-		newValidatingWebhook(request.Namespace),
-	} {
+		newClusterRoleBinding(namespace),
+		newValidatingWebhook(namespace, admission.Fail),
+	}
+	if namespace != request.Namespace {
+		// Resources outside of the SSP namespace do not have owner references,
+		// so they are not removed by the garbage collector.
+		objects = append(objects, namespacedObjects(namespace, selfSignedTLS(request))...)
+	}
+	if previous := previousValidatorNamespace(request); previous != "" && previous != request.Namespace {
+		// The reconciliation may not have removed the resources from the previous namespace yet
+		previousObjects, err := previousNamespaceObjects(request, previous)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, previousObjects...)
+	}
+	return deleteObjects(request, objects)
+}
+
+func deleteObjects(request *common.Request, objects []client.Object) error {
+	for _, obj := range objects {
 		err := request.Client.Delete(request.Context, obj)
+		if _, isSecret := obj.(*v1.Secret); isSecret && errors.IsForbidden(err) {
+			// A leftover certificate secret must not block the cleanup
+			request.Logger.Info(fmt.Sprintf("Not allowed to delete secret %s/%s: %s", obj.GetNamespace(), obj.GetName(), err))
+			continue
+		}
 		if err != nil && !errors.IsNotFound(err) {
 			request.Logger.Error(err, fmt.Sprintf("Error deleting \"%s\": %s", obj.GetName(), err))
 			return err
@@ -76,6 +122,79 @@ func (t *templateValidator) Cleanup(request *common.Request) error {
 	return nil
 }
 
+// namespacedObjects returns the validator resources deployed to the namespace.
+// The certificate secret is included only if withSecret is true.
+func namespacedObjects(namespace string, withSecret bool) []client.Object {
+	objects := []client.Object{
+		newServiceAccount(namespace),
+		newService(namespace),
+		newDeployment(namespace, 0, ""),
+		newPodDisruptionBudget(namespace, 0),
+	}
+	if withSecret {
+		objects = append(objects, newCertificateSecret(namespace))
+	}
+	return objects
+}
+
+// previousNamespaceObjects returns the validator resources to remove from the previous namespace.
+// The certificate secret is included only if it was created by the operator.
+func previousNamespaceObjects(request *common.Request, namespace string) ([]client.Object, error) {
+	objects := namespacedObjects(namespace, false)
+
+	secret := newCertificateSecret(namespace)
+	err := request.UncachedReader().Get(request.Context, client.ObjectKeyFromObject(secret), secret)
+	if errors.IsNotFound(err) {
+		return objects, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if secret.Annotations[SelfSignedCertAnnotation] == "true" {
+		objects = append(objects, secret)
+	}
+	return objects, nil
+}
+
+// previousValidatorNamespace returns the namespace where the validator was deployed before
+// the configured namespace was changed. It returns an empty string, if there was no change.
+func previousValidatorNamespace(request *common.Request) string {
+	status := request.Instance.Status.TemplateValidator
+	if status == nil || status.Namespace == common.TemplateValidatorNamespace(request) {
+		return ""
+	}
+	return status.Namespace
+}
+
+// removePreviousNamespaceResources removes validator resources from the namespace
+// where it was deployed before the configured namespace was changed.
+// It runs after the resources in the new namespace are reconciled, so the webhook
+// already points to the new service. The namespace is then recorded in the SSP status.
+func removePreviousNamespaceResources(request *common.Request) (common.ResourceStatus, error) {
+	namespace := common.TemplateValidatorNamespace(request)
+	status := common.ResourceStatus{
+		Resource:          request.Instance,
+		TemplateValidator: &ssp.TemplateValidatorStatus{Namespace: namespace},
+	}
+	if request.DryRun {
+		status.TemplateValidator = nil
+		return status, nil
+	}
+	previous := previousValidatorNamespace(request)
+	if previous == "" {
+		return status, nil
+	}
+	request.Logger.Info(fmt.Sprintf("Removing template validator from previous namespace %s", previous))
+	objects, err := previousNamespaceObjects(request, previous)
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
+	if err := deleteObjects(request, objects); err != nil {
+		return common.ResourceStatus{}, err
+	}
+	return status, nil
+}
+
 var _ operands.Operand = &templateValidator{}
 
 func GetOperand() operands.Operand {
@@ -85,8 +204,24 @@ func GetOperand() operands.Operand {
 const (
 	operandName      = "template-validator"
 	operandComponent = common.AppComponentTemplating
+
+	// podSecurityEnforceLabel selects the PodSecurity level enforced in a namespace
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	podSecurityBaseline     = "baseline"
+	podSecurityRestricted   = "restricted"
 )
 
+// createOrUpdateNamespaced returns a builder for a namespaced validator resource.
+// Owner references cannot cross namespaces, so resources outside
+// of the SSP namespace are handled like cluster resources.
+func createOrUpdateNamespaced(request *common.Request, resource client.Object) common.ReconcileBuilder {
+	builder := common.CreateOrUpdate(request)
+	if resource.GetNamespace() != request.Namespace {
+		return builder.ClusterResource(resource)
+	}
+	return builder.NamespacedResource(resource)
+}
+
 func reconcileClusterRole(request *common.Request) (common.ResourceStatus, error) {
 	return common.CreateOrUpdate(request).
 		ClusterResource(newClusterRole()).
@@ -98,15 +233,14 @@ func reconcileClusterRole(request *common.Request) (common.ResourceStatus, error
 }
 
 func reconcileServiceAccount(request *common.Request) (common.ResourceStatus, error) {
-	return common.CreateOrUpdate(request).
-		NamespacedResource(newServiceAccount(request.Namespace)).
+	return createOrUpdateNamespaced(request, newServiceAccount(common.TemplateValidatorNamespace(request))).
 		WithAppLabels(operandName, operandComponent).
 		Reconcile()
 }
 
 func reconcileClusterRoleBinding(request *common.Request) (common.ResourceStatus, error) {
 	return common.CreateOrUpdate(request).
-		ClusterResource(newClusterRoleBinding(request.Namespace)).
+		ClusterResource(newClusterRoleBinding(common.TemplateValidatorNamespace(request))).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			newBinding := newRes.(*rbac.ClusterRoleBinding)
@@ -118,8 +252,13 @@ func reconcileClusterRoleBinding(request *common.Request) (common.ResourceStatus
 }
 
 func reconcileService(request *common.Request) (common.ResourceStatus, error) {
-	return common.CreateOrUpdate(request).
-		NamespacedResource(newService(request.Namespace)).
+	service := newService(common.TemplateValidatorNamespace(request))
+	selfSigned := selfSignedTLS(request)
+	if selfSigned {
+		// The secret is created by the operator, not by the service CA operator
+		delete(service.Annotations, ServingCertSecretAnnotation)
+	}
+	return createOrUpdateNamespaced(request, service).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			newService := newRes.(*v1.Service)
@@ -129,6 +268,9 @@ func reconcileService(request *common.Request) (common.ResourceStatus, error) {
 			newService.Spec.ClusterIP = foundService.Spec.ClusterIP
 
 			foundService.Spec = newService.Spec
+			if selfSigned {
+				delete(foundService.Annotations, ServingCertSecretAnnotation)
+			}
 		}).
 		Reconcile()
 }
@@ -139,33 +281,276 @@ func reconcileDeployment(request *common.Request) (common.ResourceStatus, error)
 	if image == "" {
 		panic("Cannot reconcile without valid image name")
 	}
-	deployment := newDeployment(request.Namespace, *validatorSpec.Replicas, image)
+	replicas := validatorReplicas(request)
+	deployment := newDeployment(common.TemplateValidatorNamespace(request), replicas, image)
 	addPlacementFields(deployment, validatorSpec.Placement)
-	return common.CreateOrUpdate(request).
-		NamespacedResource(deployment).
+	addScrapeAnnotations(deployment, validatorSpec.ScrapeAnnotations)
+	addPodMetadata(deployment, validatorSpec.PodLabels, validatorSpec.PodAnnotations)
+	addImagePullFields(deployment, validatorSpec.ImagePullSecrets, validatorSpec.ImagePullPolicy)
+	tlsUnsupported := checkTLSConfigSupport(image, validatorSpec.TLSConfig)
+	if tlsUnsupported == nil {
+		addTLSArgs(deployment, validatorSpec.TLSConfig)
+	}
+	addProbes(deployment, validatorSpec.ProbeConfig)
+	addResources(deployment, validatorSpec.Resources)
+	addAuditWebhookArg(deployment, validatorSpec.AuditWebhookURL)
+	addMaxConcurrentRequestsArg(deployment, validatorSpec.MaxConcurrentRequests)
+	setLogVerbosityArg(deployment, int32OrDefault(validatorSpec.LogVerbosity, defaultLogVerbosity))
+	podSecurityViolation, err := checkPodSecurity(request, deployment)
+	if err != nil {
+		return common.ResourceStatus{}, err
+	}
+	previousImage := ""
+	status, err := createOrUpdateNamespaced(request, deployment).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
-			foundRes.(*apps.Deployment).Spec = newRes.(*apps.Deployment).Spec
+			foundDeployment := foundRes.(*apps.Deployment)
+			if containers := foundDeployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+				previousImage = containers[0].Image
+			}
+			foundDeployment.Spec = newRes.(*apps.Deployment).Spec
 		}).
 		StatusFunc(func(res client.Object) common.ResourceStatus {
 			dep := res.(*apps.Deployment)
 			status := common.ResourceStatus{}
-			if *validatorSpec.Replicas > 0 && dep.Status.AvailableReplicas == 0 {
+			if replicas > 0 && dep.Status.AvailableReplicas == 0 {
 				msg := fmt.Sprintf("No validator pods are running. Expected: %d", dep.Status.Replicas)
 				status.NotAvailable = &msg
 			}
-			if dep.Status.AvailableReplicas != *validatorSpec.Replicas {
+			if dep.Status.AvailableReplicas != replicas {
 				msg := fmt.Sprintf(
 					"Not all template validator pods are running. Expected: %d, running: %d",
-					*validatorSpec.Replicas,
+					replicas,
 					dep.Status.AvailableReplicas,
 				)
 				status.Progressing = &msg
 				status.Degraded = &msg
 			}
+			if status.Degraded == nil {
+				status.Degraded = checkValidatorVersion(image)
+			}
+			if status.Degraded == nil {
+				status.Degraded = tlsUnsupported
+			}
+			if podSecurityViolation != nil {
+				// Explains why the pods are not running
+				status.Degraded = podSecurityViolation
+			}
 			return status
 		}).
 		Reconcile()
+	if err == nil && previousImage != "" && previousImage != image && !request.DryRun {
+		status.Upgrade = &ssp.UpgradeSummary{
+			PreviousValidatorImage: previousImage,
+			ValidatorImage:         image,
+		}
+	}
+	return status, err
+}
+
+// reconcilePodDisruptionBudget keeps at least one validator pod running during
+// voluntary disruptions, like node drains, when there are multiple replicas.
+// With a single replica the budget would block the drain, so it is removed.
+func reconcilePodDisruptionBudget(request *common.Request) (common.ResourceStatus, error) {
+	pdb := newPodDisruptionBudget(common.TemplateValidatorNamespace(request), pdbMinAvailable)
+	if validatorReplicas(request) <= 1 {
+		if !request.DryRun {
+			err := request.Client.Delete(request.Context, pdb)
+			if err != nil && !errors.IsNotFound(err) {
+				return common.ResourceStatus{}, err
+			}
+		}
+		return common.ResourceStatus{}, nil
+	}
+	return createOrUpdateNamespaced(request, pdb).
+		WithAppLabels(operandName, operandComponent).
+		UpdateFunc(func(newRes, foundRes client.Object) {
+			foundRes.(*policy.PodDisruptionBudget).Spec = newRes.(*policy.PodDisruptionBudget).Spec
+		}).
+		Reconcile()
+}
+
+// checkPodSecurity returns a message, if the PodSecurity level enforced in the namespace
+// would reject the validator pods. The level is read from the namespace label,
+// and only the pod fields relevant to the Baseline and Restricted levels are checked.
+func checkPodSecurity(request *common.Request, deployment *apps.Deployment) (*string, error) {
+	namespace := &v1.Namespace{}
+	err := request.Client.Get(request.Context, client.ObjectKey{Name: deployment.Namespace}, namespace)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	level := namespace.Labels[podSecurityEnforceLabel]
+	violations := podSecurityViolations(level, &deployment.Spec.Template.Spec)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+	msg := fmt.Sprintf("Template validator pods violate PodSecurity level %q enforced in namespace %s: %s",
+		level, deployment.Namespace, strings.Join(violations, ", "))
+	return &msg, nil
+}
+
+// podSecurityViolations returns the checks of the PodSecurity level, that the pod does not pass
+func podSecurityViolations(level string, podSpec *v1.PodSpec) []string {
+	if level != podSecurityBaseline && level != podSecurityRestricted {
+		return nil
+	}
+
+	var violations []string
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		violations = append(violations, "host namespaces")
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, "hostPath volumes")
+			break
+		}
+	}
+	for _, container := range podSpec.Containers {
+		if sc := container.SecurityContext; sc != nil && sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, "privileged")
+			break
+		}
+	}
+	if level == podSecurityBaseline {
+		return violations
+	}
+
+	podRunAsNonRoot := podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil &&
+		*podSpec.SecurityContext.RunAsNonRoot
+	podSeccomp := podSpec.SecurityContext != nil && allowedSeccompProfile(podSpec.SecurityContext.SeccompProfile)
+	var runAsRoot, privilegeEscalation, capabilities, seccomp bool
+	for _, container := range podSpec.Containers {
+		sc := container.SecurityContext
+		if sc == nil {
+			sc = &v1.SecurityContext{}
+		}
+		if !podRunAsNonRoot && (sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot) {
+			runAsRoot = true
+		}
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			privilegeEscalation = true
+		}
+		if !dropsAllCapabilities(sc.Capabilities) {
+			capabilities = true
+		}
+		if !podSeccomp && !allowedSeccompProfile(sc.SeccompProfile) {
+			seccomp = true
+		}
+	}
+	if privilegeEscalation {
+		violations = append(violations, "allowPrivilegeEscalation != false")
+	}
+	if capabilities {
+		violations = append(violations, "unrestricted capabilities")
+	}
+	if runAsRoot {
+		violations = append(violations, "runAsNonRoot != true")
+	}
+	if seccomp {
+		violations = append(violations, "seccompProfile")
+	}
+	return violations
+}
+
+func allowedSeccompProfile(profile *v1.SeccompProfile) bool {
+	return profile != nil &&
+		(profile.Type == v1.SeccompProfileTypeRuntimeDefault || profile.Type == v1.SeccompProfileTypeLocalhost)
+}
+
+func dropsAllCapabilities(capabilities *v1.Capabilities) bool {
+	if capabilities == nil {
+		return false
+	}
+	for _, capability := range capabilities.Add {
+		if capability != "NET_BIND_SERVICE" {
+			return false
+		}
+	}
+	for _, capability := range capabilities.Drop {
+		if capability == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+// validatorReplicas returns the configured number of replicas.
+// The CRD sets a default value, but the field can still be nil
+// if the CR was created before the default existed.
+func validatorReplicas(request *common.Request) int32 {
+	replicas := request.Instance.Spec.TemplateValidator.Replicas
+	if replicas == nil {
+		return defaultReplicas
+	}
+	if *replicas < 0 {
+		return 0
+	}
+	return *replicas
+}
+
+// checkValidatorVersion returns a message if the validator image
+// is older than the version required by the common templates bundle.
+// Images without a semantic version tag are not checked.
+func checkValidatorVersion(image string) *string {
+	if !validatorOlderThan(image, common_templates.MinTemplateValidatorVersion) {
+		return nil
+	}
+	msg := fmt.Sprintf("Template validator version %s is older than %s, required by common templates %s",
+		imageTag(image), common_templates.MinTemplateValidatorVersion, common_templates.Version)
+	return &msg
+}
+
+// checkTLSConfigSupport returns a message if the TLS configuration is set,
+// but the validator image is older than the version supporting it.
+// Images without a semantic version tag are expected to support it.
+func checkTLSConfigSupport(image string, config *ssp.TLSConfig) *string {
+	if config == nil || !validatorOlderThan(image, minTLSConfigVersion) {
+		return nil
+	}
+	msg := fmt.Sprintf("Template validator version %s does not support TLS configuration, %s is required",
+		imageTag(image), minTLSConfigVersion)
+	return &msg
+}
+
+// setAdmissionReviewVersions limits the webhook to v1beta1 admission reviews,
+// if the validator image is older than the first version handling v1.
+// Images without a semantic version tag are expected to handle both versions.
+func setAdmissionReviewVersions(webhook *admission.ValidatingWebhookConfiguration, image string) {
+	if !validatorOlderThan(image, minAdmissionReviewV1Version) {
+		return
+	}
+	for i := range webhook.Webhooks {
+		webhook.Webhooks[i].AdmissionReviewVersions = []string{"v1beta1"}
+	}
+}
+
+// validatorOlderThan returns true, if the image has a semantic version tag older than minVersion
+func validatorOlderThan(image string, minVersion string) bool {
+	tag := imageTag(image)
+	if tag == "" {
+		return false
+	}
+	validatorVersion, err := semver.ParseTolerant(tag)
+	if err != nil {
+		return false
+	}
+	return validatorVersion.LT(semver.MustParse(strings.TrimPrefix(minVersion, "v")))
+}
+
+func imageTag(image string) string {
+	if strings.Contains(image, "@") {
+		// Image is referenced by digest
+		return ""
+	}
+	tagIndex := strings.LastIndex(image, ":")
+	if tagIndex < 0 || tagIndex < strings.LastIndex(image, "/") {
+		return ""
+	}
+	return image[tagIndex+1:]
 }
 
 func addPlacementFields(deployment *apps.Deployment, nodePlacement *lifecycleapi.NodePlacement) {
@@ -179,23 +564,202 @@ func addPlacementFields(deployment *apps.Deployment, nodePlacement *lifecycleapi
 	podSpec.Tolerations = nodePlacement.Tolerations
 }
 
+// addScrapeAnnotations adds the annotations used by annotation based Prometheus scraping to the pod template.
+// Metrics are served on the webhook port, which uses only TLS.
+func addScrapeAnnotations(deployment *apps.Deployment, enabled *bool) {
+	if enabled == nil || !*enabled {
+		return
+	}
+	podMeta := &deployment.Spec.Template.ObjectMeta
+	if podMeta.Annotations == nil {
+		podMeta.Annotations = make(map[string]string, 4)
+	}
+	podMeta.Annotations["prometheus.io/scrape"] = "true"
+	podMeta.Annotations["prometheus.io/port"] = strconv.Itoa(ContainerPort)
+	podMeta.Annotations["prometheus.io/path"] = MetricsPath
+	podMeta.Annotations["prometheus.io/scheme"] = "https"
+}
+
+// addPodMetadata adds user defined labels and annotations to the pod template.
+// Labels already set by the operator are not overwritten.
+func addPodMetadata(deployment *apps.Deployment, labels, annotations map[string]string) {
+	podMeta := &deployment.Spec.Template.ObjectMeta
+	for key, value := range labels {
+		if podMeta.Labels == nil {
+			podMeta.Labels = make(map[string]string, len(labels))
+		}
+		if _, exists := podMeta.Labels[key]; !exists {
+			podMeta.Labels[key] = value
+		}
+	}
+	for key, value := range annotations {
+		if podMeta.Annotations == nil {
+			podMeta.Annotations = make(map[string]string, len(annotations))
+		}
+		podMeta.Annotations[key] = value
+	}
+}
+
+func addImagePullFields(deployment *apps.Deployment, pullSecrets []v1.LocalObjectReference, pullPolicy v1.PullPolicy) {
+	podSpec := &deployment.Spec.Template.Spec
+	podSpec.ImagePullSecrets = pullSecrets
+	if pullPolicy != "" {
+		for i := range podSpec.Containers {
+			podSpec.Containers[i].ImagePullPolicy = pullPolicy
+		}
+	}
+}
+
+// addTLSArgs passes the TLS configuration to the validator container
+func addTLSArgs(deployment *apps.Deployment, config *ssp.TLSConfig) {
+	if config == nil {
+		return
+	}
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	if config.MinVersion != "" {
+		container.Args = append(container.Args, "--tls-min-version="+config.MinVersion)
+	}
+	if len(config.CipherSuites) > 0 {
+		container.Args = append(container.Args, "--tls-cipher-suites="+strings.Join(config.CipherSuites, ","))
+	}
+	if config.DisableHTTP2 {
+		container.Args = append(container.Args, "--disable-http2")
+	}
+}
+
+// addProbes adds liveness and readiness probes checking the health endpoint on the webhook port.
+// The validator serves only over TLS, so the probes use HTTPS.
+// Their timing is taken from the config, or defaults are used.
+func addProbes(deployment *apps.Deployment, config *ssp.ProbeConfig) {
+	if config == nil {
+		config = &ssp.ProbeConfig{}
+	}
+	newProbe := func() *v1.Probe {
+		return &v1.Probe{
+			Handler: v1.Handler{
+				HTTPGet: &v1.HTTPGetAction{
+					Path:   HealthzPath,
+					Port:   intstr.FromInt(ContainerPort),
+					Scheme: v1.URISchemeHTTPS,
+				},
+			},
+			InitialDelaySeconds: int32OrDefault(config.InitialDelay, defaultProbeInitialDelay),
+			PeriodSeconds:       int32OrDefault(config.Period, defaultProbePeriod),
+			TimeoutSeconds:      int32OrDefault(config.Timeout, defaultProbeTimeout),
+			FailureThreshold:    int32OrDefault(config.FailureThreshold, defaultProbeFailureThreshold),
+		}
+	}
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		container.LivenessProbe = newProbe()
+		container.ReadinessProbe = newProbe()
+	}
+}
+
+// addResources sets compute resources of the containers.
+// If resources are not configured, default requests are used.
+func addResources(deployment *apps.Deployment, resources *v1.ResourceRequirements) {
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		if resources != nil {
+			container.Resources = *resources.DeepCopy()
+			continue
+		}
+		container.Resources = v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(defaultCPURequest),
+				v1.ResourceMemory: resource.MustParse(defaultMemoryRequest),
+			},
+		}
+	}
+}
+
+// addAuditWebhookArg configures the validator to post admission decisions to the URL
+func addAuditWebhookArg(deployment *apps.Deployment, url string) {
+	if url == "" {
+		return
+	}
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		container.Args = append(container.Args, fmt.Sprintf("--audit-webhook-url=%s", url))
+	}
+}
+
+// addMaxConcurrentRequestsArg limits the number of admission reviews processed by the validator at the same time
+func addMaxConcurrentRequestsArg(deployment *apps.Deployment, maxRequests *int32) {
+	if maxRequests == nil {
+		return
+	}
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		container.Args = append(container.Args, fmt.Sprintf("--max-concurrent-requests=%d", *maxRequests))
+	}
+}
+
+// setLogVerbosityArg replaces the verbosity argument of the validator container
+func setLogVerbosityArg(deployment *apps.Deployment, verbosity int32) {
+	for i := range deployment.Spec.Template.Spec.Containers {
+		container := &deployment.Spec.Template.Spec.Containers[i]
+		for j, arg := range container.Args {
+			if strings.HasPrefix(arg, "-v=") {
+				container.Args[j] = fmt.Sprintf("-v=%d", verbosity)
+			}
+		}
+	}
+}
+
+func int32OrDefault(value *int32, defaultValue int32) int32 {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
 func reconcileValidatingWebhook(request *common.Request) (common.ResourceStatus, error) {
+	webhook := newValidatingWebhook(common.TemplateValidatorNamespace(request), webhookFailurePolicy(request))
+	setAdmissionReviewVersions(webhook, getTemplateValidatorImage())
+	webhook.Webhooks[0].NamespaceSelector = request.Instance.Spec.TemplateValidator.NamespaceSelector.DeepCopy()
+	webhook.Webhooks[0].ObjectSelector = request.Instance.Spec.TemplateValidator.ObjectSelector.DeepCopy()
+	selfSigned := selfSignedTLS(request)
+	if selfSigned {
+		caBundle, err := selfSignedCABundle(request)
+		if err != nil {
+			return common.ResourceStatus{}, err
+		}
+		delete(webhook.Annotations, InjectCABundleAnnotation)
+		webhook.Webhooks[0].ClientConfig.CABundle = caBundle
+		// The certificate may have been renewed, so the cached webhook cannot be used
+		request.VersionCache.RemoveObj(webhook)
+	}
 	return common.CreateOrUpdate(request).
-		ClusterResource(newValidatingWebhook(request.Namespace)).
+		ClusterResource(webhook).
 		WithAppLabels(operandName, operandComponent).
 		UpdateFunc(func(newRes, foundRes client.Object) {
 			newWebhookConf := newRes.(*admission.ValidatingWebhookConfiguration)
 			foundWebhookConf := foundRes.(*admission.ValidatingWebhookConfiguration)
 
-			// Copy CA Bundle from the found webhook,
-			// so it will not be overwritten
-			copyFoundCaBundles(newWebhookConf.Webhooks, foundWebhookConf.Webhooks)
+			if selfSigned {
+				delete(foundWebhookConf.Annotations, InjectCABundleAnnotation)
+			} else {
+				// Copy CA Bundle from the found webhook,
+				// so it will not be overwritten
+				copyFoundCaBundles(newWebhookConf.Webhooks, foundWebhookConf.Webhooks)
+			}
 
 			foundWebhookConf.Webhooks = newWebhookConf.Webhooks
 		}).
 		Reconcile()
 }
 
+// webhookFailurePolicy returns the configured failure policy, or Fail if it is not set
+func webhookFailurePolicy(request *common.Request) admission.FailurePolicyType {
+	failurePolicy := request.Instance.Spec.TemplateValidator.WebhookFailurePolicy
+	if failurePolicy == "" {
+		return admission.Fail
+	}
+	return failurePolicy
+}
+
 func copyFoundCaBundles(newWebhooks []admission.ValidatingWebhook, foundWebhooks []admission.ValidatingWebhook) {
 	for i := range newWebhooks {
 		newWebhook := &newWebhooks[i]
@@ -2,17 +2,33 @@ package template_validator
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	admission "k8s.io/api/admissionregistration/v1"
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/pointer"
+	lifecycleapi "kubevirt.io/controller-lifecycle-operator-sdk/pkg/sdk/api"
 	. "kubevirt.io/ssp-operator/internal/test-utils"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -21,6 +37,7 @@ import (
 
 	ssp "kubevirt.io/ssp-operator/api/v1beta1"
 	"kubevirt.io/ssp-operator/internal/common"
+	common_templates "kubevirt.io/ssp-operator/internal/operands/common-templates"
 )
 
 var log = logf.Log.WithName("validator_operand")
@@ -37,6 +54,17 @@ var _ = Describe("Template validator operand", func() {
 		operand = GetOperand()
 	)
 
+	// reconcileDeploymentArgs reconciles the operand and returns arguments of the validator container
+	reconcileDeploymentArgs := func(request *common.Request) []string {
+		_, err := operand.Reconcile(request)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+		deployment := &apps.Deployment{}
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		ExpectWithOffset(1, request.Client.Get(request.Context, key, deployment)).To(Succeed())
+		return deployment.Spec.Template.Spec.Containers[0].Args
+	}
+
 	BeforeEach(func() {
 		s := scheme.Scheme
 		Expect(ssp.AddToScheme(s)).ToNot(HaveOccurred())
@@ -80,14 +108,512 @@ var _ = Describe("Template validator operand", func() {
 		ExpectResourceExists(newClusterRoleBinding(namespace), request)
 		ExpectResourceExists(newService(namespace), request)
 		ExpectResourceExists(newDeployment(namespace, replicas, "test-img"), request)
-		ExpectResourceExists(newValidatingWebhook(namespace), request)
+		ExpectResourceExists(newValidatingWebhook(namespace, admission.Fail), request)
+	})
+
+	It("should update deployment replicas", func() {
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(1)
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(3)
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+	})
+
+	It("should use default replicas when not set", func() {
+		request.Instance.Spec.TemplateValidator.Replicas = nil
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(defaultReplicas))
+	})
+
+	It("should clamp negative replicas to zero", func() {
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(-1)
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(BeZero())
+	})
+
+	It("should create pod disruption budget with multiple replicas", func() {
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(3)
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		pdb := newPodDisruptionBudget(namespace, 0)
+		ExpectResourceExists(pdb, request)
+		Expect(pdb.Spec.MinAvailable.IntValue()).To(Equal(int(pdbMinAvailable)))
+		Expect(pdb.Spec.Selector.MatchLabels).To(Equal(commonLabels()))
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(1)
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		ExpectResourceNotExists(newPodDisruptionBudget(namespace, 0), request)
+	})
+
+	It("should not create pod disruption budget with single replica", func() {
+		request.Instance.Spec.TemplateValidator.Replicas = pointer.Int32Ptr(1)
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		ExpectResourceNotExists(newPodDisruptionBudget(namespace, 0), request)
+	})
+
+	It("should update deployment placement", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		placement := &lifecycleapi.NodePlacement{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations: []core.Toleration{{
+				Key:      "node-role.kubernetes.io/infra",
+				Operator: core.TolerationOpExists,
+				Effect:   core.TaintEffectNoSchedule,
+			}},
+		}
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.Placement = placement
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.NodeSelector).To(Equal(placement.NodeSelector))
+		Expect(deployment.Spec.Template.Spec.Tolerations).To(Equal(placement.Tolerations))
+		Expect(deployment.Spec.Template.Spec.Affinity).To(BeNil())
+	})
+
+	It("should add pod labels and annotations", func() {
+		request.Instance.Spec.TemplateValidator.PodLabels = map[string]string{
+			"sidecar.istio.io/inject": "false",
+			"kubevirt.io":             "overwritten",
+		}
+		request.Instance.Spec.TemplateValidator.PodAnnotations = map[string]string{
+			"sidecar.istio.io/inject": "false",
+		}
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		podMeta := deployment.Spec.Template.ObjectMeta
+		Expect(podMeta.Labels).To(HaveKeyWithValue("sidecar.istio.io/inject", "false"))
+		Expect(podMeta.Labels).To(HaveKeyWithValue("kubevirt.io", VirtTemplateValidator))
+		Expect(podMeta.Annotations).To(HaveKeyWithValue("sidecar.istio.io/inject", "false"))
+	})
+
+	Context("scrape annotations", func() {
+		getPodAnnotations := func() map[string]string {
+			key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+			deployment := &apps.Deployment{}
+			Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+			return deployment.Spec.Template.Annotations
+		}
+
+		enableScrapeAnnotations := func(enabled bool) {
+			// The controller clears the version cache when the spec changes
+			request.VersionCache = common.VersionCache{}
+			request.Instance.Spec.TemplateValidator.ScrapeAnnotations = &enabled
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("should not add scrape annotations by default", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getPodAnnotations()).ToNot(HaveKey("prometheus.io/scrape"))
+		})
+
+		It("should add scrape annotations when enabled", func() {
+			enableScrapeAnnotations(true)
+
+			annotations := getPodAnnotations()
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/port", strconv.Itoa(ContainerPort)))
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/path", MetricsPath))
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/scheme", "https"))
+		})
+
+		It("should remove scrape annotations when disabled", func() {
+			enableScrapeAnnotations(true)
+			Expect(getPodAnnotations()).To(HaveKey("prometheus.io/scrape"))
+
+			enableScrapeAnnotations(false)
+			Expect(getPodAnnotations()).ToNot(HaveKey("prometheus.io/scrape"))
+			Expect(getPodAnnotations()).ToNot(HaveKey("prometheus.io/port"))
+		})
+
+		It("should prefer pod annotations defined by user", func() {
+			request.Instance.Spec.TemplateValidator.PodAnnotations = map[string]string{
+				"prometheus.io/path": "/custom-metrics",
+			}
+			enableScrapeAnnotations(true)
+
+			annotations := getPodAnnotations()
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+			Expect(annotations).To(HaveKeyWithValue("prometheus.io/path", "/custom-metrics"))
+		})
+	})
+
+	It("should always pull image by default", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.ImagePullSecrets).To(BeEmpty())
+		Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(core.PullAlways))
+	})
+
+	It("should set image pull secrets and policy", func() {
+		pullSecrets := []core.LocalObjectReference{{Name: "registry-secret"}}
+		request.Instance.Spec.TemplateValidator.ImagePullSecrets = pullSecrets
+		request.Instance.Spec.TemplateValidator.ImagePullPolicy = core.PullIfNotPresent
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.ImagePullSecrets).To(Equal(pullSecrets))
+		Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(core.PullIfNotPresent))
+	})
+
+	It("should add probes with default timing", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		container := deployment.Spec.Template.Spec.Containers[0]
+		for _, probe := range []*core.Probe{container.LivenessProbe, container.ReadinessProbe} {
+			Expect(probe).ToNot(BeNil())
+			Expect(probe.HTTPGet).ToNot(BeNil())
+			Expect(probe.HTTPGet.Path).To(Equal(HealthzPath))
+			Expect(probe.HTTPGet.Port.IntValue()).To(Equal(ContainerPort))
+			Expect(probe.HTTPGet.Scheme).To(Equal(core.URISchemeHTTPS))
+			Expect(probe.InitialDelaySeconds).To(Equal(defaultProbeInitialDelay))
+			Expect(probe.PeriodSeconds).To(Equal(defaultProbePeriod))
+			Expect(probe.TimeoutSeconds).To(Equal(defaultProbeTimeout))
+			Expect(probe.FailureThreshold).To(Equal(defaultProbeFailureThreshold))
+		}
+	})
+
+	It("should update probe timing from config", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		initialDelay := int32(60)
+		timeout := int32(30)
+		request.Instance.Spec.TemplateValidator.ProbeConfig = &ssp.ProbeConfig{
+			InitialDelay: &initialDelay,
+			Timeout:      &timeout,
+		}
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		container := deployment.Spec.Template.Spec.Containers[0]
+		for _, probe := range []*core.Probe{container.LivenessProbe, container.ReadinessProbe} {
+			Expect(probe.InitialDelaySeconds).To(Equal(initialDelay))
+			Expect(probe.TimeoutSeconds).To(Equal(timeout))
+			Expect(probe.PeriodSeconds).To(Equal(defaultProbePeriod))
+			Expect(probe.FailureThreshold).To(Equal(defaultProbeFailureThreshold))
+		}
+	})
+
+	It("should set default resource requests", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		resources := deployment.Spec.Template.Spec.Containers[0].Resources
+		Expect(resources.Requests.Cpu().Equal(resource.MustParse(defaultCPURequest))).To(BeTrue())
+		Expect(resources.Requests.Memory().Equal(resource.MustParse(defaultMemoryRequest))).To(BeTrue())
+		Expect(resources.Limits).To(BeEmpty())
+	})
+
+	It("should update resources from config", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		resources := core.ResourceRequirements{
+			Requests: core.ResourceList{
+				core.ResourceCPU:    resource.MustParse("100m"),
+				core.ResourceMemory: resource.MustParse("200Mi"),
+			},
+			Limits: core.ResourceList{
+				core.ResourceCPU:    resource.MustParse("500m"),
+				core.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+		request.Instance.Spec.TemplateValidator.Resources = &resources
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		applied := deployment.Spec.Template.Spec.Containers[0].Resources
+		Expect(applied.Limits.Cpu().Equal(resource.MustParse("500m"))).To(BeTrue())
+		Expect(applied.Limits.Memory().Equal(resource.MustParse("1Gi"))).To(BeTrue())
+		Expect(applied.Requests.Cpu().Equal(resource.MustParse("100m"))).To(BeTrue())
+		Expect(applied.Requests.Memory().Equal(resource.MustParse("200Mi"))).To(BeTrue())
+	})
+
+	It("should not add audit webhook argument by default", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+
+		for _, arg := range deployment.Spec.Template.Spec.Containers[0].Args {
+			Expect(arg).ToNot(HavePrefix("--audit-webhook-url"))
+		}
+	})
+
+	It("should update audit webhook argument", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		const auditURL = "https://siem.example.com/audit"
+		request.Instance.Spec.TemplateValidator.AuditWebhookURL = auditURL
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newDeployment(namespace, 0, "test-img"))
+		deployment := &apps.Deployment{}
+		Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Args).To(ContainElement("--audit-webhook-url=" + auditURL))
+	})
+
+	It("should add max concurrent requests argument", func() {
+		for _, arg := range reconcileDeploymentArgs(&request) {
+			Expect(arg).ToNot(HavePrefix("--max-concurrent-requests"))
+		}
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.MaxConcurrentRequests = pointer.Int32Ptr(20)
+		Expect(reconcileDeploymentArgs(&request)).To(ContainElement("--max-concurrent-requests=20"))
+
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.MaxConcurrentRequests = pointer.Int32Ptr(50)
+		args := reconcileDeploymentArgs(&request)
+		Expect(args).To(ContainElement("--max-concurrent-requests=50"))
+		Expect(args).ToNot(ContainElement("--max-concurrent-requests=20"))
+	})
+
+	It("should set log verbosity argument", func() {
+		args := reconcileDeploymentArgs(&request)
+		Expect(args).To(ContainElement(fmt.Sprintf("-v=%d", defaultLogVerbosity)))
+
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.LogVerbosity = pointer.Int32Ptr(5)
+		args = reconcileDeploymentArgs(&request)
+		Expect(args).To(ContainElement("-v=5"))
+		Expect(args).ToNot(ContainElement(fmt.Sprintf("-v=%d", defaultLogVerbosity)))
+
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.LogVerbosity = pointer.Int32Ptr(0)
+		Expect(reconcileDeploymentArgs(&request)).To(ContainElement("-v=0"))
+	})
+
+	It("should use Fail webhook failure policy by default", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
+		webhook := &admission.ValidatingWebhookConfiguration{}
+		Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
+		Expect(*webhook.Webhooks[0].FailurePolicy).To(Equal(admission.Fail))
+	})
+
+	It("should update webhook failure policy", func() {
+		_, err := operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		// The controller clears the version cache when the spec changes
+		request.VersionCache = common.VersionCache{}
+		request.Instance.Spec.TemplateValidator.WebhookFailurePolicy = admission.Ignore
+		_, err = operand.Reconcile(&request)
+		Expect(err).ToNot(HaveOccurred())
+
+		key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Ignore))
+		webhook := &admission.ValidatingWebhookConfiguration{}
+		Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
+		Expect(*webhook.Webhooks[0].FailurePolicy).To(Equal(admission.Ignore))
+	})
+
+	It("should list v1 admission review version first", func() {
+		webhook := newValidatingWebhook(namespace, admission.Fail)
+		for _, hook := range webhook.Webhooks {
+			Expect(hook.AdmissionReviewVersions).To(Equal([]string{"v1", "v1beta1"}))
+		}
+	})
+
+	Context("admission review versions", func() {
+		AfterEach(func() {
+			Expect(os.Unsetenv(common.TemplateValidatorImageKey)).To(Succeed())
+		})
+
+		reconcileWebhook := func(image string) *admission.ValidatingWebhookConfiguration {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, image)).To(Succeed())
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
+			webhook := &admission.ValidatingWebhookConfiguration{}
+			Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
+			return webhook
+		}
+
+		It("should use v1 with a validator supporting it", func() {
+			webhook := reconcileWebhook("quay.io/kubevirt/kubevirt-template-validator:" + minAdmissionReviewV1Version)
+			Expect(webhook.Webhooks[0].AdmissionReviewVersions).To(Equal([]string{"v1", "v1beta1"}))
+		})
+
+		It("should use v1 with a validator without version tag", func() {
+			webhook := reconcileWebhook("quay.io/kubevirt/kubevirt-template-validator:latest")
+			Expect(webhook.Webhooks[0].AdmissionReviewVersions).To(Equal([]string{"v1", "v1beta1"}))
+		})
+
+		It("should only use v1beta1 with an older validator", func() {
+			webhook := reconcileWebhook("quay.io/kubevirt/kubevirt-template-validator:v0.10.0")
+			Expect(webhook.Webhooks[0].AdmissionReviewVersions).To(Equal([]string{"v1beta1"}))
+		})
+	})
+
+	Context("namespace selector", func() {
+		getWebhook := func() *admission.ValidatingWebhookConfiguration {
+			key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
+			webhook := &admission.ValidatingWebhookConfiguration{}
+			Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
+			return webhook
+		}
+
+		It("should not set namespace selector by default", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].NamespaceSelector).To(BeNil())
+		})
+
+		It("should set namespace selector on the webhook", func() {
+			selector := &meta.LabelSelector{
+				MatchExpressions: []meta.LabelSelectorRequirement{{
+					Key:      "kubernetes.io/metadata.name",
+					Operator: meta.LabelSelectorOpNotIn,
+					Values:   []string{"kube-system", "openshift-cnv"},
+				}},
+			}
+			request.Instance.Spec.TemplateValidator.NamespaceSelector = selector
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].NamespaceSelector).To(Equal(selector))
+		})
+
+		It("should update namespace selector on the existing webhook", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			selector := &meta.LabelSelector{MatchLabels: map[string]string{"validate-vms": "true"}}
+			request.Instance.Spec.TemplateValidator.NamespaceSelector = selector
+			request.VersionCache = common.VersionCache{}
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].NamespaceSelector).To(Equal(selector))
+		})
+	})
+
+	Context("object selector", func() {
+		getWebhook := func() *admission.ValidatingWebhookConfiguration {
+			key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
+			webhook := &admission.ValidatingWebhookConfiguration{}
+			Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
+			return webhook
+		}
+
+		It("should not set object selector by default", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].ObjectSelector).To(BeNil())
+		})
+
+		It("should set object selector on the webhook", func() {
+			selector := &meta.LabelSelector{MatchLabels: map[string]string{"vm.kubevirt.io/template": "fedora-server-small"}}
+			request.Instance.Spec.TemplateValidator.ObjectSelector = selector
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].ObjectSelector).To(Equal(selector))
+		})
+
+		It("should update object selector on the existing webhook", func() {
+			selector := &meta.LabelSelector{MatchLabels: map[string]string{"validate": "true"}}
+			request.Instance.Spec.TemplateValidator.ObjectSelector = selector
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Instance.Spec.TemplateValidator.ObjectSelector = nil
+			request.VersionCache = common.VersionCache{}
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getWebhook().Webhooks[0].ObjectSelector).To(BeNil())
+		})
 	})
 
 	It("should not update webhook CA bundle", func() {
 		_, err := operand.Reconcile(&request)
 		Expect(err).ToNot(HaveOccurred())
 
-		key := client.ObjectKeyFromObject(newValidatingWebhook(namespace))
+		key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
 		webhook := &admission.ValidatingWebhookConfiguration{}
 		Expect(request.Client.Get(request.Context, key, webhook)).ToNot(HaveOccurred())
 
@@ -131,16 +657,506 @@ var _ = Describe("Template validator operand", func() {
 
 		ExpectResourceExists(newClusterRole(), request)
 		ExpectResourceExists(newClusterRoleBinding(namespace), request)
-		ExpectResourceExists(newValidatingWebhook(namespace), request)
+		ExpectResourceExists(newValidatingWebhook(namespace, admission.Fail), request)
 
 		Expect(operand.Cleanup(&request)).ToNot(HaveOccurred())
 
 		ExpectResourceNotExists(newClusterRole(), request)
 		ExpectResourceNotExists(newClusterRoleBinding(namespace), request)
-		ExpectResourceNotExists(newValidatingWebhook(namespace), request)
+		ExpectResourceNotExists(newValidatingWebhook(namespace, admission.Fail), request)
+	})
+
+	Context("serving certificate expiry", func() {
+		createCertSecret := func(notAfter time.Time) {
+			secret := &core.Secret{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      SecretName,
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					core.TLSCertKey: newTestCertificate(notAfter),
+				},
+			}
+			Expect(request.Client.Create(request.Context, secret)).To(Succeed())
+		}
+
+		getSecretStatus := func(statuses []common.ResourceStatus) common.ResourceStatus {
+			for _, status := range statuses {
+				if _, ok := status.Resource.(*core.Secret); ok {
+					return status
+				}
+			}
+			Fail("secret status not found")
+			return common.ResourceStatus{}
+		}
+
+		It("should report missing secret", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := getSecretStatus(statuses)
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(*status.Degraded).To(ContainSubstring(SecretName + " does not exist"))
+			Expect(*status.Degraded).To(ContainSubstring("service CA operator"))
+			Expect(status.Progressing).ToNot(BeNil())
+			Expect(status.NotAvailable).To(BeNil())
+		})
+
+		It("should report missing self-signed secret", func() {
+			request.Instance.Spec.TemplateValidator.TLSProvider = ssp.TLSProviderSelfSigned
+
+			status, err := reconcileCertificateExpiry(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(*status.Degraded).To(ContainSubstring(SecretName + " does not exist"))
+			Expect(*status.Degraded).To(ContainSubstring("SelfSigned TLS provider"))
+			Expect(*status.Degraded).ToNot(ContainSubstring("service CA operator"))
+		})
+
+		It("should report invalid certificate", func() {
+			secret := &core.Secret{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      SecretName,
+					Namespace: namespace,
+				},
+			}
+			Expect(request.Client.Create(request.Context, secret)).To(Succeed())
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := getSecretStatus(statuses)
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(*status.Degraded).To(ContainSubstring("Failed to parse serving certificate"))
+		})
+
+		It("should not report valid certificate", func() {
+			createCertSecret(time.Now().Add(365 * 24 * time.Hour))
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := getSecretStatus(statuses)
+			Expect(status.Degraded).To(BeNil())
+			Expect(status.NotAvailable).To(BeNil())
+		})
+
+		It("should read the secret directly from the API server", func() {
+			secret := &core.Secret{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      SecretName,
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					core.TLSCertKey: newTestCertificate(time.Now().Add(365 * 24 * time.Hour)),
+				},
+			}
+			request.APIReader = fake.NewFakeClientWithScheme(request.Client.Scheme(), secret)
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getSecretStatus(statuses).Degraded).To(BeNil())
+		})
+
+		It("should report certificate close to expiry", func() {
+			createCertSecret(time.Now().Add(10*24*time.Hour + time.Hour))
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := getSecretStatus(statuses)
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(*status.Degraded).To(ContainSubstring("expires in 10 days"))
+			Expect(status.NotAvailable).To(BeNil())
+		})
+
+		It("should use configured warning window", func() {
+			request.Instance.Spec.TemplateValidator.CertExpiryWarningDays = 5
+			createCertSecret(time.Now().Add(10 * 24 * time.Hour))
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getSecretStatus(statuses).Degraded).To(BeNil())
+		})
+
+		It("should report expired certificate", func() {
+			createCertSecret(time.Now().Add(-time.Hour))
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			status := getSecretStatus(statuses)
+			Expect(status.Degraded).ToNot(BeNil())
+			Expect(status.NotAvailable).ToNot(BeNil())
+		})
+	})
+
+	Context("self-signed TLS provider", func() {
+		BeforeEach(func() {
+			request.Instance.Spec.TemplateValidator.TLSProvider = ssp.TLSProviderSelfSigned
+		})
+
+		getSecret := func() *core.Secret {
+			secret := newCertificateSecret(namespace)
+			ExpectWithOffset(1, request.Client.Get(request.Context, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+			return secret
+		}
+
+		getWebhook := func() *admission.ValidatingWebhookConfiguration {
+			key := client.ObjectKeyFromObject(newValidatingWebhook(namespace, admission.Fail))
+			webhook := &admission.ValidatingWebhookConfiguration{}
+			ExpectWithOffset(1, request.Client.Get(request.Context, key, webhook)).To(Succeed())
+			return webhook
+		}
+
+		It("should create certificate secret and inject CA bundle", func() {
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				if _, ok := status.Resource.(*core.Secret); ok {
+					Expect(status.Degraded).To(BeNil())
+				}
+			}
+
+			secret := getSecret()
+			Expect(secret.Type).To(Equal(core.SecretTypeTLS))
+			Expect(secret.Annotations).To(HaveKeyWithValue(SelfSignedCertAnnotation, "true"))
+			Expect(secret.Data[core.TLSPrivateKeyKey]).ToNot(BeEmpty())
+			Expect(secret.Data[caBundleKey]).To(Equal(secret.Data[core.TLSCertKey]))
+
+			cert, err := parseCertificate(secret.Data[core.TLSCertKey])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.DNSNames).To(ContainElement(ServiceName + "." + namespace + ".svc"))
+			_, err = tls.X509KeyPair(secret.Data[core.TLSCertKey], secret.Data[core.TLSPrivateKeyKey])
+			Expect(err).ToNot(HaveOccurred())
+
+			webhook := getWebhook()
+			Expect(webhook.Annotations).ToNot(HaveKey(InjectCABundleAnnotation))
+			Expect(webhook.Webhooks[0].ClientConfig.CABundle).To(Equal(secret.Data[caBundleKey]))
+
+			service := &core.Service{}
+			Expect(request.Client.Get(request.Context, client.ObjectKeyFromObject(newService(namespace)), service)).To(Succeed())
+			Expect(service.Annotations).ToNot(HaveKey(ServingCertSecretAnnotation))
+		})
+
+		It("should keep valid certificate", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			cert := getSecret().Data[core.TLSCertKey]
+
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getSecret().Data[core.TLSCertKey]).To(Equal(cert))
+		})
+
+		It("should renew expiring certificate", func() {
+			expiring := newTestCertificate(time.Now().Add(24 * time.Hour))
+			Expect(request.Client.Create(request.Context, &core.Secret{
+				ObjectMeta: meta.ObjectMeta{
+					Name:      SecretName,
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					core.TLSCertKey:       expiring,
+					core.TLSPrivateKeyKey: []byte("key"),
+					caBundleKey:           expiring,
+				},
+			})).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			secret := getSecret()
+			Expect(secret.Data[core.TLSCertKey]).ToNot(Equal(expiring))
+			cert, err := parseCertificate(secret.Data[core.TLSCertKey])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cert.NotAfter).To(BeTemporally(">", time.Now().Add(300*24*time.Hour)))
+			Expect(getWebhook().Webhooks[0].ClientConfig.CABundle).To(Equal(secret.Data[caBundleKey]))
+		})
+
+		It("should remove self-signed certificate when switching to service CA", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			ExpectResourceExists(newCertificateSecret(namespace), request)
+
+			request.Instance.Spec.TemplateValidator.TLSProvider = ssp.TLSProviderServiceCA
+			request.VersionCache = common.VersionCache{}
+			_, err = operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceNotExists(newCertificateSecret(namespace), request)
+			Expect(getWebhook().Annotations).To(HaveKeyWithValue(InjectCABundleAnnotation, "true"))
+		})
+	})
+
+	Context("with custom namespace", func() {
+		const validatorNamespace = "validator-namespace"
+
+		BeforeEach(func() {
+			request.Instance.Spec.TemplateValidator.Namespace = validatorNamespace
+		})
+
+		It("should create validator resources in the configured namespace", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			ExpectResourceExists(newServiceAccount(validatorNamespace), request)
+			ExpectResourceExists(newService(validatorNamespace), request)
+			ExpectResourceExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+
+			binding := newClusterRoleBinding(validatorNamespace)
+			ExpectResourceExists(binding, request)
+			Expect(binding.Subjects[0].Namespace).To(Equal(validatorNamespace))
+
+			webhook := newValidatingWebhook(validatorNamespace, admission.Fail)
+			ExpectResourceExists(webhook, request)
+			Expect(webhook.Webhooks[0].ClientConfig.Service.Namespace).To(Equal(validatorNamespace))
+
+			ExpectResourceNotExists(newDeployment(namespace, replicas, "test-img"), request)
+		})
+
+		It("should not set owner references", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := newDeployment(validatorNamespace, replicas, "test-img")
+			ExpectResourceExists(deployment, request)
+			Expect(deployment.GetOwnerReferences()).To(BeEmpty())
+		})
+
+		It("should remove namespaced resources on cleanup", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(operand.Cleanup(&request)).ToNot(HaveOccurred())
+
+			ExpectResourceNotExists(newServiceAccount(validatorNamespace), request)
+			ExpectResourceNotExists(newService(validatorNamespace), request)
+			ExpectResourceNotExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+			ExpectResourceNotExists(newPodDisruptionBudget(validatorNamespace, 0), request)
+			ExpectResourceNotExists(newClusterRoleBinding(validatorNamespace), request)
+			ExpectResourceNotExists(newValidatingWebhook(validatorNamespace, admission.Fail), request)
+		})
+
+		Context("when the namespace is changed", func() {
+			const newValidatorNamespace = "new-validator-namespace"
+
+			// reconcile reconciles the operand and copies the validator status, like the controller does
+			reconcile := func() {
+				statuses, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+				for _, status := range statuses {
+					if status.TemplateValidator != nil {
+						request.Instance.Status.TemplateValidator = status.TemplateValidator
+					}
+				}
+			}
+
+			BeforeEach(func() {
+				reconcile()
+				Expect(request.Instance.Status.TemplateValidator.Namespace).To(Equal(validatorNamespace))
+
+				request.Instance.Spec.TemplateValidator.Namespace = newValidatorNamespace
+				request.VersionCache = common.VersionCache{}
+			})
+
+			It("should move resources to the new namespace", func() {
+				reconcile()
+
+				ExpectResourceExists(newServiceAccount(newValidatorNamespace), request)
+				ExpectResourceExists(newService(newValidatorNamespace), request)
+				ExpectResourceExists(newDeployment(newValidatorNamespace, replicas, "test-img"), request)
+				ExpectResourceExists(newPodDisruptionBudget(newValidatorNamespace, 0), request)
+
+				ExpectResourceNotExists(newServiceAccount(validatorNamespace), request)
+				ExpectResourceNotExists(newService(validatorNamespace), request)
+				ExpectResourceNotExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+				ExpectResourceNotExists(newPodDisruptionBudget(validatorNamespace, 0), request)
+
+				webhook := newValidatingWebhook(newValidatorNamespace, admission.Fail)
+				ExpectResourceExists(webhook, request)
+				Expect(webhook.Webhooks[0].ClientConfig.Service.Namespace).To(Equal(newValidatorNamespace))
+
+				Expect(request.Instance.Status.TemplateValidator.Namespace).To(Equal(newValidatorNamespace))
+			})
+
+			It("should remove self-signed certificate from the previous namespace", func() {
+				request.Instance.Spec.TemplateValidator.Namespace = validatorNamespace
+				request.Instance.Spec.TemplateValidator.TLSProvider = ssp.TLSProviderSelfSigned
+				reconcile()
+				ExpectResourceExists(newCertificateSecret(validatorNamespace), request)
+
+				request.Instance.Spec.TemplateValidator.Namespace = newValidatorNamespace
+				request.VersionCache = common.VersionCache{}
+				reconcile()
+
+				ExpectResourceExists(newCertificateSecret(newValidatorNamespace), request)
+				ExpectResourceNotExists(newCertificateSecret(validatorNamespace), request)
+			})
+
+			It("should remove resources from the previous namespace on cleanup", func() {
+				Expect(operand.Cleanup(&request)).To(Succeed())
+
+				ExpectResourceNotExists(newServiceAccount(validatorNamespace), request)
+				ExpectResourceNotExists(newService(validatorNamespace), request)
+				ExpectResourceNotExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+				ExpectResourceNotExists(newPodDisruptionBudget(validatorNamespace, 0), request)
+			})
+
+			It("should not remove user provided certificate from the previous namespace", func() {
+				secret := newCertificateSecret(validatorNamespace)
+				Expect(request.Client.Create(request.Context, secret)).To(Succeed())
+
+				reconcile()
+				ExpectResourceExists(secret, request)
+
+				Expect(operand.Cleanup(&request)).To(Succeed())
+				ExpectResourceExists(secret, request)
+			})
+
+			It("should finish cleanup when deleting the certificate is forbidden", func() {
+				request.Instance.Spec.TemplateValidator.Namespace = validatorNamespace
+				request.Instance.Spec.TemplateValidator.TLSProvider = ssp.TLSProviderSelfSigned
+				reconcile()
+				ExpectResourceExists(newCertificateSecret(validatorNamespace), request)
+
+				request.Instance.Spec.TemplateValidator.Namespace = newValidatorNamespace
+				request.Client = &forbiddenSecretDeleteClient{Client: request.Client}
+
+				Expect(operand.Cleanup(&request)).To(Succeed())
+
+				ExpectResourceExists(newCertificateSecret(validatorNamespace), request)
+				ExpectResourceNotExists(newServiceAccount(validatorNamespace), request)
+				ExpectResourceNotExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+			})
+
+			It("should not remove resources from the previous namespace in dry-run mode", func() {
+				request.DryRun = true
+				_, err := operand.Reconcile(&request)
+				Expect(err).ToNot(HaveOccurred())
+
+				ExpectResourceExists(newDeployment(validatorNamespace, replicas, "test-img"), request)
+				Expect(request.Instance.Status.TemplateValidator.Namespace).To(Equal(validatorNamespace))
+			})
+		})
+	})
+
+	Context("with old validator image", func() {
+		BeforeEach(func() {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, "quay.io/kubevirt/kubevirt-template-validator:v0.1.0")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Unsetenv(common.TemplateValidatorImageKey)).To(Succeed())
+		})
+
+		It("should report degraded deployment", func() {
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			key := client.ObjectKeyFromObject(newDeployment(namespace, replicas, "test-img"))
+			updateDeployment(key, &request, func(deployment *apps.Deployment) {
+				deployment.Status.Replicas = replicas
+				deployment.Status.ReadyReplicas = replicas
+				deployment.Status.AvailableReplicas = replicas
+				deployment.Status.UpdatedReplicas = replicas
+			})
+
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, status := range statuses {
+				if _, ok := status.Resource.(*apps.Deployment); ok {
+					Expect(status.Degraded).ToNot(BeNil())
+					Expect(*status.Degraded).To(ContainSubstring("older than " + common_templates.MinTemplateValidatorVersion))
+				}
+			}
+		})
+	})
+
+	Context("TLS config", func() {
+		tlsConfig := &ssp.TLSConfig{
+			MinVersion:   ssp.TLSVersion12,
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+			DisableHTTP2: true,
+		}
+
+		deploymentArgs := func() []string {
+			key := client.ObjectKeyFromObject(newDeployment(namespace, replicas, "test-img"))
+			deployment := &apps.Deployment{}
+			Expect(request.Client.Get(request.Context, key, deployment)).To(Succeed())
+			return deployment.Spec.Template.Spec.Containers[0].Args
+		}
+
+		AfterEach(func() {
+			Expect(os.Unsetenv(common.TemplateValidatorImageKey)).To(Succeed())
+		})
+
+		It("should add TLS arguments", func() {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, "quay.io/kubevirt/kubevirt-template-validator:"+minTLSConfigVersion)).To(Succeed())
+			request.Instance.Spec.TemplateValidator.TLSConfig = tlsConfig
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deploymentArgs()).To(ContainElements(
+				"--tls-min-version=VersionTLS12",
+				"--tls-cipher-suites=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+				"--disable-http2",
+			))
+		})
+
+		It("should not add TLS arguments when not configured", func() {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, "quay.io/kubevirt/kubevirt-template-validator:"+minTLSConfigVersion)).To(Succeed())
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, arg := range deploymentArgs() {
+				Expect(arg).ToNot(HavePrefix("--tls-"))
+				Expect(arg).ToNot(Equal("--disable-http2"))
+			}
+		})
+
+		It("should report degraded and not add TLS arguments for old validator", func() {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, "quay.io/kubevirt/kubevirt-template-validator:v0.10.0")).To(Succeed())
+			request.Instance.Spec.TemplateValidator.TLSConfig = tlsConfig
+
+			_, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, arg := range deploymentArgs() {
+				Expect(arg).ToNot(HavePrefix("--tls-"))
+			}
+
+			key := client.ObjectKeyFromObject(newDeployment(namespace, replicas, "test-img"))
+			updateDeployment(key, &request, func(deployment *apps.Deployment) {
+				deployment.Status.Replicas = replicas
+				deployment.Status.AvailableReplicas = replicas
+			})
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				if _, ok := status.Resource.(*apps.Deployment); ok {
+					Expect(status.Degraded).ToNot(BeNil())
+					Expect(*status.Degraded).To(ContainSubstring("does not support TLS configuration"))
+				}
+			}
+		})
 	})
 
 	It("should report status", func() {
+		secret := &core.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      SecretName,
+				Namespace: namespace,
+			},
+			Data: map[string][]byte{
+				core.TLSCertKey: newTestCertificate(time.Now().Add(365 * 24 * time.Hour)),
+			},
+		}
+		Expect(request.Client.Create(request.Context, secret)).To(Succeed())
+
 		statuses, err := operand.Reconcile(&request)
 		Expect(err).ToNot(HaveOccurred())
 
@@ -188,8 +1204,144 @@ var _ = Describe("Template validator operand", func() {
 			Expect(status.Degraded).To(BeNil())
 		}
 	})
+
+	Context("upgrade summary", func() {
+		const (
+			previousImage = "quay.io/kubevirt/kubevirt-template-validator:v0.14.0"
+			newImage      = "quay.io/kubevirt/kubevirt-template-validator:v0.15.0"
+		)
+
+		AfterEach(func() {
+			Expect(os.Unsetenv(common.TemplateValidatorImageKey)).To(Succeed())
+		})
+
+		deploymentUpgrade := func(image string) *ssp.UpgradeSummary {
+			Expect(os.Setenv(common.TemplateValidatorImageKey, image)).To(Succeed())
+			request.VersionCache = common.VersionCache{}
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+			for _, status := range statuses {
+				if _, ok := status.Resource.(*apps.Deployment); ok {
+					return status.Upgrade
+				}
+			}
+			Fail("deployment status not found")
+			return nil
+		}
+
+		It("should report changed validator image", func() {
+			Expect(deploymentUpgrade(previousImage)).To(BeNil())
+			Expect(deploymentUpgrade(newImage)).To(Equal(&ssp.UpgradeSummary{
+				PreviousValidatorImage: previousImage,
+				ValidatorImage:         newImage,
+			}))
+		})
+
+		It("should not report unchanged validator image", func() {
+			Expect(deploymentUpgrade(newImage)).To(BeNil())
+			Expect(deploymentUpgrade(newImage)).To(BeNil())
+		})
+	})
+
+	Context("PodSecurity", func() {
+		createNamespace := func(level string) {
+			Expect(request.Client.Create(request.Context, &core.Namespace{
+				ObjectMeta: meta.ObjectMeta{
+					Name:   namespace,
+					Labels: map[string]string{podSecurityEnforceLabel: level},
+				},
+			})).To(Succeed())
+		}
+
+		restrictedDeployment := func() *apps.Deployment {
+			deployment := newDeployment(namespace, replicas, "test-img")
+			deployment.Spec.Template.Spec.SecurityContext = &core.PodSecurityContext{
+				RunAsNonRoot:   pointer.BoolPtr(true),
+				SeccompProfile: &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+			}
+			container := &deployment.Spec.Template.Spec.Containers[0]
+			container.SecurityContext.AllowPrivilegeEscalation = pointer.BoolPtr(false)
+			container.SecurityContext.Capabilities = &core.Capabilities{Drop: []core.Capability{"ALL"}}
+			return deployment
+		}
+
+		It("should not report pods without enforced level", func() {
+			violation, err := checkPodSecurity(&request, newDeployment(namespace, replicas, "test-img"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violation).To(BeNil())
+		})
+
+		It("should not report pods passing the restricted level", func() {
+			createNamespace(podSecurityRestricted)
+			violation, err := checkPodSecurity(&request, restrictedDeployment())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violation).To(BeNil())
+		})
+
+		It("should report pods violating the restricted level", func() {
+			createNamespace(podSecurityRestricted)
+			violation, err := checkPodSecurity(&request, newDeployment(namespace, replicas, "test-img"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violation).ToNot(BeNil())
+			Expect(*violation).To(ContainSubstring(`PodSecurity level "restricted"`))
+			Expect(*violation).To(ContainSubstring("runAsNonRoot != true"))
+			Expect(*violation).To(ContainSubstring("allowPrivilegeEscalation != false"))
+		})
+
+		It("should not report validator pods in baseline level", func() {
+			createNamespace(podSecurityBaseline)
+			violation, err := checkPodSecurity(&request, newDeployment(namespace, replicas, "test-img"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violation).To(BeNil())
+		})
+
+		It("should report privileged pods in baseline level", func() {
+			createNamespace(podSecurityBaseline)
+			deployment := newDeployment(namespace, replicas, "test-img")
+			deployment.Spec.Template.Spec.Containers[0].SecurityContext.Privileged = pointer.BoolPtr(true)
+			violation, err := checkPodSecurity(&request, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(violation).ToNot(BeNil())
+			Expect(*violation).To(ContainSubstring("privileged"))
+		})
+
+		It("should report degraded status and still reconcile deployment violating the level", func() {
+			createNamespace(podSecurityRestricted)
+			statuses, err := operand.Reconcile(&request)
+			Expect(err).ToNot(HaveOccurred())
+
+			var deploymentStatus *common.ResourceStatus
+			for i := range statuses {
+				if _, ok := statuses[i].Resource.(*apps.Deployment); ok {
+					deploymentStatus = &statuses[i]
+				}
+			}
+			Expect(deploymentStatus).ToNot(BeNil())
+			Expect(deploymentStatus.Degraded).ToNot(BeNil())
+			Expect(*deploymentStatus.Degraded).To(ContainSubstring("PodSecurity"))
+			ExpectResourceExists(newDeployment(namespace, replicas, "test-img"), request)
+		})
+	})
 })
 
+var _ = DescribeTable("Validator version check", func(image string, compatible bool) {
+	msg := checkValidatorVersion(image)
+	if compatible {
+		Expect(msg).To(BeNil())
+	} else {
+		Expect(msg).ToNot(BeNil())
+		Expect(*msg).To(ContainSubstring(common_templates.MinTemplateValidatorVersion))
+	}
+},
+	Entry("same version", "quay.io/kubevirt/kubevirt-template-validator:"+common_templates.MinTemplateValidatorVersion, true),
+	Entry("newer version", "quay.io/kubevirt/kubevirt-template-validator:v99.0.0", true),
+	Entry("older version", "quay.io/kubevirt/kubevirt-template-validator:v0.1.0", false),
+	Entry("older version without prefix", "quay.io/kubevirt/kubevirt-template-validator:0.1.0", false),
+	Entry("non-semver tag", "quay.io/kubevirt/kubevirt-template-validator:latest", true),
+	Entry("image digest", "quay.io/kubevirt/kubevirt-template-validator@sha256:0123456789abcdef", true),
+	Entry("registry port without tag", "localhost:5000/kubevirt-template-validator", true),
+)
+
 func updateDeployment(key client.ObjectKey, request *common.Request, updateFunc func(deployment *apps.Deployment)) {
 	deployment := &apps.Deployment{}
 	Expect(request.Client.Get(request.Context, key, deployment)).ToNot(HaveOccurred())
@@ -198,7 +1350,35 @@ func updateDeployment(key client.ObjectKey, request *common.Request, updateFunc
 	Expect(request.Client.Status().Update(request.Context, deployment)).ToNot(HaveOccurred())
 }
 
+// forbiddenSecretDeleteClient denies deletion of secrets, like a missing RBAC rule
+type forbiddenSecretDeleteClient struct {
+	client.Client
+}
+
+func (c *forbiddenSecretDeleteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if _, isSecret := obj.(*core.Secret); isSecret {
+		return errors.NewForbidden(core.Resource("secrets"), obj.GetName(), fmt.Errorf("secrets is forbidden"))
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
 func TestValidator(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Template Validator Suite")
 }
+
+func newTestCertificate(notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: ServiceName},
+		NotBefore:    notAfter.Add(-2 * 365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -6,6 +6,7 @@ import (
 	admission "k8s.io/api/admissionregistration/v1"
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 	rbac "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -29,6 +30,21 @@ const (
 	ServiceAccountName     = "template-validator"
 	ServiceName            = VirtTemplateValidator
 	DeploymentName         = VirtTemplateValidator
+
+	// PodDisruptionBudgetName is the name of the PodDisruptionBudget created when there are multiple replicas
+	PodDisruptionBudgetName = VirtTemplateValidator
+
+	// HealthzPath is the endpoint of the template validator checked by probes
+	HealthzPath = "/healthz"
+	// MetricsPath is the endpoint of the template validator serving Prometheus metrics
+	MetricsPath = "/metrics"
+
+	// ServingCertSecretAnnotation asks the service CA operator to create the serving certificate secret
+	ServingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+	// InjectCABundleAnnotation asks the service CA operator to inject the CA bundle to the webhook
+	InjectCABundleAnnotation = "service.beta.openshift.io/inject-cabundle"
+	// SelfSignedCertAnnotation marks the serving certificate secret created by the operator
+	SelfSignedCertAnnotation = "ssp.kubevirt.io/self-signed-cert"
 )
 
 func commonLabels() map[string]string {
@@ -95,7 +111,7 @@ func newService(namespace string) *core.Service {
 			Namespace: namespace,
 			Labels:    commonLabels(),
 			Annotations: map[string]string{
-				"service.beta.openshift.io/serving-cert-secret-name": SecretName,
+				ServingCertSecretAnnotation: SecretName,
 			},
 		},
 		Spec: core.ServiceSpec{
@@ -109,6 +125,22 @@ func newService(namespace string) *core.Service {
 	}
 }
 
+func newPodDisruptionBudget(namespace string, minAvailable int32) *policy.PodDisruptionBudget {
+	minAvailableVal := intstr.FromInt(int(minAvailable))
+	return &policy.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PodDisruptionBudgetName,
+			Namespace: namespace,
+		},
+		Spec: policy.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailableVal,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: commonLabels(),
+			},
+		},
+	}
+}
+
 func newDeployment(namespace string, replicas int32, image string) *apps.Deployment {
 	const volumeName = "tls"
 	const certMountPath = "/etc/webhook/certs"
@@ -139,7 +171,7 @@ func newDeployment(namespace string, replicas int32, image string) *apps.Deploym
 						Image:           image,
 						ImagePullPolicy: core.PullAlways,
 						Args: []string{
-							"-v=2",
+							fmt.Sprintf("-v=%d", defaultLogVerbosity),
 							fmt.Sprintf("--port=%d", ContainerPort),
 							fmt.Sprintf("--cert-dir=%s", certMountPath),
 						},
@@ -171,9 +203,8 @@ func newDeployment(namespace string, replicas int32, image string) *apps.Deploym
 	}
 }
 
-func newValidatingWebhook(namespace string) *admission.ValidatingWebhookConfiguration {
+func newValidatingWebhook(namespace string, failurePolicy admission.FailurePolicyType) *admission.ValidatingWebhookConfiguration {
 	path := "/virtualmachine-template-validate"
-	fail := admission.Fail
 	sideEffectsNone := admission.SideEffectClassNone
 
 	var rules []admission.RuleWithOperations
@@ -194,7 +225,7 @@ func newValidatingWebhook(namespace string) *admission.ValidatingWebhookConfigur
 		ObjectMeta: metav1.ObjectMeta{
 			Name: WebhookName,
 			Annotations: map[string]string{
-				"service.beta.openshift.io/inject-cabundle": "true",
+				InjectCABundleAnnotation: "true",
 			},
 		},
 		Webhooks: []admission.ValidatingWebhook{{
@@ -207,11 +238,10 @@ func newValidatingWebhook(namespace string) *admission.ValidatingWebhookConfigur
 				},
 			},
 			Rules: rules,
-			FailurePolicy: &fail,
+			FailurePolicy: &failurePolicy,
 			SideEffects:   &sideEffectsNone,
-			// TODO - add "v1" to the list once the template-validator
-			//        is updated to new API
-			AdmissionReviewVersions: []string{"v1beta1"},
+			// The API server uses the first version it supports
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
 		}},
 	}
 }
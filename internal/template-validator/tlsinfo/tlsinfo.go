@@ -23,6 +23,8 @@ const (
 
 type TLSInfo struct {
 	CertsDirectory string
+	MinVersion     uint16
+	CipherSuites   []uint16
 	cert           *tls.Certificate
 	certLock       sync.Mutex
 	stopCertReload chan struct{}
@@ -170,5 +172,43 @@ func (ti *TLSInfo) CrateTlsConfig() *tls.Config {
 			}
 			return cert, nil
 		},
+		MinVersion:   ti.MinVersion,
+		CipherSuites: ti.CipherSuites,
 	}
 }
+
+// ParseMinVersion returns the TLS version with the name used by the crypto/tls constants,
+// for example VersionTLS12. An empty name returns 0, so the default version is used.
+func ParseMinVersion(name string) (uint16, error) {
+	switch name {
+	case "":
+		return 0, nil
+	case "VersionTLS12":
+		return tls.VersionTLS12, nil
+	case "VersionTLS13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version: %s", name)
+	}
+}
+
+// ParseCipherSuites returns IDs of the cipher suites with the IANA names.
+// Only cipher suites without known security issues are accepted.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	ids := make(map[string]uint16, len(tls.CipherSuites()))
+	for _, suite := range tls.CipherSuites() {
+		ids[suite.Name] = suite.ID
+	}
+	result := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid or insecure TLS cipher suite: %s", name)
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
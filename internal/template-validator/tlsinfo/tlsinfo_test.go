@@ -90,6 +90,43 @@ var _ = Describe("TlsInfo", func() {
 	AfterEach(func() {
 		os.RemoveAll(certDir)
 	})
+
+	It("should apply minimum version and cipher suites", func() {
+		tlsInfo := TLSInfo{
+			MinVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		}
+		tlsConfig := tlsInfo.CrateTlsConfig()
+		Expect(tlsConfig.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+		Expect(tlsConfig.CipherSuites).To(Equal([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}))
+	})
+
+	It("should parse minimum version", func() {
+		version, err := ParseMinVersion("VersionTLS13")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(uint16(tls.VersionTLS13)))
+
+		version, err = ParseMinVersion("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(BeZero())
+
+		_, err = ParseMinVersion("VersionTLS10")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should parse cipher suites", func() {
+		suites, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(suites).To(Equal([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}))
+	})
+
+	It("should reject invalid and insecure cipher suites", func() {
+		_, err := ParseCipherSuites([]string{"TLS_NOT_A_CIPHER"})
+		Expect(err).To(MatchError(ContainSubstring("TLS_NOT_A_CIPHER")))
+
+		_, err = ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+		Expect(err).To(MatchError(ContainSubstring("TLS_RSA_WITH_RC4_128_SHA")))
+	})
 })
 
 func writeCertificate(dir string) {
@@ -1,10 +1,12 @@
 package validator
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
 
 	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	flag "github.com/spf13/pflag"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -34,9 +36,15 @@ func init() {
 
 type App struct {
 	service.ServiceListen
-	TLSInfo       tlsinfo.TLSInfo
-	versionOnly   bool
-	skipInformers bool
+	TLSInfo         tlsinfo.TLSInfo
+	versionOnly     bool
+	skipInformers   bool
+	tlsMinVersion   string
+	tlsCipherSuites []string
+	disableHTTP2    bool
+	auditWebhookURL string
+
+	maxConcurrentRequests int
 }
 
 var _ service.Service = &App{}
@@ -50,6 +58,11 @@ func (app *App) AddFlags() {
 	flag.StringVarP(&app.TLSInfo.CertsDirectory, "cert-dir", "c", "", "specify path to the directory containing TLS key and certificate - this enables TLS")
 	flag.BoolVarP(&app.versionOnly, "version", "V", false, "show version and exit")
 	flag.BoolVarP(&app.skipInformers, "skip-informers", "S", false, "don't initialize informerers - use this only in devel mode")
+	flag.StringVar(&app.tlsMinVersion, "tls-min-version", "", "minimum TLS version, VersionTLS12 or VersionTLS13")
+	flag.StringSliceVar(&app.tlsCipherSuites, "tls-cipher-suites", nil, "comma-separated list of enabled TLS cipher suites, using IANA names")
+	flag.BoolVar(&app.disableHTTP2, "disable-http2", false, "serve only HTTP/1.1")
+	flag.StringVar(&app.auditWebhookURL, "audit-webhook-url", "", "URL where admission decisions are posted for auditing")
+	flag.IntVar(&app.maxConcurrentRequests, "max-concurrent-requests", 0, "maximum number of admission reviews processed at the same time, 0 means unlimited")
 }
 
 func (app *App) KubevirtVersion() string {
@@ -64,6 +77,11 @@ func (app *App) Run() {
 		return
 	}
 
+	if err := app.parseTLSFlags(); err != nil {
+		log.Log.Criticalf("Invalid TLS configuration: %s", err)
+		panic(err)
+	}
+
 	app.TLSInfo.Init()
 	defer app.TLSInfo.Clean()
 
@@ -90,13 +108,29 @@ func (app *App) Run() {
 
 	log.Log.Infof("validator app: running with TLSInfo.CertsDirectory%+v", app.TLSInfo.CertsDirectory)
 
+	if app.auditWebhookURL != "" {
+		log.Log.Infof("validator app: posting admission decisions to %s", app.auditWebhookURL)
+		validating.SetAuditWebhookURL(app.auditWebhookURL)
+	}
+
+	if app.maxConcurrentRequests > 0 {
+		log.Log.Infof("validator app: processing at most %d admission reviews at the same time", app.maxConcurrentRequests)
+		validating.SetMaxConcurrentRequests(app.maxConcurrentRequests)
+	}
+
 	http.HandleFunc(validating.VMTemplateValidatePath,
 		func(w http.ResponseWriter, r *http.Request) {
 			validating.ServeVMTemplateValidate(w, r)
 		})
+	http.HandleFunc(validating.HealthzPath, validating.ServeHealthz)
+	http.Handle(validating.MetricsPath, promhttp.Handler())
 
 	if app.TLSInfo.IsEnabled() {
 		server := &http.Server{Addr: app.Address(), TLSConfig: app.TLSInfo.CrateTlsConfig()}
+		if app.disableHTTP2 {
+			// A non-nil empty map disables the automatic HTTP/2 support
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
 		log.Log.Infof("validator app: TLS configured, serving over HTTPS on %s", app.Address())
 		if err := server.ListenAndServeTLS("", ""); err != nil {
 			log.Log.Criticalf("Error listening TLS: %s", err)
@@ -110,3 +144,17 @@ func (app *App) Run() {
 		}
 	}
 }
+
+func (app *App) parseTLSFlags() error {
+	minVersion, err := tlsinfo.ParseMinVersion(app.tlsMinVersion)
+	if err != nil {
+		return err
+	}
+	cipherSuites, err := tlsinfo.ParseCipherSuites(app.tlsCipherSuites)
+	if err != nil {
+		return err
+	}
+	app.TLSInfo.MinVersion = minVersion
+	app.TLSInfo.CipherSuites = cipherSuites
+	return nil
+}
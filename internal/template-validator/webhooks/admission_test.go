@@ -1,13 +1,22 @@
 package validating
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"io/ioutil"
+	admissionv1 "k8s.io/api/admission/v1"
+	authv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k6tv1 "kubevirt.io/client-go/api/v1"
 	"kubevirt.io/ssp-operator/internal/template-validator/validation"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 var _ = Describe("Admission", func() {
@@ -108,6 +117,146 @@ var _ = Describe("Admission", func() {
 			Expect(vmRules[0].Name).To(Equal(ruleName))
 		})
 	})
+
+	Context("Audit webhook", func() {
+		var (
+			server  *httptest.Server
+			records chan AuditRecord
+		)
+
+		newRequest := func() *admissionv1.AdmissionRequest {
+			return &admissionv1.AdmissionRequest{
+				UID:       "test-uid",
+				Name:      "test-vm",
+				Namespace: "test-ns",
+				Operation: admissionv1.Create,
+				UserInfo:  authv1.UserInfo{Username: "test-user"},
+			}
+		}
+
+		BeforeEach(func() {
+			records = make(chan AuditRecord, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).ToNot(HaveOccurred())
+				record := AuditRecord{}
+				Expect(json.Unmarshal(body, &record)).To(Succeed())
+				records <- record
+			}))
+			SetAuditWebhookURL(server.URL)
+		})
+
+		AfterEach(func() {
+			SetAuditWebhookURL("")
+			server.Close()
+		})
+
+		It("should post rejected decision", func() {
+			response := ToAdmissionResponse([]metav1.StatusCause{{Message: "memory too low"}})
+			Eventually(auditDecision(newRequest(), response)).Should(BeClosed())
+
+			record := AuditRecord{}
+			Expect(records).To(Receive(&record))
+			Expect(record.UID).To(Equal("test-uid"))
+			Expect(record.Name).To(Equal("test-vm"))
+			Expect(record.Namespace).To(Equal("test-ns"))
+			Expect(record.Operation).To(Equal("CREATE"))
+			Expect(record.User).To(Equal("test-user"))
+			Expect(record.Allowed).To(BeFalse())
+			Expect(record.Message).To(Equal("memory too low"))
+		})
+
+		It("should post allowed decision", func() {
+			Eventually(auditDecision(newRequest(), ToAdmissionResponseOK())).Should(BeClosed())
+
+			record := AuditRecord{}
+			Expect(records).To(Receive(&record))
+			Expect(record.Allowed).To(BeTrue())
+			Expect(record.Message).To(BeEmpty())
+		})
+
+		It("should not post if URL is not set", func() {
+			SetAuditWebhookURL("")
+			Eventually(auditDecision(newRequest(), ToAdmissionResponseOK())).Should(BeClosed())
+			Expect(records).ToNot(Receive())
+		})
+	})
+
+	Context("Admission review versions", func() {
+		DescribeTable("should respond with the version of the request", func(apiVersion string) {
+			body := []byte(`{"apiVersion":"` + apiVersion + `","kind":"AdmissionReview","request":{"uid":"test-uid"}}`)
+			request := httptest.NewRequest(http.MethodPost, VMTemplateValidatePath, bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			serve(recorder, request, func(*admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
+				return ToAdmissionResponseOK()
+			})
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			response := admissionv1.AdmissionReview{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.APIVersion).To(Equal(apiVersion))
+			Expect(response.Kind).To(Equal("AdmissionReview"))
+			Expect(response.Response.UID).To(BeEquivalentTo("test-uid"))
+			Expect(response.Response.Allowed).To(BeTrue())
+		},
+			Entry("v1", "admission.k8s.io/v1"),
+			Entry("v1beta1", "admission.k8s.io/v1beta1"),
+		)
+	})
+
+	Context("Health endpoint", func() {
+		It("should report healthy", func() {
+			recorder := httptest.NewRecorder()
+			ServeHealthz(recorder, httptest.NewRequest(http.MethodGet, HealthzPath, nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(Equal("ok"))
+		})
+	})
+
+	Context("Concurrent requests limit", func() {
+		AfterEach(func() {
+			SetMaxConcurrentRequests(0)
+		})
+
+		It("should not limit requests by default", func() {
+			for i := 0; i < 10; i++ {
+				_, ok := acquireRequestSlot(context.Background())
+				Expect(ok).To(BeTrue())
+			}
+		})
+
+		It("should wait for a free slot", func() {
+			SetMaxConcurrentRequests(1)
+			release, ok := acquireRequestSlot(context.Background())
+			Expect(ok).To(BeTrue())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, ok = acquireRequestSlot(ctx)
+			Expect(ok).To(BeFalse())
+
+			release()
+			release, ok = acquireRequestSlot(context.Background())
+			Expect(ok).To(BeTrue())
+			release()
+		})
+
+		It("should reject canceled request over the limit", func() {
+			SetMaxConcurrentRequests(1)
+			release, _ := acquireRequestSlot(context.Background())
+			defer release()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			recorder := httptest.NewRecorder()
+			ServeVMTemplateValidate(recorder, httptest.NewRequest(http.MethodPost, VMTemplateValidatePath, nil).WithContext(ctx))
+			Expect(recorder.Code).To(Equal(http.StatusTooManyRequests))
+		})
+	})
 })
 
 func TestValidating(t *testing.T) {
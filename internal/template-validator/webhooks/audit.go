@@ -0,0 +1,101 @@
+package validating
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"kubevirt.io/client-go/log"
+)
+
+const auditRequestTimeout = 10 * time.Second
+
+// AuditRecord is the admission decision posted to the audit webhook
+type AuditRecord struct {
+	UID       string    `json:"uid"`
+	Timestamp time.Time `json:"timestamp"`
+	Operation string    `json:"operation"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	User      string    `json:"user"`
+	Allowed   bool      `json:"allowed"`
+	Message   string    `json:"message,omitempty"`
+}
+
+var (
+	auditLock   sync.RWMutex
+	auditURL    string
+	auditClient = &http.Client{Timeout: auditRequestTimeout}
+)
+
+// SetAuditWebhookURL sets the URL, where admission decisions are posted.
+// An empty URL disables posting.
+func SetAuditWebhookURL(url string) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	auditURL = url
+}
+
+func getAuditWebhookURL() string {
+	auditLock.RLock()
+	defer auditLock.RUnlock()
+	return auditURL
+}
+
+func newAuditRecord(request *admissionv1.AdmissionRequest, response *admissionv1.AdmissionResponse) *AuditRecord {
+	record := &AuditRecord{
+		UID:       string(request.UID),
+		Timestamp: time.Now().UTC(),
+		Operation: string(request.Operation),
+		Name:      request.Name,
+		Namespace: request.Namespace,
+		User:      request.UserInfo.Username,
+	}
+	if response != nil {
+		record.Allowed = response.Allowed
+		if response.Result != nil {
+			record.Message = response.Result.Message
+		}
+	}
+	return record
+}
+
+// auditDecision posts the admission decision to the audit webhook in the background,
+// if it is configured. Errors are only logged, they never change the admission decision.
+// The returned channel is closed when posting has finished.
+func auditDecision(request *admissionv1.AdmissionRequest, response *admissionv1.AdmissionResponse) <-chan struct{} {
+	done := make(chan struct{})
+	url := getAuditWebhookURL()
+	if url == "" || request == nil {
+		close(done)
+		return done
+	}
+	record := newAuditRecord(request, response)
+	go func() {
+		defer close(done)
+		if err := postAuditRecord(url, record); err != nil {
+			log.Log.Errorf("failed to post admission decision to audit webhook: %v", err)
+		}
+	}()
+	return done
+}
+
+func postAuditRecord(url string, record *AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resp, err := auditClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
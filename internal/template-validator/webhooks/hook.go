@@ -30,12 +30,29 @@ import (
 
 const (
 	VMTemplateValidatePath string = "/virtualmachine-template-validate"
+	HealthzPath            string = "/healthz"
+	MetricsPath            string = "/metrics"
 )
 
 func ServeVMTemplateValidate(resp http.ResponseWriter, req *http.Request) {
+	release, ok := acquireRequestSlot(req.Context())
+	if !ok {
+		resp.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	defer release()
 	serve(resp, req, admitVMTemplate)
 }
 
+// ServeHealthz reports the server as healthy. It is used by liveness and readiness probes,
+// which succeed only after the server is listening with its TLS certificate loaded.
+func ServeHealthz(resp http.ResponseWriter, _ *http.Request) {
+	resp.WriteHeader(http.StatusOK)
+	if _, err := resp.Write([]byte("ok")); err != nil {
+		log.Log.Errorf("failed to write health response: %v", err)
+	}
+}
+
 type admitFunc func(*admissionv1.AdmissionReview) *admissionv1.AdmissionResponse
 
 func admitVMTemplate(ar *admissionv1.AdmissionReview) *admissionv1.AdmissionResponse {
@@ -83,10 +100,13 @@ func serve(resp http.ResponseWriter, req *http.Request, admit admitFunc) {
 
 	log.Log.V(8).Infof("admission review response:\n%s", spew.Sdump(reviewResponse))
 
+	// The response has to use the same version as the request
+	response.TypeMeta = review.TypeMeta
 	if reviewResponse != nil {
 		response.Response = reviewResponse
 		response.Response.UID = review.Request.UID
 	}
+	auditDecision(review.Request, reviewResponse)
 	// reset the Object and OldObject, they are not needed in a response.
 	review.Request.Object = runtime.RawExtension{}
 	review.Request.OldObject = runtime.RawExtension{}
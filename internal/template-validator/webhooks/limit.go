@@ -0,0 +1,41 @@
+package validating
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	limitLock    sync.RWMutex
+	requestSlots chan struct{}
+)
+
+// SetMaxConcurrentRequests limits the number of admission reviews processed at the same time.
+// Requests over the limit wait until a slot is free. Zero disables the limit.
+func SetMaxConcurrentRequests(limit int) {
+	limitLock.Lock()
+	defer limitLock.Unlock()
+	if limit <= 0 {
+		requestSlots = nil
+		return
+	}
+	requestSlots = make(chan struct{}, limit)
+}
+
+// acquireRequestSlot waits until the request can be processed and returns the function
+// releasing its slot. It returns false, if the request was canceled while waiting.
+func acquireRequestSlot(ctx context.Context) (func(), bool) {
+	limitLock.RLock()
+	slots := requestSlots
+	limitLock.RUnlock()
+	if slots == nil {
+		return func() {}, true
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
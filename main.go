@@ -33,6 +33,8 @@ import (
 
 	sspv1beta1 "kubevirt.io/ssp-operator/api/v1beta1"
 	"kubevirt.io/ssp-operator/controllers"
+	"kubevirt.io/ssp-operator/internal/common"
+	common_templates "kubevirt.io/ssp-operator/internal/operands/common-templates"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -74,15 +76,67 @@ func main() {
 	var metricsAddr string
 	var readyProbeAddr string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&readyProbeAddr, "ready-probe-addr", ":9440", "The address the readiness probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"The namespace where the leader election lease is created. "+
+			"It has to be the namespace where the operator is installed. Defaults to the operator namespace.")
+	var templatesBundleDir string
+	flag.StringVar(&templatesBundleDir, "templates-bundle-dir", "",
+		"The directory containing the common templates bundles. "+
+			"It overrides the "+common.TemplatesBundleDirKey+" environment variable and the default directory.")
+	var dumpTemplates bool
+	var templatesBundle string
+	var templatesNamespace string
+	var goldenImagesNamespace string
+	flag.BoolVar(&dumpTemplates, "dump-templates", false,
+		"Print the common templates from the bundle as YAML and exit, without connecting to a cluster.")
+	flag.StringVar(&templatesBundle, "templates-bundle", "",
+		"The templates bundle file printed by --dump-templates. Defaults to the bundle deployed by the operator.")
+	flag.StringVar(&templatesNamespace, "templates-namespace", "openshift",
+		"The namespace set to templates printed by --dump-templates.")
+	flag.StringVar(&goldenImagesNamespace, "golden-images-namespace", common_templates.GoldenImagesNSname,
+		"The golden images namespace referenced by templates printed by --dump-templates.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	if templatesBundleDir != "" {
+		common_templates.SetBundleDir(templatesBundleDir)
+	}
+
+	if dumpTemplates {
+		if templatesBundle == "" {
+			templatesBundle = common_templates.DefaultBundleFile()
+		}
+		if err := common_templates.DumpTemplates(os.Stdout, templatesBundle, templatesNamespace, goldenImagesNamespace); err != nil {
+			setupLog.Error(err, "unable to dump common templates")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if enableLeaderElection {
+		operatorNamespace := os.Getenv(common.OperatorNamespaceKey)
+		if operatorNamespace == "" {
+			setupLog.Info("Operator namespace is not known, leader election namespace cannot be validated",
+				"env", common.OperatorNamespaceKey)
+		}
+		if err := common.CheckLeaderElectionNamespace(leaderElectionNamespace, operatorNamespace); err != nil {
+			setupLog.Error(err, "invalid leader election configuration")
+			os.Exit(1)
+		}
+	}
+
+	if err := common_templates.CheckBundleDir(); err != nil {
+		setupLog.Error(err, "invalid common templates bundle configuration")
+		os.Exit(1)
+	}
+
 	err := copyCertificates()
 	if err != nil {
 		setupLog.Error(err, "Error copying certificates")
@@ -90,21 +144,38 @@ func main() {
 	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		HealthProbeBindAddress: readyProbeAddr,
-		Port:                   9443,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       leaderElectionID,
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		HealthProbeBindAddress:  readyProbeAddr,
+		Port:                    9443,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+		NewCache:                common.SelectiveCacheBuilder(controllers.CacheSelectors()),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	managedResources := common.NewResourceRegistry()
+	if err = mgr.AddMetricsExtraHandler("/debug/managed-resources", managedResources); err != nil {
+		setupLog.Error(err, "unable to register managed resources endpoint")
+		os.Exit(1)
+	}
+	for path, handler := range controllers.DebugHandlers() {
+		if err = mgr.AddMetricsExtraHandler(path, handler); err != nil {
+			setupLog.Error(err, "unable to register debug endpoint", "path", path)
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.SSPReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("SSP"),
+		Client:           mgr.GetClient(),
+		Log:              ctrl.Log.WithName("controllers").WithName("SSP"),
+		Recorder:         mgr.GetEventRecorderFor("ssp-operator"),
+		APIReader:        mgr.GetAPIReader(),
+		ManagedResources: managedResources,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SSP")
 		os.Exit(1)
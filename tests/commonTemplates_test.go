@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/extensions/table"
@@ -204,6 +205,12 @@ var _ = Describe("Common templates", func() {
 		)
 	})
 
+	It("should report deployed common templates version in status", func() {
+		status := getSsp().Status
+		Expect(status.CommonTemplatesVersion).To(Equal(commonTemplates.Version))
+		Expect(status.ObservedVersion).ToNot(BeEmpty())
+	})
+
 	Context("resource change", func() {
 		table.DescribeTable("should restore modified resource", expectRestoreAfterUpdate,
 			table.Entry("[test_id:5315]edit cluster role", &editClusterRole),
@@ -238,6 +245,57 @@ var _ = Describe("Common templates", func() {
 		)
 	})
 
+	Context("unmanaged template", func() {
+		setUnmanaged := func(template *templatev1.Template, unmanaged bool) {
+			if template.Annotations == nil {
+				template.Annotations = map[string]string{}
+			}
+			if unmanaged {
+				template.Annotations[commonTemplates.TemplateUnmanagedAnnotation] = "true"
+			} else {
+				delete(template.Annotations, commonTemplates.TemplateUnmanagedAnnotation)
+			}
+		}
+
+		AfterEach(func() {
+			Eventually(func() error {
+				template := &templatev1.Template{}
+				if err := apiClient.Get(ctx, testTemplate.GetKey(), template); err != nil {
+					return err
+				}
+				setUnmanaged(template, false)
+				return apiClient.Update(ctx, template)
+			}, shortTimeout, time.Second).Should(Succeed())
+		})
+
+		It("should keep user changes and restore them after the annotation is removed", func() {
+			original := &templatev1.Template{}
+			Expect(apiClient.Get(ctx, testTemplate.GetKey(), original)).To(Succeed())
+
+			changed := original.DeepCopy()
+			setUnmanaged(changed, true)
+			changed.Parameters = append(changed.Parameters, templatev1.Parameter{Name: "CUSTOM_PARAMETER"})
+			Expect(apiClient.Update(ctx, changed)).To(Succeed())
+
+			Consistently(func() ([]templatev1.Parameter, error) {
+				found := &templatev1.Template{}
+				err := apiClient.Get(ctx, testTemplate.GetKey(), found)
+				return found.Parameters, err
+			}, pauseDuration, time.Second).Should(Equal(changed.Parameters))
+
+			found := &templatev1.Template{}
+			Expect(apiClient.Get(ctx, testTemplate.GetKey(), found)).To(Succeed())
+			setUnmanaged(found, false)
+			Expect(apiClient.Update(ctx, found)).To(Succeed())
+
+			Eventually(func() ([]templatev1.Parameter, error) {
+				found := &templatev1.Template{}
+				err := apiClient.Get(ctx, testTemplate.GetKey(), found)
+				return found.Parameters, err
+			}, timeout, time.Second).Should(Equal(original.Parameters))
+		})
+	})
+
 	Context("resource deletion", func() {
 		table.DescribeTable("recreate after delete", expectRecreateAfterDelete,
 			table.Entry("[test_id:4773]view role", &viewRole),
@@ -279,6 +337,8 @@ var _ = Describe("Common templates", func() {
 					Labels: map[string]string{
 						commonTemplates.TemplateVersionLabel: "not-latest",
 						commonTemplates.TemplateTypeLabel:    "base",
+						common.AppKubernetesNameLabel:        "common-templates",
+						common.AppKubernetesManagedByLabel:   "ssp-operator",
 						testOsLabel:                          "true",
 						testFlavorLabel:                      "true",
 						testWorkflowLabel:                    "true",
@@ -157,12 +157,12 @@ var _ = Describe("Validation webhook", func() {
 			strategy.RevertToOriginalSspCr()
 		})
 
-		It("[test_id:6057] should fail to update commonTemplates.namespace", func() {
+		It("[test_id:6057] should fail to update commonTemplates.namespace to a nonexistent namespace", func() {
 			originalNs := foundSsp.Spec.CommonTemplates.Namespace
 			foundSsp.Spec.CommonTemplates.Namespace = originalNs + "-updated"
 			err := apiClient.Update(ctx, foundSsp)
 			Expect(err).To(HaveOccurred())
-			Expect(err.Error()).To(ContainSubstring("commonTemplates.namespace cannot be changed."))
+			Expect(err.Error()).To(ContainSubstring("the configured namespace for common templates does not exist"))
 		})
 
 		Context("Placement API validation", func() {